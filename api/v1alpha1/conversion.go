@@ -33,3 +33,7 @@ func Convert_v1alpha2_RunnerSetSpec_To_v1alpha1_RunnerSetSpec(in *octorunv1.Runn
 func Convert_v1alpha2_RunnerSetStatus_To_v1alpha1_RunnerSetStatus(in *octorunv1.RunnerSetStatus, out *RunnerSetStatus, scope apiconversion.Scope) error {
 	return autoConvert_v1alpha2_RunnerSetStatus_To_v1alpha1_RunnerSetStatus(in, out, scope)
 }
+
+func Convert_v1alpha2_RunnerStatus_To_v1alpha1_RunnerStatus(in *octorunv1.RunnerStatus, out *RunnerStatus, scope apiconversion.Scope) error {
+	return autoConvert_v1alpha2_RunnerStatus_To_v1alpha1_RunnerStatus(in, out, scope)
+}
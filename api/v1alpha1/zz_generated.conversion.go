@@ -117,11 +117,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1alpha2.RunnerStatus)(nil), (*RunnerStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha2_RunnerStatus_To_v1alpha1_RunnerStatus(a.(*v1alpha2.RunnerStatus), b.(*RunnerStatus), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*RunnerTemplateSpec)(nil), (*v1alpha2.RunnerTemplateSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_RunnerTemplateSpec_To_v1alpha2_RunnerTemplateSpec(a.(*RunnerTemplateSpec), b.(*v1alpha2.RunnerTemplateSpec), scope)
 	}); err != nil {
@@ -147,6 +142,11 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*v1alpha2.RunnerStatus)(nil), (*RunnerStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_RunnerStatus_To_v1alpha1_RunnerStatus(a.(*v1alpha2.RunnerStatus), b.(*RunnerStatus), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -365,6 +365,9 @@ func autoConvert_v1alpha2_RunnerSetSpec_To_v1alpha1_RunnerSetSpec(in *v1alpha2.R
 	out.Selector = in.Selector
 	// WARNING: in.UpdateStrategy requires manual conversion: does not exist in peer-type
 	// WARNING: in.RevisionHistoryLimit requires manual conversion: does not exist in peer-type
+	// WARNING: in.IdlePolicy requires manual conversion: does not exist in peer-type
+	// WARNING: in.OnDemand requires manual conversion: does not exist in peer-type
+	// WARNING: in.VolumePools requires manual conversion: does not exist in peer-type
 	if err := Convert_v1alpha2_RunnerTemplateSpec_To_v1alpha1_RunnerTemplateSpec(&in.Template, &out.Template, s); err != nil {
 		return err
 	}
@@ -389,6 +392,7 @@ func autoConvert_v1alpha2_RunnerSetStatus_To_v1alpha1_RunnerSetStatus(in *v1alph
 	out.Runners = in.Runners
 	out.IdleRunners = in.IdleRunners
 	out.ActiveRunners = in.ActiveRunners
+	// WARNING: in.OnDemandRunners requires manual conversion: does not exist in peer-type
 	// WARNING: in.CurrentRevision requires manual conversion: does not exist in peer-type
 	// WARNING: in.NextRevision requires manual conversion: does not exist in peer-type
 	// WARNING: in.CollisionCount requires manual conversion: does not exist in peer-type
@@ -425,13 +429,19 @@ func Convert_v1alpha1_RunnerSpec_To_v1alpha2_RunnerSpec(in *RunnerSpec, out *v1a
 
 func autoConvert_v1alpha2_RunnerSpec_To_v1alpha1_RunnerSpec(in *v1alpha2.RunnerSpec, out *RunnerSpec, s conversion.Scope) error {
 	out.URL = in.URL
+	// WARNING: in.CredentialRef requires manual conversion: does not exist in peer-type
 	out.ID = (*int64)(unsafe.Pointer(in.ID))
 	out.OS = in.OS
 	out.Group = in.Group
 	out.Workdir = in.Workdir
+	// WARNING: in.RegistrationMode requires manual conversion: does not exist in peer-type
+	// WARNING: in.Lifecycle requires manual conversion: does not exist in peer-type
 	if err := Convert_v1alpha2_RunnerImage_To_v1alpha1_RunnerImage(&in.Image, &out.Image, s); err != nil {
 		return err
 	}
+	// WARNING: in.Docker requires manual conversion: does not exist in peer-type
+	// WARNING: in.ContainerMode requires manual conversion: does not exist in peer-type
+	// WARNING: in.WorkVolumeClaim requires manual conversion: does not exist in peer-type
 	// WARNING: in.EvictionPolicy requires manual conversion: does not exist in peer-type
 	if err := Convert_v1alpha2_RunnerPlacement_To_v1alpha1_RunnerPlacement(&in.Placement, &out.Placement, s); err != nil {
 		return err
@@ -442,6 +452,8 @@ func autoConvert_v1alpha2_RunnerSpec_To_v1alpha1_RunnerSpec(in *v1alpha2.RunnerS
 	out.RuntimeClassName = (*string)(unsafe.Pointer(in.RuntimeClassName))
 	out.Volumes = *(*[]v1.Volume)(unsafe.Pointer(&in.Volumes))
 	out.VolumeMounts = *(*[]v1.VolumeMount)(unsafe.Pointer(&in.VolumeMounts))
+	// WARNING: in.BackoffLimit requires manual conversion: does not exist in peer-type
+	// WARNING: in.PodTemplate requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -458,15 +470,15 @@ func Convert_v1alpha1_RunnerStatus_To_v1alpha2_RunnerStatus(in *RunnerStatus, ou
 
 func autoConvert_v1alpha2_RunnerStatus_To_v1alpha1_RunnerStatus(in *v1alpha2.RunnerStatus, out *RunnerStatus, s conversion.Scope) error {
 	out.Phase = RunnerPhase(in.Phase)
+	// WARNING: in.Reason requires manual conversion: does not exist in peer-type
+	// WARNING: in.Message requires manual conversion: does not exist in peer-type
+	// WARNING: in.Restarts requires manual conversion: does not exist in peer-type
+	// WARNING: in.IdleSince requires manual conversion: does not exist in peer-type
+	// WARNING: in.Job requires manual conversion: does not exist in peer-type
 	out.Conditions = *(*[]metav1.Condition)(unsafe.Pointer(&in.Conditions))
 	return nil
 }
 
-// Convert_v1alpha2_RunnerStatus_To_v1alpha1_RunnerStatus is an autogenerated conversion function.
-func Convert_v1alpha2_RunnerStatus_To_v1alpha1_RunnerStatus(in *v1alpha2.RunnerStatus, out *RunnerStatus, s conversion.Scope) error {
-	return autoConvert_v1alpha2_RunnerStatus_To_v1alpha1_RunnerStatus(in, out, s)
-}
-
 func autoConvert_v1alpha1_RunnerTemplateSpec_To_v1alpha2_RunnerTemplateSpec(in *RunnerTemplateSpec, out *v1alpha2.RunnerTemplateSpec, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1alpha1_RunnerSpec_To_v1alpha2_RunnerSpec(&in.Spec, &out.Spec, s); err != nil {
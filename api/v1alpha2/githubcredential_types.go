@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	GitHubCredentialConditionAuthenticated string = "githubcredential.octorun.github.io/Authenticated"
+)
+
+const (
+	CredentialAuthenticatedReason        string = "Authenticated"
+	CredentialAuthenticationFailedReason string = "AuthenticationFailed"
+	CredentialSecretNotFoundReason       string = "SecretNotFound"
+	CredentialInvalidSecretReason        string = "InvalidSecret"
+)
+
+// The keys of the GitHubCredential Secret.
+const (
+	// GitHubCredentialTokenKey is the Secret key of the Github Personal Access Token.
+	// It takes precedence if set along with the Github App keys.
+	GitHubCredentialTokenKey string = "token"
+	// GitHubCredentialAppIDKey is the Secret key of the Github App ID.
+	GitHubCredentialAppIDKey string = "appID"
+	// GitHubCredentialPrivateKeyKey is the Secret key of the Github App private key in PEM format.
+	GitHubCredentialPrivateKeyKey string = "privateKey"
+	// GitHubCredentialInstallationIDKey is the Secret key of the Github App installation ID.
+	// If not set, the installation is looked up for the owner of each Runner URL.
+	GitHubCredentialInstallationIDKey string = "installationID"
+)
+
+// GitHubCredentialReference identifies the GitHubCredential used by a Runner.
+type GitHubCredentialReference struct {
+	// Name of the GitHubCredential in the same namespace as the Runner.
+	Name string `json:"name"`
+}
+
+// GitHubCredentialSpec defines the desired state of GitHubCredential
+type GitHubCredentialSpec struct {
+	// SecretName is the name of the Secret in the same namespace that holds the credential.
+	// The Secret has either a "token" key with a Github Personal Access Token, or the "appID"
+	// and "privateKey" keys to authenticate as a Github App installation. The optional
+	// "installationID" key pins the installation, otherwise it is looked up for the owner
+	// of each Runner URL.
+	SecretName string `json:"secretName"`
+}
+
+// GitHubCredentialStatus defines the observed state of GitHubCredential
+type GitHubCredentialStatus struct {
+	// Conditions defines current service state of the credential.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Secret",type="string",description="The Secret holding the credential.",JSONPath=".spec.secretName"
+// +kubebuilder:printcolumn:name="Authenticated",type="string",description="Whether the credential authenticates to Github.",JSONPath=".status.conditions[?(@.type==\"githubcredential.octorun.github.io/Authenticated\")].status"
+// +kubebuilder:printcolumn:name="Age",type="date",description="Time duration since creation of GitHubCredential",JSONPath=".metadata.creationTimestamp"
+
+// GitHubCredential is the Schema for the githubcredentials API
+type GitHubCredential struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitHubCredentialSpec   `json:"spec,omitempty"`
+	Status GitHubCredentialStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GitHubCredentialList contains a list of GitHubCredential
+type GitHubCredentialList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitHubCredential `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitHubCredential{}, &GitHubCredentialList{})
+}
@@ -22,7 +22,8 @@ import (
 )
 
 const (
-	RunnerConditionOnline string = "runner.octorun.github.io/Online"
+	RunnerConditionOnline             string = "runner.octorun.github.io/Online"
+	RunnerConditionRegistrationFailed string = "runner.octorun.github.io/RegistrationFailed"
 )
 
 type RunnerEvictionPolicy string
@@ -35,12 +36,68 @@ const (
 )
 
 const (
-	RunnerBusyReason         string = "RunnerBusy"
-	RunnerOnlineReason       string = "RunnerOnline"
-	RunnerOfflineReason      string = "RunnerOffline"
-	RunnerPodPendingReason   string = "RunnerPodPending"
-	RunnerPodSucceededReason string = "RunnerPodSucceeded"
-	RunnerSecretFailedReason string = "RunnerSecretFailed"
+	RunnerBusyReason                  string = "RunnerBusy"
+	RunnerOnlineReason                string = "RunnerOnline"
+	RunnerOfflineReason               string = "RunnerOffline"
+	RunnerPodPendingReason            string = "RunnerPodPending"
+	RunnerPodSucceededReason          string = "RunnerPodSucceeded"
+	RunnerPodFailedReason             string = "RunnerPodFailed"
+	RunnerPodUnknownReason            string = "RunnerPodUnknown"
+	RunnerPodRecreatedReason          string = "RunnerPodRecreated"
+	RunnerBackoffLimitExceededReason  string = "BackoffLimitExceeded"
+	RunnerSecretFailedReason          string = "RunnerSecretFailed"
+	RunnerRemoveFailedReason          string = "RunnerRemoveFailed"
+	RunnerRegistrationForbiddenReason string = "RegistrationForbidden"
+	RunnerRegistrationNotFoundReason  string = "RegistrationNotFound"
+	RunnerRegistrationRetryReason     string = "RegistrationRetry"
+	RunnerJobStartedReason            string = "RunnerJobStarted"
+	RunnerJobCompletedReason          string = "RunnerJobCompleted"
+	RunnerCredentialFailedReason      string = "RunnerCredentialFailed"
+	RunnerRateLimitedReason           string = "RunnerRateLimited"
+)
+
+// RunnerRegistrationMode is the way the runner is registered to Github.
+// +kubebuilder:validation:Enum=Token;JIT
+type RunnerRegistrationMode string
+
+const (
+	// Token means that the runner registers itself from the runner pod using a registration token.
+	RunnerRegistrationToken RunnerRegistrationMode = "Token"
+	// JIT means that the controller registers the runner and passes a just-in-time runner
+	// configuration to the runner pod.
+	RunnerRegistrationJIT RunnerRegistrationMode = "JIT"
+)
+
+// RunnerLifecycle is how many jobs the runner takes before it is complete.
+// +kubebuilder:validation:Enum=Ephemeral;Persistent
+type RunnerLifecycle string
+
+const (
+	// Ephemeral means that the runner takes a single job and is complete once the job has finished.
+	RunnerLifecycleEphemeral RunnerLifecycle = "Ephemeral"
+	// Persistent means that the runner keeps taking jobs until the Runner is deleted.
+	RunnerLifecyclePersistent RunnerLifecycle = "Persistent"
+)
+
+// RunnerDockerMode is how the Docker daemon sidecar container runs.
+// +kubebuilder:validation:Enum=Rootless;Privileged
+type RunnerDockerMode string
+
+const (
+	// Rootless means that the Docker daemon runs as the runner user.
+	RunnerDockerRootless RunnerDockerMode = "Rootless"
+	// Privileged means that the Docker daemon runs as root.
+	RunnerDockerPrivileged RunnerDockerMode = "Privileged"
+)
+
+// RunnerContainerMode is where the job and service containers of the runner run.
+// +kubebuilder:validation:Enum=Kubernetes
+type RunnerContainerMode string
+
+const (
+	// Kubernetes means that the job and service containers run as separate pods
+	// created by the actions runner container hooks.
+	RunnerContainerModeKubernetes RunnerContainerMode = "Kubernetes"
 )
 
 type RunnerPhase string
@@ -57,6 +114,12 @@ const (
 	RunnerActivePhase RunnerPhase = "Active"
 	// Complete means the runner has already completed his job.
 	RunnerCompletePhase RunnerPhase = "Complete"
+
+	// Failed means the runner has failed and its pod will not be recreated.
+	// The reason is recorded in the runner status. A runner that failed to
+	// register to Github is retried once its spec has changed or after a
+	// while in case the Github credentials have changed.
+	RunnerFailedPhase RunnerPhase = "Failed"
 )
 
 type RunnerImage struct {
@@ -77,6 +140,25 @@ type RunnerImage struct {
 	PullSecrets []corev1.LocalObjectReference `json:"pullSecrets,omitempty"`
 }
 
+// RunnerDocker describes the Docker daemon sidecar container of the runner pod.
+type RunnerDocker struct {
+	// Mode can be Rootless or Privileged. Rootless runs the Docker daemon as the runner user,
+	// Privileged runs the Docker daemon as root. Both modes require a privileged container.
+	// Defaults to Rootless.
+	// +optional
+	// +kubebuilder:default=Rootless
+	Mode RunnerDockerMode `json:"mode,omitempty"`
+
+	// Docker daemon container image. Defaults to docker:dind-rootless for Rootless mode
+	// and docker:dind for Privileged mode.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Compute resources required by the Docker daemon container.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
 type RunnerPlacement struct {
 	// A selector which must be true for the pod to fit on a node.
 	// Selector which must match a node's labels for the pod to be scheduled on that node.
@@ -108,13 +190,19 @@ type RunnerTemplateSpec struct {
 
 // RunnerSpec defines the desired state of Runner
 type RunnerSpec struct {
-	// The github Organization or Repository URL for this runner.
-	// Must be a valid Github Org or Repository URL.
+	// The github Enterprise, Organization or Repository URL for this runner.
+	// Must be a valid Github Enterprise, Org or Repository URL.
 	// eg:
+	// 	- "https://github.com/enterprises/enterprise"
 	// 	- "https://github.com/org"
 	// 	- "https://github.com/org/repo"
 	URL string `json:"url"`
 
+	// CredentialRef points to the GitHubCredential used to register this runner.
+	// Defaults to the Github credential the controller has been started with.
+	// +optional
+	CredentialRef *GitHubCredentialReference `json:"credentialRef,omitempty"`
+
 	// ID of the runner assigned by Github, basically it is sequential number.
 	// Read-only.
 	// +optional
@@ -134,10 +222,41 @@ type RunnerSpec struct {
 	// +optional
 	Workdir string `json:"workdir,omitempty"`
 
+	// RegistrationMode can be Token or JIT. Token stores a registration token in the runner
+	// Secret and the runner registers itself. JIT registers the runner from the controller and
+	// stores a just-in-time runner configuration in the runner Secret instead, the runner ID
+	// is known before the runner pod starts. Defaults to Token.
+	// +optional
+	// +kubebuilder:default=Token
+	RegistrationMode RunnerRegistrationMode `json:"registrationMode,omitempty"`
+
+	// Lifecycle can be Ephemeral or Persistent. Ephemeral runners take a single job and are
+	// complete once the job has finished. Persistent runners keep their pod and take jobs
+	// until the Runner is deleted, it requires Token registration mode. Defaults to Ephemeral.
+	// +optional
+	// +kubebuilder:default=Ephemeral
+	Lifecycle RunnerLifecycle `json:"lifecycle,omitempty"`
+
 	// Runner container image specification
 	// +optional
 	Image RunnerImage `json:"image,omitempty"`
 
+	// Docker adds a Docker daemon sidecar container to the runner pod. The runner container
+	// reaches the Docker daemon through a shared socket, the runner image only needs the Docker CLI.
+	// +optional
+	Docker *RunnerDocker `json:"docker,omitempty"`
+
+	// ContainerMode can be Kubernetes. Kubernetes runs the job and service containers as separate
+	// pods through the actions runner container hooks instead of requiring Docker in the runner pod.
+	// The runner work directory is a per runner PersistentVolumeClaim shared with those pods.
+	// +optional
+	ContainerMode RunnerContainerMode `json:"containerMode,omitempty"`
+
+	// WorkVolumeClaim is the spec of the runner work directory PersistentVolumeClaim in Kubernetes
+	// container mode. Defaults to a 1Gi ReadWriteOnce volume of the default StorageClass.
+	// +optional
+	WorkVolumeClaim *corev1.PersistentVolumeClaimSpec `json:"workVolumeClaim,omitempty"`
+
 	// EvictionPolicy can be Never or IfNotActive.
 	// IfNotActive will annotate the runner Pod with `cluster-autoscaler.kubernetes.io/safe-to-evict=true` once created
 	// and will be removed when Runner become Active (has assigned job) to allow Kubernetes cluster-autoscaler eviction
@@ -178,6 +297,64 @@ type RunnerSpec struct {
 	// Runner pod volumes to mount into the runner container filesystem.
 	// +optional
 	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+
+	// Specifies the number of times the runner pod is recreated after it has
+	// failed before the runner is marked as Failed. The pod is recreated with
+	// an exponential backoff delay (10s, 20s, 40s ...) capped at 5 minutes.
+	// Defaults to 3.
+	// +optional
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=0
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// PodTemplate is strategically merged on top of the runner pod generated from this spec.
+	// Containers, init containers, env and volumes are merged by name, the runner container
+	// is named `runner`. It allows to set any pod field not exposed by this spec, eg: sidecars,
+	// hostAliases, dnsConfig, priorityClassName, topologySpreadConstraints or pod securityContext.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	PodTemplate *corev1.PodTemplateSpec `json:"podTemplate,omitempty"`
+}
+
+// RunnerJobStatus describes the Github workflow job of a runner.
+type RunnerJobStatus struct {
+	// ID of the job assigned by Github.
+	// +optional
+	ID int64 `json:"id,omitempty"`
+
+	// Name of the job.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// RunID is the ID of the workflow run the job belongs to.
+	// +optional
+	RunID int64 `json:"runID,omitempty"`
+
+	// WorkflowName is the name of the workflow the job belongs to.
+	// +optional
+	WorkflowName string `json:"workflowName,omitempty"`
+
+	// Repository is the full name of the repository the job runs for. eg: octorun/octorun
+	// +optional
+	Repository string `json:"repository,omitempty"`
+
+	// HeadBranch is the branch the workflow run was triggered on.
+	// +optional
+	HeadBranch string `json:"headBranch,omitempty"`
+
+	// URL is the Github web page of the job.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Conclusion of the job once it has completed. eg: success, failure or cancelled.
+	// +optional
+	Conclusion string `json:"conclusion,omitempty"`
+
+	// CompletionTime is the time the job has completed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 }
 
 // RunnerStatus defines the observed state of Runner
@@ -186,6 +363,27 @@ type RunnerStatus struct {
 	// +optional
 	Phase RunnerPhase `json:"phase,omitempty"`
 
+	// A brief CamelCase message indicating details about why the runner is in this phase.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// A human readable message indicating details about why the runner is in this phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// The number of times the runner pod has been recreated after it failed.
+	// +optional
+	Restarts int32 `json:"restarts,omitempty"`
+
+	// IdleSince is the time the runner became idle.
+	// It is cleared once the runner got a job.
+	// +optional
+	IdleSince *metav1.Time `json:"idleSince,omitempty"`
+
+	// Job is the last Github workflow job of the runner.
+	// +optional
+	Job *RunnerJobStatus `json:"job,omitempty"`
+
 	// Conditions defines current service state of the runner.
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
@@ -196,7 +394,11 @@ type RunnerStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="RunnerID",type="string",description="ID of the runner assigned by Github, basically it is sequential number.",JSONPath=".spec.id"
 // +kubebuilder:printcolumn:name="Status",type="string",description="Represents the current phase of the runner.",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Restarts",type="integer",description="The number of times the runner pod has been recreated.",JSONPath=".status.restarts",priority=10
 // +kubebuilder:printcolumn:name="Online",type="string",description="Represents the current Online status of the runner.",JSONPath=".status.conditions[?(@.type==\"runner.octorun.github.io/Online\")].status"
+// +kubebuilder:printcolumn:name="Repository",type="string",description="The repository of the last workflow job of the runner.",JSONPath=".status.job.repository",priority=10
+// +kubebuilder:printcolumn:name="Workflow",type="string",description="The workflow of the last workflow job of the runner.",JSONPath=".status.job.workflowName",priority=10
+// +kubebuilder:printcolumn:name="Job",type="string",description="The name of the last workflow job of the runner.",JSONPath=".status.job.name",priority=10
 // +kubebuilder:printcolumn:name="URL",type="string",description="The github Organization or Repository URL for this runner.",JSONPath=".spec.url",priority=10
 // +kubebuilder:printcolumn:name="RunnerGroup",type="string",description="RunnerGroup of the runner",JSONPath=".spec.group",priority=10
 // +kubebuilder:printcolumn:name="Age",type="date",description="Time duration since creation of Runner",JSONPath=".metadata.creationTimestamp"
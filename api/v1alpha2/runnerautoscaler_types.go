@@ -0,0 +1,165 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	RunnerAutoscalerConditionAbleToScale string = "runnerautoscaler.octorun.github.io/AbleToScale"
+)
+
+const (
+	RunnerSetNotFoundReason    string = "RunnerSetNotFound"
+	RunnerSetScaledReason      string = "RunnerSetScaled"
+	RunnerSetScaleFailedReason string = "RunnerSetScaleFailed"
+	ScaleDownCooldownReason    string = "ScaleDownCooldown"
+	ReadyForNewScaleReason     string = "ReadyForNewScale"
+	InvalidScheduleReason      string = "InvalidSchedule"
+)
+
+// RunnerAutoscalerTargetRef identifies the RunnerSet to be scaled.
+type RunnerAutoscalerTargetRef struct {
+	// Name of the RunnerSet in the same namespace as the RunnerAutoscaler.
+	Name string `json:"name"`
+}
+
+// RunnerAutoscalerSchedule is a recurring time window in which the autoscaler
+// keeps a different lower limit for the number of runners.
+type RunnerAutoscalerSchedule struct {
+	// Name of the schedule. It is reported in status when the schedule is active.
+	Name string `json:"name"`
+
+	// Days of the week on which the time window starts.
+	// Defaults to every day.
+	// +optional
+	Days []ScheduleDay `json:"days,omitempty"`
+
+	// Start is the time of the day the window starts, in 24-hour "HH:MM" format.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+
+	// End is the time of the day the window ends, in 24-hour "HH:MM" format.
+	// If End is not after Start, the window ends on the following day.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	End string `json:"end"`
+
+	// TimeZone is the IANA time zone name in which Start and End are
+	// interpreted. eg: "Europe/Berlin". Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// MinRunners is the lower limit for the number of runners while the
+	// schedule is active. It overrides the spec MinRunners and cannot be
+	// greater than the spec MaxRunners.
+	// +kubebuilder:validation:Minimum=0
+	MinRunners int32 `json:"minRunners"`
+}
+
+// ScheduleDay is a day of the week.
+// +kubebuilder:validation:Enum=Sunday;Monday;Tuesday;Wednesday;Thursday;Friday;Saturday
+type ScheduleDay string
+
+// RunnerAutoscalerSpec defines the desired state of RunnerAutoscaler
+type RunnerAutoscalerSpec struct {
+	// ScaleTargetRef points to the RunnerSet to be scaled through its
+	// scale subresource. The autoscaler owns the runners of the target
+	// RunnerSet, manual changes to them are overridden.
+	ScaleTargetRef RunnerAutoscalerTargetRef `json:"scaleTargetRef"`
+
+	// MinRunners is the lower limit for the number of runners that can be
+	// set by the autoscaler. Defaults to 1.
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=0
+	MinRunners *int32 `json:"minRunners,omitempty"`
+
+	// MaxRunners is the upper limit for the number of runners that can be
+	// set by the autoscaler. It cannot be less than MinRunners.
+	// +kubebuilder:validation:Minimum=1
+	MaxRunners int32 `json:"maxRunners"`
+
+	// CooldownPeriod is the duration to wait after the last scale event
+	// before the target RunnerSet is allowed to scale down. Defaults to 5m.
+	// +optional
+	// +kubebuilder:default="5m"
+	CooldownPeriod *metav1.Duration `json:"cooldownPeriod,omitempty"`
+
+	// Schedules is a list of time windows that override MinRunners, within
+	// MaxRunners, while they are active. eg: keep 10 runners on weekdays
+	// during working hours.
+	// If several schedules are active the first one in the list is used.
+	// +optional
+	Schedules []RunnerAutoscalerSchedule `json:"schedules,omitempty"`
+}
+
+// RunnerAutoscalerStatus defines the observed state of RunnerAutoscaler
+type RunnerAutoscalerStatus struct {
+	// InflightJobs is the number of workflow jobs for the target RunnerSet, that is
+	// the queued WorkflowJobs labeled for it plus its active Runners.
+	// +optional
+	InflightJobs int32 `json:"inflightJobs"`
+
+	// DesiredRunners is the number of runners last calculated by the autoscaler.
+	// +optional
+	DesiredRunners int32 `json:"desiredRunners"`
+
+	// ActiveSchedule is the name of the currently active schedule.
+	// +optional
+	ActiveSchedule string `json:"activeSchedule,omitempty"`
+
+	// LastScaleTime is the last time the autoscaler scaled the target RunnerSet.
+	// +optional
+	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
+
+	// Conditions defines current service state of the autoscaler.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Target",type="string",description="The target RunnerSet name.",JSONPath=".spec.scaleTargetRef.name"
+// +kubebuilder:printcolumn:name="Min",type="integer",description="The lower limit for the number of runners.",JSONPath=".spec.minRunners"
+// +kubebuilder:printcolumn:name="Max",type="integer",description="The upper limit for the number of runners.",JSONPath=".spec.maxRunners"
+// +kubebuilder:printcolumn:name="Jobs",type="integer",description="Represents the current number of the inflight jobs.",JSONPath=".status.inflightJobs"
+// +kubebuilder:printcolumn:name="Desired",type="integer",description="Represents the desired number of the runner.",JSONPath=".status.desiredRunners"
+// +kubebuilder:printcolumn:name="Schedule",type="string",description="The currently active schedule.",JSONPath=".status.activeSchedule"
+// +kubebuilder:printcolumn:name="Age",type="date",description="Time duration since creation of RunnerAutoscaler",JSONPath=".metadata.creationTimestamp"
+
+// RunnerAutoscaler is the Schema for the runnerautoscalers API
+type RunnerAutoscaler struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RunnerAutoscalerSpec   `json:"spec,omitempty"`
+	Status RunnerAutoscalerStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RunnerAutoscalerList contains a list of RunnerAutoscaler
+type RunnerAutoscalerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RunnerAutoscaler `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RunnerAutoscaler{}, &RunnerAutoscalerList{})
+}
@@ -17,13 +17,23 @@ limitations under the License.
 package v1alpha2
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	RunnerSetConditionRegistrationFailed string = "runnerset.octorun.github.io/RegistrationFailed"
+)
+
 const (
 	RunnerAdoptedReason string = "RunnerAdopted"
 	RunnerCreatedReason string = "RunnerCreated"
 	RunnerDeletedReason string = "RunnerDeleted"
+	RunnerFailedReason  string = "RunnerFailed"
+
+	RunnersRegisteredReason string = "RunnersRegistered"
+
+	VolumePoolExhaustedReason string = "VolumePoolExhausted"
 )
 
 // RunnerSetUpdateStrategyType is a string enumeration type that enumerates
@@ -51,6 +61,76 @@ type RunnerSetUpdateStrategy struct {
 	Type RunnerSetUpdateStrategyType `json:"type,omitempty"`
 }
 
+// RunnerSetIdlePolicy describes how many idle runners the RunnerSet keeps
+// and for how long.
+type RunnerSetIdlePolicy struct {
+	// MinIdleRunners is the number of idle runners to keep on top of
+	// the active runners. Defaults to 0.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MinIdleRunners int32 `json:"minIdleRunners,omitempty"`
+
+	// MaxIdleDuration is how long an idle runner that is no longer needed
+	// is allowed to wait for a job before it is deleted. If not set, idle
+	// runners that are no longer needed are deleted immediately.
+	// +optional
+	MaxIdleDuration *metav1.Duration `json:"maxIdleDuration,omitempty"`
+}
+
+// VolumePoolReclaimPolicy is what happens to the pool PersistentVolumeClaims
+// once they are no longer part of the pool.
+type VolumePoolReclaimPolicy string
+
+const (
+	// VolumePoolReclaimDelete means that the PersistentVolumeClaims are deleted when the pool
+	// shrinks or together with the RunnerSet.
+	VolumePoolReclaimDelete VolumePoolReclaimPolicy = "Delete"
+	// VolumePoolReclaimRetain means that the PersistentVolumeClaims are kept when the pool
+	// shrinks or the RunnerSet is deleted.
+	VolumePoolReclaimRetain VolumePoolReclaimPolicy = "Retain"
+)
+
+// VolumePoolWipePolicy is what happens to the content of a pool PersistentVolumeClaim
+// once it is returned to the pool.
+type VolumePoolWipePolicy string
+
+const (
+	// VolumePoolWipeNever means that the content is kept for the next Runner.
+	VolumePoolWipeNever VolumePoolWipePolicy = "Never"
+	// VolumePoolWipeRecreate means that the PersistentVolumeClaim is deleted and created again
+	// with an empty volume.
+	VolumePoolWipeRecreate VolumePoolWipePolicy = "Recreate"
+)
+
+// RunnerSetVolumePool describes a pool of PersistentVolumeClaims handed out to new Runners
+// of the RunnerSet and returned to the pool when the Runner is deleted.
+type RunnerSetVolumePool struct {
+	// Name of the volume added to the Runners. The runner template mounts it with VolumeMounts.
+	Name string `json:"name"`
+
+	// Size is the number of PersistentVolumeClaims in the pool. A Runner created while
+	// every PersistentVolumeClaim is in use gets an emptyDir volume instead.
+	// +kubebuilder:validation:Minimum=0
+	Size int32 `json:"size"`
+
+	// VolumeClaimSpec is the spec of the pool PersistentVolumeClaims, eg: the StorageClass and size.
+	VolumeClaimSpec corev1.PersistentVolumeClaimSpec `json:"volumeClaimSpec"`
+
+	// ReclaimPolicy can be Delete or Retain. Delete removes the PersistentVolumeClaims
+	// when the pool shrinks or together with the RunnerSet. Defaults to Delete.
+	// +optional
+	// +kubebuilder:default=Delete
+	// +kubebuilder:validation:Enum=Delete;Retain
+	ReclaimPolicy VolumePoolReclaimPolicy `json:"reclaimPolicy,omitempty"`
+
+	// WipePolicy can be Never or Recreate. Never keeps the volume content for the next Runner,
+	// Recreate deletes the PersistentVolumeClaim once returned and creates an empty one. Defaults to Never.
+	// +optional
+	// +kubebuilder:default=Never
+	// +kubebuilder:validation:Enum=Never;Recreate
+	WipePolicy VolumePoolWipePolicy `json:"wipePolicy,omitempty"`
+}
+
 // RunnerSetSpec defines the desired state of RunnerSet
 type RunnerSetSpec struct {
 	// Runners is the number of desired runners. This is a pointer
@@ -76,6 +156,29 @@ type RunnerSetSpec struct {
 	// +kubebuilder:default=10
 	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
 
+	// IdlePolicy makes the number of runners follow the number of active
+	// runners. When set, Runners becomes the upper limit for the number of
+	// runners and the RunnerSet only keeps MinIdleRunners idle runners on top
+	// of the active runners.
+	// +optional
+	IdlePolicy *RunnerSetIdlePolicy `json:"idlePolicy,omitempty"`
+
+	// OnDemand allows the Github webhook handler to create an additional Runner
+	// from the template for every queued workflow job whose labels match the
+	// template labels. This is meant for RunnerSets with zero runners that only
+	// wake up when there is a job to run. On-demand Runners are not counted in
+	// the desired runners and are removed once they complete.
+	// +optional
+	OnDemand bool `json:"onDemand,omitempty"`
+
+	// VolumePools are pools of PersistentVolumeClaims handed out to new Runners as volumes,
+	// eg: the work directory or the tool cache, and returned to the pool once the Runner is deleted.
+	// On-demand Runners get an emptyDir volume instead.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	VolumePools []RunnerSetVolumePool `json:"volumePools,omitempty"`
+
 	// Template is the object that describes the runner that will be created if
 	// insufficient replicas are detected.
 	// +optional
@@ -96,6 +199,10 @@ type RunnerSetStatus struct {
 	// +optional
 	ActiveRunners int32 `json:"activeRunners"`
 
+	// The number of on-demand runners for this RunnerSet.
+	// +optional
+	OnDemandRunners int32 `json:"onDemandRunners,omitempty"`
+
 	// CurrentRevision indicates the revision of RunnerSet.
 	// +optional
 	CurrentRevision string `json:"currentRevision,omitempty"`
@@ -128,6 +235,7 @@ type RunnerSetStatus struct {
 // +kubebuilder:printcolumn:name="Runners",type="integer",description="Represents the current number of the runner.",JSONPath=".status.runners"
 // +kubebuilder:printcolumn:name="Idle",type="integer",description="Represents the current number of the idle runner.",JSONPath=".status.idleRunners"
 // +kubebuilder:printcolumn:name="Active",type="integer",description="Represents the current number of the active runner.",JSONPath=".status.activeRunners"
+// +kubebuilder:printcolumn:name="OnDemand",type="integer",description="Represents the current number of the on-demand runner.",JSONPath=".status.onDemandRunners",priority=10
 // +kubebuilder:printcolumn:name="Age",type="date",description="Time duration since creation of RunnerSet",JSONPath=".metadata.creationTimestamp"
 
 // RunnerSet is the Schema for the runnersets API
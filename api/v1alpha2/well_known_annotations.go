@@ -22,7 +22,53 @@ const (
 	// github webhook handler to retrigger runner controller reconciliation.
 	AnnotationRunnerAssignedJobAt = "runner.octorun.github.io/assigned-job-at"
 
+	// AnnotationRunnerJobID, AnnotationRunnerJobName, AnnotationRunnerJobRunID, AnnotationRunnerJobWorkflow,
+	// AnnotationRunnerJobRepository, AnnotationRunnerJobHeadBranch and AnnotationRunnerJobURL are used to note
+	// the details of the Github Workflow Job a runner has picked up. They are set by github webhook handler
+	// and the runner controller copies them into the Runner status.
+	AnnotationRunnerJobID         = "runner.octorun.github.io/job-id"
+	AnnotationRunnerJobName       = "runner.octorun.github.io/job-name"
+	AnnotationRunnerJobRunID      = "runner.octorun.github.io/job-run-id"
+	AnnotationRunnerJobWorkflow   = "runner.octorun.github.io/job-workflow"
+	AnnotationRunnerJobRepository = "runner.octorun.github.io/job-repository"
+	AnnotationRunnerJobHeadBranch = "runner.octorun.github.io/job-head-branch"
+	AnnotationRunnerJobURL        = "runner.octorun.github.io/job-url"
+
+	// AnnotationRunnerJobCompletedAt is used to note when the Github Workflow Job of a runner
+	// has completed. It is set by github webhook handler and the runner controller completes
+	// an ephemeral runner right away based on this annotation.
+	AnnotationRunnerJobCompletedAt = "runner.octorun.github.io/job-completed-at"
+
+	// AnnotationRunnerJobConclusion is used to note the conclusion of the completed
+	// Github Workflow Job of a runner. eg: success, failure or cancelled.
+	AnnotationRunnerJobConclusion = "runner.octorun.github.io/job-conclusion"
+
 	// AnnotationRunnerTokenExpiresAt is used to note when the registration token will expire.
 	// The runner controller will refresh the token if needed based on this annotation.
 	AnnotationRunnerTokenExpiresAt = "runner.octorun.github.io/token-expires-at"
+
+	// AnnotationRunnerOnDemand is used to indicate that a runner was created by
+	// github webhook handler for a single queued Github Workflow Job. The RunnerSet
+	// controller does not count this runner as part of its desired runners.
+	AnnotationRunnerOnDemand = "runner.octorun.github.io/on-demand"
+
+	// AnnotationRunnerOnDemandJobID is used to note the ID of the queued Github Workflow Job
+	// an on-demand runner was created for. github webhook handler creates a single runner
+	// for a workflow job even if the queued event is delivered several times.
+	AnnotationRunnerOnDemandJobID = "runner.octorun.github.io/on-demand-job-id"
+
+	// AnnotationRunnerProvisioned is used to indicate that a runner has been online
+	// at least once. The runner controller observes the provisioning duration of a
+	// runner only before setting this annotation.
+	AnnotationRunnerProvisioned = "runner.octorun.github.io/provisioned"
+
+	// AnnotationRunnerRegistrationCredentialVersion is used to note the version of the GitHubCredential,
+	// and of its Secret, a runner has failed to register with. The runner controller retries the
+	// registration right away once the version has changed, eg: the credential has been fixed.
+	AnnotationRunnerRegistrationCredentialVersion = "runner.octorun.github.io/registration-credential-version"
+
+	// AnnotationVolumeClaimedBy is used to note which Runner a RunnerSet volume pool
+	// PersistentVolumeClaim is handed out to. The RunnerSet controller wipes the
+	// PersistentVolumeClaim once this Runner has gone if its pool asks so.
+	AnnotationVolumeClaimedBy = "runnerset.octorun.github.io/claimed-by"
 )
@@ -69,4 +69,21 @@ const (
 	LabelRunnerSetName = LabelPrefix + "runnerset"
 
 	LabelControllerRevisionHash = LabelPrefix + "revision-hash"
+
+	// LabelControllerID is used to label the Github runner with the identity of the
+	// controller that registered it, so the runner garbage collector of a controller
+	// only removes the runners it has registered. The controller adds this label to
+	// every Github runner it registers, it is not a label of the Runner resource.
+	//
+	// Example:
+	//	controller-id=3f1c2e9a-8a47-4c3b-9d0e-6f1a2b3c4d5e
+	LabelControllerID = LabelPrefix + "controller-id"
+
+	// LabelWorkflowJobID is used to label the WorkflowJob with the ID of the Github
+	// workflow job it records, so the WorkflowJob can be found in any namespace.
+	LabelWorkflowJobID = LabelPrefix + "workflow-job-id"
+
+	// LabelVolumePool is used to label the PersistentVolumeClaims of a RunnerSet
+	// volume pool with the pool name.
+	LabelVolumePool = LabelPrefix + "volume-pool"
 )
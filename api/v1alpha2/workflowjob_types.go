@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkflowJobPhase is a label for the condition of a workflow job at the current time.
+type WorkflowJobPhase string
+
+// These are the valid phases of workflow jobs.
+const (
+	// WorkflowJobQueuedPhase means the workflow job is waiting for a runner to pick it up.
+	WorkflowJobQueuedPhase WorkflowJobPhase = "Queued"
+	// WorkflowJobInProgressPhase means a runner has picked up the workflow job.
+	WorkflowJobInProgressPhase WorkflowJobPhase = "InProgress"
+	// WorkflowJobCompletedPhase means the workflow job has completed.
+	WorkflowJobCompletedPhase WorkflowJobPhase = "Completed"
+)
+
+// WorkflowJobSpec defines the Github workflow job a WorkflowJob records.
+type WorkflowJobSpec struct {
+	// ID of the job assigned by Github.
+	ID int64 `json:"id"`
+
+	// Name of the job.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// RunID is the ID of the workflow run the job belongs to.
+	// +optional
+	RunID int64 `json:"runID,omitempty"`
+
+	// WorkflowName is the name of the workflow the job belongs to.
+	// +optional
+	WorkflowName string `json:"workflowName,omitempty"`
+
+	// Repository is the full name of the repository the job runs for. eg: octorun/octorun
+	// +optional
+	Repository string `json:"repository,omitempty"`
+
+	// HeadBranch is the branch the workflow run was triggered on.
+	// +optional
+	HeadBranch string `json:"headBranch,omitempty"`
+
+	// URL is the Github web page of the job.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Labels are the runner labels from the `runs-on:` key of the job.
+	// +optional
+	Labels []string `json:"labels,omitempty"`
+}
+
+// WorkflowJobStatus defines the observed state of WorkflowJob
+type WorkflowJobStatus struct {
+	// Phase represents the current phase of the workflow job.
+	// +optional
+	Phase WorkflowJobPhase `json:"phase,omitempty"`
+
+	// Conclusion of the job once it has completed. eg: success, failure or cancelled.
+	// +optional
+	Conclusion string `json:"conclusion,omitempty"`
+
+	// QueuedTime is the time the job has been queued.
+	// +optional
+	QueuedTime *metav1.Time `json:"queuedTime,omitempty"`
+
+	// StartTime is the time a runner has picked up the job.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time the job has completed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// RunnerName is the name of the Runner that picked up the job.
+	// +optional
+	RunnerName string `json:"runnerName,omitempty"`
+
+	// PodName is the name of the runner pod that ran the job.
+	// +optional
+	PodName string `json:"podName,omitempty"`
+
+	// NodeName is the name of the node the runner pod was scheduled to.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Repository",type="string",description="The repository of the workflow job.",JSONPath=".spec.repository"
+// +kubebuilder:printcolumn:name="Workflow",type="string",description="The workflow of the workflow job.",JSONPath=".spec.workflowName"
+// +kubebuilder:printcolumn:name="Job",type="string",description="The name of the workflow job.",JSONPath=".spec.name"
+// +kubebuilder:printcolumn:name="Status",type="string",description="Represents the current phase of the workflow job.",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Conclusion",type="string",description="The conclusion of the completed workflow job.",JSONPath=".status.conclusion"
+// +kubebuilder:printcolumn:name="Runner",type="string",description="The Runner that picked up the workflow job.",JSONPath=".status.runnerName"
+// +kubebuilder:printcolumn:name="Node",type="string",description="The node the runner pod was scheduled to.",JSONPath=".status.nodeName",priority=10
+// +kubebuilder:printcolumn:name="Branch",type="string",description="The branch the workflow run was triggered on.",JSONPath=".spec.headBranch",priority=10
+// +kubebuilder:printcolumn:name="Age",type="date",description="Time duration since creation of WorkflowJob",JSONPath=".metadata.creationTimestamp"
+
+// WorkflowJob is the Schema for the workflowjobs API.
+// It records a Github workflow job run by an octorun Runner.
+type WorkflowJob struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WorkflowJobSpec   `json:"spec,omitempty"`
+	Status WorkflowJobStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WorkflowJobList contains a list of WorkflowJob
+type WorkflowJobList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WorkflowJob `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WorkflowJob{}, &WorkflowJobList{})
+}
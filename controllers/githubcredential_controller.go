@@ -0,0 +1,166 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+	"octorun.github.io/octorun/pkg/github"
+	"octorun.github.io/octorun/util/patch"
+)
+
+const GitHubCredentialController = "githubcredential.octorun.github.io/controller"
+
+// credentialResyncPeriod is how often an authenticated GitHubCredential is checked again,
+// eg: the Github App may have been uninstalled or the Personal Access Token revoked.
+const credentialResyncPeriod = 10 * time.Minute
+
+// credentialRetryPeriod is how often a GitHubCredential that failed to authenticate is checked again.
+const credentialRetryPeriod = time.Minute
+
+// GitHubCredentialReconciler reconciles a GitHubCredential object
+type GitHubCredentialReconciler struct {
+	client.Client
+	Scheme      *runtime.Scheme
+	Recorder    record.EventRecorder
+	Credentials github.ClientGetter
+}
+
+// +kubebuilder:rbac:groups=octorun.github.io,resources=githubcredentials,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=octorun.github.io,resources=githubcredentials/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=octorun.github.io,resources=githubcredentials/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;update;patch
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GitHubCredentialReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&octorunv1.GitHubCredential{}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.secretToCredentials)).
+		Complete(r)
+}
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *GitHubCredentialReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+	credential := &octorunv1.GitHubCredential{}
+	if err := r.Get(ctx, req.NamespacedName, credential); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Return early if requested credential is not found.
+			log.V(1).Info("GitHubCredential resource not found or already deleted")
+			r.Credentials.Forget(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	patcher, err := patch.NewPatcher(r.Client, credential)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		if err := patcher.Patch(ctx, credential, client.FieldOwner(GitHubCredentialController)); err != nil {
+			reterr = err
+		}
+	}()
+
+	if !credential.GetDeletionTimestamp().IsZero() {
+		r.Credentials.Forget(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	reason, authErr := r.authenticate(ctx, credential)
+	if authErr != nil {
+		log.Info("GitHubCredential failed to authenticate", "reason", reason, "error", authErr.Error())
+		r.Recorder.Eventf(credential, corev1.EventTypeWarning, reason, "Unable to authenticate to Github: %v", authErr)
+		meta.SetStatusCondition(&credential.Status.Conditions, metav1.Condition{
+			Type:               octorunv1.GitHubCredentialConditionAuthenticated,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: credential.Generation,
+			Reason:             reason,
+			Message:            fmt.Sprintf("Unable to authenticate to Github: %v", authErr),
+		})
+		return ctrl.Result{RequeueAfter: credentialRetryPeriod}, nil
+	}
+
+	log.V(1).Info("GitHubCredential authenticated")
+	meta.SetStatusCondition(&credential.Status.Conditions, metav1.Condition{
+		Type:               octorunv1.GitHubCredentialConditionAuthenticated,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: credential.Generation,
+		Reason:             octorunv1.CredentialAuthenticatedReason,
+		Message:            "Authenticated to Github",
+	})
+	return ctrl.Result{RequeueAfter: credentialResyncPeriod}, nil
+}
+
+// authenticate builds the Github client of the given credential and checks it is accepted by Github.
+// It returns the condition reason along with the error if the credential is not usable.
+func (r *GitHubCredentialReconciler) authenticate(ctx context.Context, credential *octorunv1.GitHubCredential) (string, error) {
+	ghc, err := r.Credentials.ClientFor(ctx, client.ObjectKeyFromObject(credential))
+	switch {
+	case apierrors.IsNotFound(err):
+		return octorunv1.CredentialSecretNotFoundReason, fmt.Errorf("secret %s not found", credential.Spec.SecretName)
+	case errors.Is(err, github.ErrInvalidCredentialSecret):
+		return octorunv1.CredentialInvalidSecretReason, err
+	case err != nil:
+		return octorunv1.CredentialAuthenticationFailedReason, err
+	}
+
+	if err := ghc.Authenticate(ctx); err != nil {
+		return octorunv1.CredentialAuthenticationFailedReason, err
+	}
+
+	return octorunv1.CredentialAuthenticatedReason, nil
+}
+
+// secretToCredentials maps the given Secret to the GitHubCredentials referencing it.
+func (r *GitHubCredentialReconciler) secretToCredentials(o client.Object) []reconcile.Request {
+	credentialList := &octorunv1.GitHubCredentialList{}
+	if err := r.List(context.Background(), credentialList, client.InNamespace(o.GetNamespace())); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range credentialList.Items {
+		credential := &credentialList.Items[i]
+		if credential.Spec.SecretName == o.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(credential)})
+		}
+	}
+
+	return requests
+}
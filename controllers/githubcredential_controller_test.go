@@ -0,0 +1,199 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+	"octorun.github.io/octorun/pkg/github"
+	mghclient "octorun.github.io/octorun/pkg/github/client/mock"
+)
+
+// fakeClientGetter returns the Github Client registered for the GitHubCredential name,
+// or the given error. Unknown GitHubCredentials are reported as not found.
+type fakeClientGetter struct {
+	clients   map[string]github.Client
+	err       error
+	forgotten []client.ObjectKey
+}
+
+func (f *fakeClientGetter) ClientFor(ctx context.Context, key client.ObjectKey) (github.Client, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	ghc, ok := f.clients[key.Name]
+	if !ok {
+		return nil, apierrors.NewNotFound(octorunv1.GroupVersion.WithResource("githubcredentials").GroupResource(), key.Name)
+	}
+
+	return ghc, nil
+}
+
+func (f *fakeClientGetter) Forget(key client.ObjectKey) {
+	f.forgotten = append(f.forgotten, key)
+}
+
+func TestGitHubCredentialReconciler_Reconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+
+	tests := []struct {
+		name         string
+		credentialFn func(credential *octorunv1.GitHubCredential) *octorunv1.GitHubCredential
+		getterErr    error
+		expectFn     func(cmockr *mghclient.MockClientMockRecorder)
+		wantRequeue  bool
+		wantStatus   metav1.ConditionStatus
+		wantReason   string
+		wantForgot   bool
+		wantErr      bool
+	}{
+		{
+			name: "credential_not_found",
+			credentialFn: func(credential *octorunv1.GitHubCredential) *octorunv1.GitHubCredential {
+				return &octorunv1.GitHubCredential{}
+			},
+			expectFn:   func(cmockr *mghclient.MockClientMockRecorder) {},
+			wantForgot: true,
+			wantErr:    false,
+		},
+		{
+			name:         "credential_authenticated",
+			credentialFn: func(credential *octorunv1.GitHubCredential) *octorunv1.GitHubCredential { return credential },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.Authenticate(gomock.Any()).Return(nil)
+			},
+			wantRequeue: true,
+			wantStatus:  metav1.ConditionTrue,
+			wantReason:  octorunv1.CredentialAuthenticatedReason,
+			wantErr:     false,
+		},
+		{
+			name:         "credential_authentication_failed",
+			credentialFn: func(credential *octorunv1.GitHubCredential) *octorunv1.GitHubCredential { return credential },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.Authenticate(gomock.Any()).Return(errors.New("401 Bad credentials"))
+			},
+			wantRequeue: true,
+			wantStatus:  metav1.ConditionFalse,
+			wantReason:  octorunv1.CredentialAuthenticationFailedReason,
+			wantErr:     false,
+		},
+		{
+			name:         "credential_secret_not_found",
+			credentialFn: func(credential *octorunv1.GitHubCredential) *octorunv1.GitHubCredential { return credential },
+			getterErr:    apierrors.NewNotFound(corev1.Resource("secrets"), "octorun-github"),
+			expectFn:     func(cmockr *mghclient.MockClientMockRecorder) {},
+			wantRequeue:  true,
+			wantStatus:   metav1.ConditionFalse,
+			wantReason:   octorunv1.CredentialSecretNotFoundReason,
+			wantErr:      false,
+		},
+		{
+			name:         "credential_invalid_secret",
+			credentialFn: func(credential *octorunv1.GitHubCredential) *octorunv1.GitHubCredential { return credential },
+			getterErr:    fmt.Errorf("%w: missing %q key", github.ErrInvalidCredentialSecret, octorunv1.GitHubCredentialTokenKey),
+			expectFn:     func(cmockr *mghclient.MockClientMockRecorder) {},
+			wantRequeue:  true,
+			wantStatus:   metav1.ConditionFalse,
+			wantReason:   octorunv1.CredentialInvalidSecretReason,
+			wantErr:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mghc := mghclient.NewMockClient(mockCtrl)
+			tt.expectFn(mghc.EXPECT())
+
+			credential := tt.credentialFn(&octorunv1.GitHubCredential{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "credential-test",
+					Namespace: "default",
+				},
+				Spec: octorunv1.GitHubCredentialSpec{
+					SecretName: "octorun-github",
+				},
+			})
+
+			fakec := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(credential).
+				Build()
+
+			getter := &fakeClientGetter{
+				clients: map[string]github.Client{"credential-test": mghc},
+				err:     tt.getterErr,
+			}
+			r := &GitHubCredentialReconciler{
+				Client:      fakec,
+				Scheme:      scheme,
+				Recorder:    record.NewFakeRecorder(10),
+				Credentials: getter,
+			}
+
+			got, err := r.Reconcile(context.Background(), reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      "credential-test",
+					Namespace: "default",
+				},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GitHubCredentialReconciler.Reconcile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if (got.RequeueAfter > 0) != tt.wantRequeue {
+				t.Errorf("GitHubCredentialReconciler.Reconcile() = %v, wantRequeue %v", got, tt.wantRequeue)
+			}
+			if forgot := len(getter.forgotten) > 0; forgot != tt.wantForgot {
+				t.Errorf("GitHubCredentialReconciler.Reconcile() forgotten = %v, wantForgot %v", getter.forgotten, tt.wantForgot)
+			}
+
+			if credential.Name != "" {
+				gotCredential := &octorunv1.GitHubCredential{}
+				if err := fakec.Get(context.Background(), client.ObjectKeyFromObject(credential), gotCredential); err != nil {
+					t.Errorf("unexpected Get error: %v", err)
+					return
+				}
+				cond := meta.FindStatusCondition(gotCredential.Status.Conditions, octorunv1.GitHubCredentialConditionAuthenticated)
+				if cond == nil || cond.Status != tt.wantStatus || cond.Reason != tt.wantReason {
+					t.Errorf("GitHubCredential conditions = %v, want status %v reason %v", gotCredential.Status.Conditions, tt.wantStatus, tt.wantReason)
+				}
+			}
+		})
+	}
+}
@@ -18,12 +18,17 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"path"
 	"strings"
 	"time"
 
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
@@ -31,9 +36,14 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+	"octorun.github.io/octorun/metrics"
 	"octorun.github.io/octorun/pkg/github"
+	ghclient "octorun.github.io/octorun/pkg/github/client"
 	gherrors "octorun.github.io/octorun/pkg/github/errors"
 	"octorun.github.io/octorun/util"
 	"octorun.github.io/octorun/util/annotations"
@@ -44,35 +54,69 @@ import (
 
 const RunnerController = "runner.octorun.github.io/controller"
 
+// registrationRetryPeriod is the period after which a runner that failed to
+// register to Github is retried even though neither its spec nor its credential has changed.
+const registrationRetryPeriod = 10 * time.Minute
+
+// persistentRunnerSyncPeriod is the period to check whether a busy persistent
+// runner has finished its job since its runner pod keeps running.
+const persistentRunnerSyncPeriod = 30 * time.Second
+
 // RunnerReconciler reconciles a Runner object
 type RunnerReconciler struct {
 	client.Client
 	Github   github.Client
 	Scheme   *runtime.Scheme
-	Executor remoteexec.RemoteExecutor
 	Recorder record.EventRecorder
+
+	// Credentials provides the Github client of the runners referencing a GitHubCredential.
+	Credentials github.ClientGetter
+
+	// Executor is used to read the runner id from the runner pod when the runner is not found
+	// on Github by its name. It is optional and requires pods/exec permission.
+	Executor remoteexec.RemoteExecutor
+
+	// Poller provides the Github runners status listed periodically for every runner URL.
+	// It is optional, the runners are get from Github one by one without it.
+	Poller *RunnerPoller
+
+	// ControllerID is passed to every Github runner registered by the controller as the
+	// controller-id label, so the runner garbage collector only removes its own runners.
+	ControllerID string
 }
 
 // +kubebuilder:rbac:groups=octorun.github.io,resources=runners,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=octorun.github.io,resources=runners/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=octorun.github.io,resources=runners/finalizers,verbs=update
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;update;patch
-// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete;deletecollection
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;create;update;patch;delete;deletecollection
 // +kubebuilder:rbac:groups=core,resources=pods/status,verbs=get
-// +kubebuilder:rbac:groups=core,resources=pods/exec,verbs=create
+// +kubebuilder:rbac:groups=core,resources=pods/exec,verbs=get;create
+// +kubebuilder:rbac:groups=core,resources=pods/log,verbs=get;list;watch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;create;delete;deletecollection
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims;serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=octorun.github.io,resources=githubcredentials,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *RunnerReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&octorunv1.Runner{}).
 		Owns(&corev1.Pod{}).
-		Complete(r)
+		Watches(&source.Kind{Type: &octorunv1.GitHubCredential{}}, handler.EnqueueRequestsFromMapFunc(r.credentialToRunners)).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.secretToRunners))
+	if r.Poller != nil {
+		builder = builder.Watches(r.Poller.Events(), &handler.EnqueueRequestForObject{})
+	}
+
+	return builder.Complete(r)
 }
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, reterr error) {
 	log := ctrl.LoggerFrom(ctx)
 	runner := &octorunv1.Runner{}
 	if err := r.Get(ctx, req.NamespacedName, runner); err != nil {
@@ -91,14 +135,40 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ c
 	}
 
 	defer func() {
+		// Do not retry hot while the Github API rate limit is exceeded, wait until it is reset instead.
+		if retryAfter, ok := gherrors.RateLimitRetryAfter(reterr); ok {
+			log.Info("Github API rate limit exceeded. Requeueing once it is reset", "after", retryAfter, "error", reterr.Error())
+			r.Recorder.Eventf(runner, corev1.EventTypeWarning, octorunv1.RunnerRateLimitedReason, "Github API rate limit exceeded. Retrying in %s", retryAfter.Round(time.Second))
+			result, reterr = ctrl.Result{RequeueAfter: retryAfter}, nil
+		}
+
 		if err := patcher.Patch(ctx, runner, client.FieldOwner(RunnerController)); err != nil {
 			reterr = err
 		}
 	}()
 
-	runnerPod := podForRunner(runner)
+	ghc, err := r.githubClientFor(ctx, runner)
+	if err != nil && (runner.GetDeletionTimestamp().IsZero() || !apierrors.IsNotFound(err)) {
+		log.Error(err, "unable to get the Github client of the Runner credential")
+		r.Recorder.Eventf(runner, corev1.EventTypeWarning, octorunv1.RunnerCredentialFailedReason, "Unable to get Github credential: %v", err)
+		return ctrl.Result{}, err
+	}
+
+	runnerPod := podForRunner(runner, r.ControllerID)
 	runnerSecret := secretForRunner(runner)
 	if !runner.GetDeletionTimestamp().IsZero() {
+		// The GitHubCredential of the runner may have been deleted already (eg: the namespace is being deleted).
+		// The runner is deleted without talking to Github then, it is up to the runner GC to remove it from Github.
+		if ghc == nil {
+			log.Info("Runner credential has gone. Deleting Runner without removing it from Github")
+		}
+
+		// The Github webhook hook may have noted the runner job completion already.
+		if r.reconcileJob(runner) && runner.Status.Phase == octorunv1.RunnerActivePhase {
+			log.Info("Runner has completed its job")
+			runner.Status.Phase = octorunv1.RunnerCompletePhase
+		}
+
 		// Handle deletion if we have non zero deletion timestamp
 		// by cleaning up owned resources.
 		if runner.Status.Phase == octorunv1.RunnerActivePhase {
@@ -107,14 +177,14 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ c
 
 			// Check runner status from Github.
 			runnerid := pointer.Int64Deref(runner.Spec.ID, -1)
-			if runnerid == -1 {
+			if runnerid == -1 || ghc == nil {
 				// This is unexpected condition when runner has Active phase
-				// but don't have an ID.
+				// but don't have an ID or credential.
 				runner.Status.Phase = octorunv1.RunnerCompletePhase
 				return ctrl.Result{Requeue: true}, nil
 			}
 
-			ghrunner, err := r.Github.GetRunner(ctx, runner.Spec.URL, runnerid)
+			ghrunner, err := r.getRunner(ctx, ghc, runner, runnerid)
 			if err != nil && !(gherrors.IsForbidden(err) || gherrors.IsNotFound(err)) {
 				log.Error(err, "unable to retrieve Runner information from Github")
 				return ctrl.Result{}, err
@@ -130,9 +200,9 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ c
 
 		log.Info("deleting Runner resources")
 		if _, err := ctrl.CreateOrUpdate(ctx, r.Client, runnerSecret, func() error {
-			if annotations.IsTokenExpired(runnerSecret) {
+			if ghc != nil && runner.Spec.RegistrationMode != octorunv1.RunnerRegistrationJIT && annotations.IsTokenExpired(runnerSecret) {
 				log.V(1).Info("registration token has expired. Refresh before deleting", "secret", runnerSecret.Name)
-				rt, err := r.Github.CreateRunnerToken(ctx, runner.Spec.URL)
+				rt, err := ghc.CreateRunnerToken(ctx, runner.Spec.URL)
 				if err != nil && !(gherrors.IsForbidden(err) || gherrors.IsNotFound(err)) {
 					return err
 				}
@@ -155,6 +225,38 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ c
 			return ctrl.Result{}, err
 		}
 
+		// Wait until the Runner pod has gone before removing the runner from Github. The runner
+		// removes itself on graceful termination and its cleanup keeps retrying if the runner
+		// has already been removed. It will automatically reconciling again once Runner Pod has gone.
+		if err := r.Get(ctx, client.ObjectKeyFromObject(runnerPod), runnerPod); err == nil {
+			log.V(1).Info("Runner pod is being deleted. Waiting for Runner pod to be deleted", "pod", runnerPod.Name)
+			return ctrl.Result{}, nil
+		} else if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		if runner.Spec.ContainerMode == octorunv1.RunnerContainerModeKubernetes {
+			// The job and service container pods are created by the container hooks
+			// without an owner. Delete them once the Runner pod has gone.
+			log.V(1).Info("deleting Runner container hooks resources")
+			if err := r.deleteContainerHooksResources(ctx, runner); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		// Remove the runner from Github in case the runner was not able to remove itself
+		// eg: the runner pod was OOM-killed, evicted or force-deleted.
+		if ghc == nil {
+			r.Recorder.Event(runner, corev1.EventTypeWarning, octorunv1.RunnerRemoveFailedReason, "Unable to remove Runner from Github: Runner credential not found")
+		} else if err := r.removeRunnerRegistration(ctx, ghc, runner); err != nil {
+			if !gherrors.IsForbidden(err) {
+				return ctrl.Result{}, err
+			}
+
+			// Do not block the Runner deletion if we are not allowed to remove the runner.
+			r.Recorder.Eventf(runner, corev1.EventTypeWarning, octorunv1.RunnerRemoveFailedReason, "Unable to remove Runner from Github: %v", err)
+		}
+
 		log.V(1).Info("deleting Runner registration token secret", "secret", runnerSecret.Name)
 		if err := r.Delete(ctx, runnerSecret); client.IgnoreNotFound(err) != nil {
 			return ctrl.Result{}, err
@@ -165,15 +267,81 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ c
 		return ctrl.Result{}, nil
 	}
 
+	if runner.Status.Phase == octorunv1.RunnerFailedPhase {
+		cond := meta.FindStatusCondition(runner.Status.Conditions, octorunv1.RunnerConditionRegistrationFailed)
+		if cond == nil || cond.Status != metav1.ConditionTrue {
+			// Failed is a terminal phase. The runner pod and the Github registration
+			// have been cleaned up, it is up to the owner to replace this runner.
+			log.V(1).Info("Runner has Failed phase", "reason", runner.Status.Reason)
+			return ctrl.Result{}, nil
+		}
+
+		credentialVersion, err := r.credentialVersion(ctx, runner)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// The registration is retried when the runner spec or its credential has changed, or after
+		// a while since the Github permissions may have changed in the meantime.
+		credentialChanged := credentialVersion != annotations.RegistrationCredentialVersion(runner)
+		if remaining := time.Until(cond.LastTransitionTime.Add(registrationRetryPeriod)); cond.ObservedGeneration == runner.Generation && !credentialChanged && remaining > 0 {
+			log.V(1).Info("Runner has failed to register. Waiting before retrying", "reason", runner.Status.Reason, "remaining", remaining)
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
+
+		log.Info("retrying Runner registration")
+		meta.SetStatusCondition(&runner.Status.Conditions, metav1.Condition{
+			Type:               octorunv1.RunnerConditionRegistrationFailed,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: runner.Generation,
+			Reason:             octorunv1.RunnerRegistrationRetryReason,
+			Message:            "Retrying Runner registration",
+		})
+
+		runner.Status.Phase = octorunv1.RunnerPendingPhase
+		runner.Status.Reason = ""
+		runner.Status.Message = ""
+	}
+
 	log.Info("reconciling Runner resources")
 	controllerutil.AddFinalizer(runner, RunnerController)
 
 	// Create a runner secret if it doesn't exist or update it if the token has expired.
+	// registered is set once the JIT runner configuration is generated, which registers the runner on Github.
+	registered := false
 	if op, err := ctrl.CreateOrUpdate(ctx, r.Client, runnerSecret, func() error {
 		log.V(1).Info("reconciling Runner registration token secret", "secret", runnerSecret.Name)
+		if runner.Spec.RegistrationMode == octorunv1.RunnerRegistrationJIT {
+			// The JIT runner configuration does not expire. It only needs to be generated once.
+			if runnerSecret.CreationTimestamp.IsZero() {
+				log.V(1).Info("Runner JIT config secret does not exist", "secret", runnerSecret.Name)
+				// Remove the runner of a previous JIT configuration that could not be removed when its secret failed to be written.
+				if err := r.removeRunnerRegistration(ctx, ghc, runner); err != nil {
+					return err
+				}
+
+				runner.Spec.ID = nil
+				jitConfig, err := ghc.GenerateJITConfig(ctx, runner.Spec.URL, &ghclient.JITConfigRequest{
+					Name:       runnerPod.Name,
+					Group:      runner.Spec.Group,
+					Labels:     githubRunnerLabels(runner, r.ControllerID),
+					WorkFolder: runner.Spec.Workdir,
+				})
+				if err != nil {
+					return err
+				}
+
+				runnerSecret.Data["jitconfig"] = []byte(jitConfig.EncodedJITConfig)
+				runner.Spec.ID = pointer.Int64(jitConfig.Runner.GetID())
+				registered = true
+			}
+
+			return ctrl.SetControllerReference(runner, runnerSecret, r.Scheme)
+		}
+
 		if runnerSecret.CreationTimestamp.IsZero() || annotations.IsTokenExpired(runnerSecret) {
 			log.V(1).Info("Runner registration token secret does not exist or already expired", "secret", runnerSecret.Name)
-			rt, err := r.Github.CreateRunnerToken(ctx, runner.Spec.URL)
+			rt, err := ghc.CreateRunnerToken(ctx, runner.Spec.URL)
 			if err != nil {
 				return err
 			}
@@ -184,18 +352,45 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ c
 
 		return ctrl.SetControllerReference(runner, runnerSecret, r.Scheme)
 	}); err != nil {
+		if registered {
+			// The JIT runner configuration is lost with the secret. Remove the runner it registered,
+			// a new one is registered on the next reconciliation.
+			if err := r.removeRunnerRegistration(ctx, ghc, runner); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			runner.Spec.ID = nil
+		}
+
 		if gherrors.IsForbidden(err) || gherrors.IsNotFound(err) {
-			// If we got forbidden or not found error from Github here just record an Warning event and return error nil (terminal failure).
-			// returning Requeue false with nil error here is to prevent the controller keep reconciling and trying to create the registration token.
-			// Users must have to recreate the runner with the proper spec.
-			//
-			// It's ok to just record an Warning event here since users still can gather information on why
-			// the runner keeps in Pending status eg: using `kubectl describe` command
-			//
-			// TODO(prksu): consider introducing terminal failure based on Runner status/condition?
+			// If we got forbidden or not found error from Github here the runner is unable to register
+			// with the current spec and credentials. Mark the runner as Failed with RegistrationFailed
+			// condition instead of trying to create the registration token on every reconciliation.
+			reason := octorunv1.RunnerRegistrationNotFoundReason
+			if gherrors.IsForbidden(err) {
+				reason = octorunv1.RunnerRegistrationForbiddenReason
+			}
+
 			log.Error(err, "Unable to create Runner registration token")
 			r.Recorder.Eventf(runner, corev1.EventTypeWarning, octorunv1.RunnerSecretFailedReason, "Unable to create Runner registration token: %v", err)
-			return ctrl.Result{Requeue: false}, nil
+			meta.SetStatusCondition(&runner.Status.Conditions, metav1.Condition{
+				Type:               octorunv1.RunnerConditionRegistrationFailed,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: runner.Generation,
+				Reason:             reason,
+				Message:            fmt.Sprintf("Unable to create Runner registration token: %v", err),
+			})
+
+			credentialVersion, err := r.credentialVersion(ctx, runner)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+
+			annotations.AnnotateRegistrationCredentialVersion(runner, credentialVersion)
+			runner.Status.Phase = octorunv1.RunnerFailedPhase
+			runner.Status.Reason = reason
+			runner.Status.Message = fmt.Sprintf("Unable to create Runner registration token: %v", err)
+			return ctrl.Result{RequeueAfter: registrationRetryPeriod}, nil
 		}
 
 		log.Error(err, "failed reconciling Runner registration token secret", "secret", runnerSecret.Name)
@@ -204,9 +399,22 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ c
 		log.V(1).Info("reconciled Runner registration token secret", "secret", runnerSecret.Name, "op", op)
 	}
 
+	if runner.Spec.ContainerMode == octorunv1.RunnerContainerModeKubernetes {
+		if err := r.reconcileContainerHooks(ctx, runner); err != nil {
+			log.Error(err, "failed reconciling Runner container hooks resources")
+			return ctrl.Result{}, err
+		}
+	}
+
 	// Create a runner pod if it doesn't exist. actually, it's never updating the runner pod and we won't.
 	if op, err := ctrl.CreateOrUpdate(ctx, r.Client, runnerPod, func() error {
 		log.V(1).Info("reconciling Runner pod", "pod", runnerPod.Name)
+		if runnerPod.CreationTimestamp.IsZero() && runner.Spec.PodTemplate != nil {
+			if err := pod.MergePodTemplate(runnerPod, runner.Spec.PodTemplate); err != nil {
+				return err
+			}
+		}
+
 		return ctrl.SetControllerReference(runner, runnerPod, r.Scheme)
 	}); err != nil {
 		log.Error(err, "failed reconciling Runner pod", "pod", runnerPod.Name)
@@ -217,12 +425,14 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ c
 
 	// All resources already reconciled. Set runner phase to "Pending" for now it will overwritten
 	// based on runner pod phase, conditions and runner status from Github.
+	lastPhase := runner.Status.Phase
 	runner.Status.Phase = octorunv1.RunnerPendingPhase
-	return r.reconcileStatus(ctx, runner, runnerPod)
+	return r.reconcileStatus(ctx, ghc, runner, runnerPod, lastPhase)
 }
 
-func (r *RunnerReconciler) reconcileStatus(ctx context.Context, runner *octorunv1.Runner, runnerPod *corev1.Pod) (ctrl.Result, error) {
+func (r *RunnerReconciler) reconcileStatus(ctx context.Context, ghc github.Client, runner *octorunv1.Runner, runnerPod *corev1.Pod, lastPhase octorunv1.RunnerPhase) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
+	jobCompleted := r.reconcileJob(runner)
 	switch runnerPod.Status.Phase {
 	case corev1.PodPending:
 		// Returns early if Runner Pod is in Pending phase. It will automatically
@@ -231,6 +441,21 @@ func (r *RunnerReconciler) reconcileStatus(ctx context.Context, runner *octorunv
 		log.V(1).Info("Runner pod is Pending. Waiting for Runner pod to be Running", "pod", runnerPod.Name)
 		return ctrl.Result{}, nil
 	case corev1.PodRunning:
+		if jobCompleted {
+			// The ephemeral runner is complete once its job has completed even
+			// though the runner pod is still running for a while to exit.
+			log.V(1).Info("Runner has completed its job", "pod", runnerPod.Name)
+			runner.Status.Phase = octorunv1.RunnerCompletePhase
+			runner.Status.IdleSince = nil
+			meta.SetStatusCondition(&runner.Status.Conditions, metav1.Condition{
+				Type:    octorunv1.RunnerConditionOnline,
+				Status:  metav1.ConditionFalse,
+				Reason:  octorunv1.RunnerJobCompletedReason,
+				Message: "Runner has completed its job",
+			})
+			return ctrl.Result{}, nil
+		}
+
 		// Once pod is in Running phase check if this pod condition is ready.
 		if !pod.PodConditionIsReady(runnerPod) {
 			// Returns early if Runner Pod is not Ready. It will automatically
@@ -239,16 +464,29 @@ func (r *RunnerReconciler) reconcileStatus(ctx context.Context, runner *octorunv
 			return ctrl.Result{}, nil
 		}
 
-		log.V(1).Info("find Runner id from Pod", "pod", runnerPod.Name)
-		runnerid, err := util.FindRunnerIDFromPod(runnerPod, r.Executor)
+		runnerid, err := r.findRunnerID(ctx, ghc, runner, runnerPod)
 		if err != nil {
-			log.Error(err, "unable to retrieve Runner id from Pod", "pod", runnerPod.Name)
+			if gherrors.IsNotFound(err) {
+				// Sometimes github runner is not listed instantly after registered.
+				log.V(1).Info("Runner is not found on Github yet", "runner", runnerPod.Name)
+				return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+			}
+
+			log.Error(err, "unable to retrieve Runner id", "pod", runnerPod.Name)
 			return ctrl.Result{}, err
 		}
 
 		runner.Spec.ID = pointer.Int64(runnerid)
-		ghrunner, err := r.Github.GetRunner(ctx, runner.Spec.URL, runnerid)
+		ghrunner, err := r.getRunner(ctx, ghc, runner, runnerid)
 		if err != nil {
+			if gherrors.IsNotFound(err) {
+				// The runner has registered again with a new id. eg: the runner container
+				// has been restarted. Find the new Runner id on the next reconciliation.
+				log.V(1).Info("Runner is not found on Github by its id", "runner-id", runnerid)
+				runner.Spec.ID = nil
+				return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+			}
+
 			log.Error(err, "unable to retrieve Runner information from Github")
 			return ctrl.Result{}, err
 		}
@@ -264,11 +502,24 @@ func (r *RunnerReconciler) reconcileStatus(ctx context.Context, runner *octorunv
 				Reason:  octorunv1.RunnerOfflineReason,
 				Message: "Github Runner has Offline status",
 			})
-			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+			return ctrl.Result{RequeueAfter: r.offlineRunnerSyncPeriod()}, nil
 		}
 
 		log.V(1).Info("Runner is online. wait for a job!", "runner", ghrunner.GetName())
+		if isFirstOnline(runner, lastPhase) {
+			metrics.RunnerProvisioningDuration.
+				WithLabelValues(runner.Namespace, runner.Labels[octorunv1.LabelRunnerSetName], runner.Spec.URL).
+				Observe(time.Since(runner.CreationTimestamp.Time).Seconds())
+		}
+
+		annotations.AnnotateProvisioned(runner)
+
 		runner.Status.Phase = octorunv1.RunnerIdlePhase
+		if runner.Status.IdleSince == nil {
+			now := metav1.Now()
+			runner.Status.IdleSince = &now
+		}
+
 		r.Recorder.Event(runner, corev1.EventTypeNormal, octorunv1.RunnerOnlineReason, "Runner wait for a job.")
 		meta.SetStatusCondition(&runner.Status.Conditions, metav1.Condition{
 			Type:    octorunv1.RunnerConditionOnline,
@@ -282,16 +533,29 @@ func (r *RunnerReconciler) reconcileStatus(ctx context.Context, runner *octorunv
 			log.V(1).Info("Runner is busy", "runner", ghrunner.GetName())
 			r.Recorder.Event(runner, corev1.EventTypeNormal, octorunv1.RunnerBusyReason, "Runner got a job.")
 			runner.Status.Phase = octorunv1.RunnerActivePhase
+			runner.Status.IdleSince = nil
 			if runner.Spec.EvictionPolicy == octorunv1.RunnerEvictionIfNotActive {
-				runnerPodPatch := client.MergeFrom(runnerPod.DeepCopyObject().(client.Object))
-				annotation := runnerPod.GetAnnotations()
-				if annotation == nil {
-					annotation = make(map[string]string)
+				if err := r.annotateSafeToEvict(ctx, runnerPod, "false"); err != nil {
+					log.Error(err, "unable to annotate runner pod", "pod", runnerPod.Name)
+					return ctrl.Result{}, err
 				}
+			}
+
+			if runner.Spec.Lifecycle == octorunv1.RunnerLifecyclePersistent {
+				// Persistent runner pod keeps running after the job has finished.
+				// Requeue to find out when the runner becomes idle again.
+				return ctrl.Result{RequeueAfter: persistentRunnerSyncPeriod}, nil
+			}
 
-				annotation["cluster-autoscaler.kubernetes.io/safe-to-evict"] = "false"
-				runnerPod.SetAnnotations(annotation)
-				if err := r.Patch(ctx, runnerPod, runnerPodPatch); err != nil {
+			return ctrl.Result{}, nil
+		}
+
+		if runner.Spec.Lifecycle == octorunv1.RunnerLifecyclePersistent && lastPhase == octorunv1.RunnerActivePhase {
+			// Persistent runner has completed its job and waits for the next one.
+			log.V(1).Info("Runner has completed a job", "runner", ghrunner.GetName())
+			r.Recorder.Event(runner, corev1.EventTypeNormal, octorunv1.RunnerJobCompletedReason, "Runner completed a job and waits for the next one.")
+			if runner.Spec.EvictionPolicy == octorunv1.RunnerEvictionIfNotActive {
+				if err := r.annotateSafeToEvict(ctx, runnerPod, "true"); err != nil {
 					log.Error(err, "unable to annotate runner pod", "pod", runnerPod.Name)
 					return ctrl.Result{}, err
 				}
@@ -302,6 +566,7 @@ func (r *RunnerReconciler) reconcileStatus(ctx context.Context, runner *octorunv
 	case corev1.PodSucceeded:
 		r.Recorder.Event(runner, corev1.EventTypeNormal, octorunv1.RunnerPodSucceededReason, "Runner complete his job.")
 		runner.Status.Phase = octorunv1.RunnerCompletePhase
+		runner.Status.IdleSince = nil
 		meta.SetStatusCondition(&runner.Status.Conditions, metav1.Condition{
 			Type:    octorunv1.RunnerConditionOnline,
 			Status:  metav1.ConditionFalse,
@@ -309,11 +574,411 @@ func (r *RunnerReconciler) reconcileStatus(ctx context.Context, runner *octorunv
 			Message: "Runner Pod has Succeeded phase",
 		})
 		return ctrl.Result{}, nil
+	case corev1.PodFailed, corev1.PodUnknown:
+		return r.reconcileFailedPod(ctx, ghc, runner, runnerPod)
 	default:
 		return ctrl.Result{}, nil
 	}
 }
 
+// isFirstOnline returns true if given runner is online for the first time since it has been created,
+// i.e. it was pending and it has never been annotated as provisioned. The annotation outlives both
+// the runner pod and the runner phase, so a runner going Idle, Pending and Idle again is not counted twice.
+func isFirstOnline(runner *octorunv1.Runner, lastPhase octorunv1.RunnerPhase) bool {
+	return (lastPhase == "" || lastPhase == octorunv1.RunnerPendingPhase) && !annotations.IsProvisioned(runner)
+}
+
+// reconcileJob records the workflow job details and completion noted by the Github webhook hook in the runner status.
+// It returns true if the runner is complete, i.e. it is an ephemeral runner whose job has completed.
+func (r *RunnerReconciler) reconcileJob(runner *octorunv1.Runner) bool {
+	if job := annotations.Job(runner); job != nil && (runner.Status.Job == nil || runner.Status.Job.ID != job.ID) {
+		runner.Status.Job = job
+		r.Recorder.Eventf(runner, corev1.EventTypeNormal, octorunv1.RunnerJobStartedReason,
+			"Runner picked up job %q of workflow %q (run %d) in %s on %s: %s", job.Name, job.WorkflowName, job.RunID, job.Repository, job.HeadBranch, job.URL)
+	}
+
+	completedAt, ok := annotations.JobCompletedAt(runner)
+	if !ok {
+		return false
+	}
+
+	completionTime := metav1.NewTime(completedAt)
+	if runner.Status.Job == nil || runner.Status.Job.CompletionTime == nil || !runner.Status.Job.CompletionTime.Equal(&completionTime) {
+		if runner.Status.Job == nil {
+			runner.Status.Job = &octorunv1.RunnerJobStatus{}
+		}
+
+		runner.Status.Job.Conclusion = annotations.JobConclusion(runner)
+		runner.Status.Job.CompletionTime = &completionTime
+		r.Recorder.Eventf(runner, corev1.EventTypeNormal, octorunv1.RunnerJobCompletedReason, "Runner completed job %q with %s conclusion.", runner.Status.Job.Name, runner.Status.Job.Conclusion)
+	}
+
+	return runner.Spec.Lifecycle != octorunv1.RunnerLifecyclePersistent
+}
+
+// reconcileContainerHooks creates the resources the actions runner container hooks need to run
+// the job and service containers as separate pods. The ServiceAccount of the runner pod is allowed
+// to manage those pods and the work directory PersistentVolumeClaim is shared with them.
+func (r *RunnerReconciler) reconcileContainerHooks(ctx context.Context, runner *octorunv1.Runner) error {
+	log := ctrl.LoggerFrom(ctx)
+	name := containerHooksName(runner)
+	serviceAccount := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: runner.Namespace}}
+	if op, err := ctrl.CreateOrUpdate(ctx, r.Client, serviceAccount, func() error {
+		return ctrl.SetControllerReference(runner, serviceAccount, r.Scheme)
+	}); err != nil {
+		return err
+	} else {
+		log.V(1).Info("reconciled Runner container hooks service account", "serviceaccount", name, "op", op)
+	}
+
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: runner.Namespace}}
+	if op, err := ctrl.CreateOrUpdate(ctx, r.Client, role, func() error {
+		role.Rules = containerHooksRules()
+		return ctrl.SetControllerReference(runner, role, r.Scheme)
+	}); err != nil {
+		return err
+	} else {
+		log.V(1).Info("reconciled Runner container hooks role", "role", name, "op", op)
+	}
+
+	roleBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: runner.Namespace}}
+	if op, err := ctrl.CreateOrUpdate(ctx, r.Client, roleBinding, func() error {
+		// RoleRef is immutable, it only needs to be set once.
+		roleBinding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name}
+		roleBinding.Subjects = []rbacv1.Subject{
+			{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: runner.Namespace},
+		}
+		return ctrl.SetControllerReference(runner, roleBinding, r.Scheme)
+	}); err != nil {
+		return err
+	} else {
+		log.V(1).Info("reconciled Runner container hooks role binding", "rolebinding", name, "op", op)
+	}
+
+	workVolumeClaim := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: workVolumeClaimName(runner), Namespace: runner.Namespace}}
+	if op, err := ctrl.CreateOrUpdate(ctx, r.Client, workVolumeClaim, func() error {
+		// PersistentVolumeClaim spec is immutable once created.
+		if workVolumeClaim.CreationTimestamp.IsZero() {
+			workVolumeClaim.Spec = workVolumeClaimSpec(runner)
+		}
+
+		return ctrl.SetControllerReference(runner, workVolumeClaim, r.Scheme)
+	}); err != nil {
+		return err
+	} else {
+		log.V(1).Info("reconciled Runner work volume claim", "persistentvolumeclaim", workVolumeClaim.Name, "op", op)
+	}
+
+	return nil
+}
+
+// containerHooksRules returns the rules of the container hooks Role. The controller is granted
+// every one of them through its RBAC markers, as Kubernetes only lets it grant what it holds.
+func containerHooksRules() []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods", "secrets"},
+			Verbs:     []string{"get", "list", "create", "delete"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods/exec"},
+			Verbs:     []string{"get", "create"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods/log"},
+			Verbs:     []string{"get", "list", "watch"},
+		},
+		{
+			APIGroups: []string{"batch"},
+			Resources: []string{"jobs"},
+			Verbs:     []string{"get", "list", "create", "delete"},
+		},
+	}
+}
+
+// deleteContainerHooksResources deletes the job and service container pods, jobs and secrets
+// created by the actions runner container hooks of the given runner.
+func (r *RunnerReconciler) deleteContainerHooksResources(ctx context.Context, runner *octorunv1.Runner) error {
+	opts := []client.DeleteAllOfOption{
+		client.InNamespace(runner.Namespace),
+		client.MatchingLabels{containerHooksRunnerPodLabel: runner.Name},
+		client.PropagationPolicy(metav1.DeletePropagationBackground),
+	}
+
+	for _, obj := range []client.Object{&batchv1.Job{}, &corev1.Pod{}, &corev1.Secret{}} {
+		if err := r.DeleteAllOf(ctx, obj, opts...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// annotateSafeToEvict sets the cluster-autoscaler safe-to-evict annotation of the given runner pod.
+func (r *RunnerReconciler) annotateSafeToEvict(ctx context.Context, runnerPod *corev1.Pod, value string) error {
+	runnerPodPatch := client.MergeFrom(runnerPod.DeepCopyObject().(client.Object))
+	annotation := runnerPod.GetAnnotations()
+	if annotation == nil {
+		annotation = make(map[string]string)
+	}
+
+	annotation["cluster-autoscaler.kubernetes.io/safe-to-evict"] = value
+	runnerPod.SetAnnotations(annotation)
+	return r.Patch(ctx, runnerPod, runnerPodPatch)
+}
+
+// reconcileFailedPod recreates the failed or unknown runner pod with an exponential backoff delay.
+// Once the runner reaches its backoff limit, the runner is marked as Failed.
+//
+// The Github registration of the failed pod is removed in both cases since the recreated pod
+// registers a new Github runner.
+func (r *RunnerReconciler) reconcileFailedPod(ctx context.Context, ghc github.Client, runner *octorunv1.Runner, runnerPod *corev1.Pod) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	if !runnerPod.GetDeletionTimestamp().IsZero() {
+		// Returns early if the failed Runner Pod is being deleted.
+		// It will automatically reconciling again once Runner Pod has gone.
+		log.V(1).Info("Runner pod is being deleted. Waiting for Runner pod to be recreated", "pod", runnerPod.Name)
+		return ctrl.Result{}, nil
+	}
+
+	reason, message := octorunv1.RunnerPodFailedReason, "Runner Pod has Failed phase"
+	if runnerPod.Status.Phase == corev1.PodUnknown {
+		reason, message = octorunv1.RunnerPodUnknownReason, "Runner Pod has Unknown phase"
+	}
+
+	if runnerPod.Status.Message != "" {
+		message = message + ": " + runnerPod.Status.Message
+	}
+
+	meta.SetStatusCondition(&runner.Status.Conditions, metav1.Condition{
+		Type:    octorunv1.RunnerConditionOnline,
+		Status:  metav1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	})
+
+	backoffLimit := pointer.Int32Deref(runner.Spec.BackoffLimit, 3)
+	if runner.Status.Restarts >= backoffLimit {
+		log.Info("Runner pod has failed and reached the backoff limit", "pod", runnerPod.Name, "restarts", runner.Status.Restarts)
+		if err := r.removeRunnerRegistration(ctx, ghc, runner); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if err := r.Delete(ctx, runnerPod); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+
+		runner.Status.Phase = octorunv1.RunnerFailedPhase
+		runner.Status.Reason = octorunv1.RunnerBackoffLimitExceededReason
+		runner.Status.Message = fmt.Sprintf("Runner pod has been recreated %d times. %s", runner.Status.Restarts, message)
+		r.Recorder.Event(runner, corev1.EventTypeWarning, octorunv1.RunnerBackoffLimitExceededReason, runner.Status.Message)
+		return ctrl.Result{}, nil
+	}
+
+	if remaining := time.Until(runnerPodFailedAt(runnerPod).Add(runnerPodBackoff(runner.Status.Restarts))); remaining > 0 {
+		log.V(1).Info("Runner pod has failed. Waiting for backoff before recreating", "pod", runnerPod.Name, "remaining", remaining)
+		r.Recorder.Eventf(runner, corev1.EventTypeWarning, reason, "%s. Recreating in %s.", message, remaining.Round(time.Second))
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	if err := r.removeRunnerRegistration(ctx, ghc, runner); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	log.Info("recreating failed Runner pod", "pod", runnerPod.Name, "restarts", runner.Status.Restarts)
+	if err := r.Delete(ctx, runnerPod); client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+
+	if runner.Spec.RegistrationMode == octorunv1.RunnerRegistrationJIT {
+		// The JIT runner configuration belongs to the removed Github runner.
+		// Delete the secret to register a new runner for the recreated pod.
+		if err := r.Delete(ctx, secretForRunner(runner)); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	runner.Spec.ID = nil
+	runner.Status.Restarts++
+	r.Recorder.Eventf(runner, corev1.EventTypeNormal, octorunv1.RunnerPodRecreatedReason, "Recreating failed Runner pod (restart %d of %d).", runner.Status.Restarts, backoffLimit)
+	return ctrl.Result{}, nil
+}
+
+// githubClientFor returns the Github client of the GitHubCredential referenced by the runner
+// or the Github client the controller has been started with if the runner does not reference any.
+func (r *RunnerReconciler) githubClientFor(ctx context.Context, runner *octorunv1.Runner) (github.Client, error) {
+	if runner.Spec.CredentialRef == nil {
+		return r.Github, nil
+	}
+
+	return r.Credentials.ClientFor(ctx, client.ObjectKey{Namespace: runner.Namespace, Name: runner.Spec.CredentialRef.Name})
+}
+
+// credentialVersion returns the version of the GitHubCredential referenced by the runner and of its Secret.
+// It is empty if the runner uses the controller credential or if either of them does not exist.
+func (r *RunnerReconciler) credentialVersion(ctx context.Context, runner *octorunv1.Runner) (string, error) {
+	if runner.Spec.CredentialRef == nil {
+		return "", nil
+	}
+
+	credential := &octorunv1.GitHubCredential{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: runner.Namespace, Name: runner.Spec.CredentialRef.Name}, credential); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: runner.Namespace, Name: credential.Spec.SecretName}, secret); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+
+	return fmt.Sprintf("%d/%s", credential.Generation, secret.ResourceVersion), nil
+}
+
+// credentialToRunners returns a request for every Runner that has failed to register with given GitHubCredential,
+// so that the registration is retried right away once the GitHubCredential has been fixed.
+func (r *RunnerReconciler) credentialToRunners(o client.Object) []reconcile.Request {
+	runnerList := &octorunv1.RunnerList{}
+	if err := r.List(context.Background(), runnerList, client.InNamespace(o.GetNamespace())); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range runnerList.Items {
+		runner := &runnerList.Items[i]
+		if runner.Spec.CredentialRef == nil || runner.Spec.CredentialRef.Name != o.GetName() {
+			continue
+		}
+
+		if meta.IsStatusConditionTrue(runner.Status.Conditions, octorunv1.RunnerConditionRegistrationFailed) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(runner)})
+		}
+	}
+
+	return requests
+}
+
+// secretToRunners returns a request for every Runner that has failed to register with a GitHubCredential
+// referencing given Secret.
+func (r *RunnerReconciler) secretToRunners(o client.Object) []reconcile.Request {
+	credentialList := &octorunv1.GitHubCredentialList{}
+	if err := r.List(context.Background(), credentialList, client.InNamespace(o.GetNamespace())); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range credentialList.Items {
+		credential := &credentialList.Items[i]
+		if credential.Spec.SecretName == o.GetName() {
+			requests = append(requests, r.credentialToRunners(credential)...)
+		}
+	}
+
+	return requests
+}
+
+// getRunner returns the Github runner with the given id from the last poll of the Poller if any,
+// otherwise it gets the runner from Github.
+func (r *RunnerReconciler) getRunner(ctx context.Context, ghc github.Client, runner *octorunv1.Runner, runnerid int64) (ghclient.Runner, error) {
+	if r.Poller != nil {
+		if ghrunner, ok := r.Poller.Runner(runner, runnerid); ok {
+			return ghrunner, nil
+		}
+	}
+
+	return ghc.GetRunner(ctx, runner.Spec.URL, runnerid)
+}
+
+// offlineRunnerSyncPeriod is how often an offline runner is checked again. With the Poller the Runner
+// is enqueued once the poller sees the runner online, requeueing is only a safety net then.
+func (r *RunnerReconciler) offlineRunnerSyncPeriod() time.Duration {
+	if r.Poller != nil {
+		return r.Poller.Interval
+	}
+
+	return 5 * time.Second
+}
+
+// findRunnerID returns the Github runner id of the runner. The id is looked up by the runner name through
+// Github API once and kept in the runner spec. If the Executor is set, the id is read from the runner pod
+// as a fallback when the runner is not found by its name.
+func (r *RunnerReconciler) findRunnerID(ctx context.Context, ghc github.Client, runner *octorunv1.Runner, runnerPod *corev1.Pod) (int64, error) {
+	log := ctrl.LoggerFrom(ctx)
+	if runner.Spec.ID != nil {
+		return *runner.Spec.ID, nil
+	}
+
+	log.V(1).Info("find Runner id from Github", "runner", runnerPod.Name)
+	ghrunner, err := ghc.FindRunnerByName(ctx, runner.Spec.URL, runnerPod.Name)
+	if err == nil {
+		return ghrunner.GetID(), nil
+	}
+
+	if !gherrors.IsNotFound(err) || r.Executor == nil {
+		return -1, err
+	}
+
+	log.V(1).Info("find Runner id from Pod", "pod", runnerPod.Name)
+	return util.FindRunnerIDFromPod(runnerPod, r.Executor)
+}
+
+// removeRunnerRegistration removes the Github runner registered with the runner ID if any.
+func (r *RunnerReconciler) removeRunnerRegistration(ctx context.Context, ghc github.Client, runner *octorunv1.Runner) error {
+	log := ctrl.LoggerFrom(ctx)
+	runnerid := pointer.Int64Deref(runner.Spec.ID, -1)
+	if runnerid == -1 {
+		return nil
+	}
+
+	log.V(1).Info("removing Runner from Github", "runner-id", runnerid)
+	if err := ghc.RemoveRunner(ctx, runner.Spec.URL, runnerid); err != nil && !gherrors.IsNotFound(err) {
+		log.Error(err, "unable to remove Runner from Github")
+		return err
+	}
+
+	return nil
+}
+
+// runnerPodBackoff returns the delay before recreating the failed runner pod
+// for the given number of restarts. It starts from 10s, doubled for each
+// restart and capped at 5 minutes.
+func runnerPodBackoff(restarts int32) time.Duration {
+	backoff := 10 * time.Second
+	for i := int32(0); i < restarts; i++ {
+		backoff *= 2
+		if backoff >= 5*time.Minute {
+			return 5 * time.Minute
+		}
+	}
+
+	return backoff
+}
+
+// runnerPodFailedAt returns the approximate time when the runner pod failed.
+// It is the latest container termination time or the time the pod became not ready.
+func runnerPodFailedAt(runnerPod *corev1.Pod) time.Time {
+	var failedAt time.Time
+	for _, cs := range runnerPod.Status.ContainerStatuses {
+		if cs.State.Terminated != nil && cs.State.Terminated.FinishedAt.After(failedAt) {
+			failedAt = cs.State.Terminated.FinishedAt.Time
+		}
+	}
+
+	if !failedAt.IsZero() {
+		return failedAt
+	}
+
+	for _, c := range runnerPod.Status.Conditions {
+		if c.Type == corev1.PodReady && c.Status != corev1.ConditionTrue {
+			return c.LastTransitionTime.Time
+		}
+	}
+
+	return runnerPod.CreationTimestamp.Time
+}
+
 func secretForRunner(runner *octorunv1.Runner) *corev1.Secret {
 	return &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -325,23 +990,51 @@ func secretForRunner(runner *octorunv1.Runner) *corev1.Secret {
 	}
 }
 
-func podForRunner(runner *octorunv1.Runner) *corev1.Pod {
+// registrationFileEnv returns the environment variable pointing the runner
+// to its registration token or JIT config file from the runner secret.
+func registrationFileEnv(runner *octorunv1.Runner) corev1.EnvVar {
+	if runner.Spec.RegistrationMode == octorunv1.RunnerRegistrationJIT {
+		return corev1.EnvVar{
+			Name:  "RUNNER_JITCONFIG_FILE",
+			Value: "/var/run/secrets/runner.octorun.github.io/registration-token/jitconfig",
+		}
+	}
+
+	return corev1.EnvVar{
+		Name:  "RUNNER_TOKEN_FILE",
+		Value: "/var/run/secrets/runner.octorun.github.io/registration-token/token",
+	}
+}
+
+// githubRunnerLabels returns the labels of the Github runner registered for the runner.
+// The controller ID is added as the controller-id label when it is set.
+func githubRunnerLabels(runner *octorunv1.Runner, controllerID string) []string {
+	labels := make(map[string]string, len(runner.Labels)+1)
+	for k, v := range runner.Labels {
+		labels[k] = v
+	}
+
+	if controllerID != "" {
+		labels[octorunv1.LabelControllerID] = controllerID
+	}
+
+	return util.RunnerLabels(labels)
+}
+
+func podForRunner(runner *octorunv1.Runner, controllerID string) *corev1.Pod {
 	annotation := make(map[string]string)
 	if runner.Spec.EvictionPolicy == octorunv1.RunnerEvictionIfNotActive {
 		annotation["cluster-autoscaler.kubernetes.io/safe-to-evict"] = "true"
 	}
 
-	runnerLabels := make([]string, 0)
-	for k, v := range runner.ObjectMeta.Labels {
-		if !strings.HasPrefix(k, octorunv1.LabelPrefix) {
-			continue
-		}
-
-		runnerLabel := strings.TrimPrefix(k, octorunv1.LabelPrefix) + "=" + v
-		runnerLabels = append(runnerLabels, runnerLabel)
+	// Persistent runner registers itself again when the runner container exits,
+	// the runner pod is never complete.
+	restartPolicy := corev1.RestartPolicyOnFailure
+	if runner.Spec.Lifecycle == octorunv1.RunnerLifecyclePersistent {
+		restartPolicy = corev1.RestartPolicyAlways
 	}
 
-	return &corev1.Pod{
+	runnerPod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        runner.Name,
 			Namespace:   runner.Namespace,
@@ -349,7 +1042,7 @@ func podForRunner(runner *octorunv1.Runner) *corev1.Pod {
 			Annotations: annotation,
 		},
 		Spec: corev1.PodSpec{
-			RestartPolicy:    corev1.RestartPolicyOnFailure,
+			RestartPolicy:    restartPolicy,
 			ImagePullSecrets: runner.Spec.Image.PullSecrets,
 			Containers: []corev1.Container{
 				{
@@ -371,7 +1064,7 @@ func podForRunner(runner *octorunv1.Runner) *corev1.Pod {
 						},
 						{
 							Name:  "RUNNER_LABELS",
-							Value: strings.Join(runnerLabels, ","),
+							Value: strings.Join(githubRunnerLabels(runner, controllerID), ","),
 						},
 						{
 							Name:  "RUNNER_GROUP",
@@ -382,9 +1075,10 @@ func podForRunner(runner *octorunv1.Runner) *corev1.Pod {
 							Value: runner.Spec.Workdir,
 						},
 						{
-							Name:  "RUNNER_TOKEN_FILE",
-							Value: "/var/run/secrets/runner.octorun.github.io/registration-token/token",
+							Name:  "RUNNER_LIFECYCLE",
+							Value: string(runner.Spec.Lifecycle),
 						},
+						registrationFileEnv(runner),
 					},
 					StartupProbe: &corev1.Probe{
 						ProbeHandler: corev1.ProbeHandler{
@@ -429,4 +1123,199 @@ func podForRunner(runner *octorunv1.Runner) *corev1.Pod {
 			RuntimeClassName: runner.Spec.RuntimeClassName,
 		},
 	}
+
+	if runner.Spec.Docker != nil {
+		addDockerSidecar(runnerPod, runner.Spec.Docker)
+	}
+
+	if runner.Spec.ContainerMode == octorunv1.RunnerContainerModeKubernetes {
+		addContainerHooks(runnerPod, runner)
+	}
+
+	return runnerPod
+}
+
+// dockerdSidecarScript starts the Docker daemon and stops it once the runner container
+// has completed, otherwise the runner pod would never complete. The Docker daemon exit
+// code is returned if it stops by itself.
+const dockerdSidecarScript = `dockerd-entrypoint.sh "$@" &
+pid=$!
+trap 'kill $pid; wait $pid; exit 0' INT TERM
+while [ ! -f ` + dockerRunnerExitedFile + ` ]; do
+  if ! kill -0 $pid 2>/dev/null; then
+    wait $pid
+    exit $?
+  fi
+  sleep 1
+done
+kill $pid && wait $pid
+exit 0`
+
+const (
+	dockerSocketDir        = "/run/docker"
+	dockerHost             = "unix://" + dockerSocketDir + "/docker.sock"
+	dockerRunnerExitedFile = dockerSocketDir + "/runner.exited"
+)
+
+// addDockerSidecar adds the Docker daemon sidecar container to the given runner pod
+// and points the runner container to the Docker daemon socket shared through an emptyDir volume.
+func addDockerSidecar(runnerPod *corev1.Pod, docker *octorunv1.RunnerDocker) {
+	image, dataDir := "docker:dind-rootless", "/home/rootless/.local/share/docker"
+	securityContext := &corev1.SecurityContext{
+		Privileged: pointer.Bool(true),
+	}
+
+	args := []string{"dockerd", "--host=" + dockerHost}
+	if docker.Mode == octorunv1.RunnerDockerPrivileged {
+		image, dataDir = "docker:dind", "/var/lib/docker"
+		securityContext.RunAsUser = pointer.Int64(0)
+		securityContext.RunAsGroup = pointer.Int64(0)
+		securityContext.RunAsNonRoot = pointer.Bool(false)
+		// Allow the runner user to access the Docker daemon socket owned by root.
+		args = append(args, "--group=1000")
+	}
+
+	if docker.Image != "" {
+		image = docker.Image
+	}
+
+	runnerContainer := &runnerPod.Spec.Containers[0]
+	runnerContainer.Env = append(runnerContainer.Env,
+		corev1.EnvVar{
+			Name:  "DOCKER_HOST",
+			Value: dockerHost,
+		},
+		corev1.EnvVar{
+			Name:  "DOCKERD_SIDECAR_EXIT_FILE",
+			Value: dockerRunnerExitedFile,
+		},
+	)
+	runnerContainer.VolumeMounts = append(runnerContainer.VolumeMounts, corev1.VolumeMount{
+		Name:      "docker-socket",
+		MountPath: dockerSocketDir,
+	})
+
+	runnerPod.Spec.Containers = append(runnerPod.Spec.Containers, corev1.Container{
+		Name:    "docker",
+		Image:   image,
+		Command: append([]string{"/bin/sh", "-c", dockerdSidecarScript, "sh"}, args...),
+		Env: []corev1.EnvVar{
+			{
+				// Disable TLS, the Docker daemon only listens on the shared socket.
+				Name:  "DOCKER_TLS_CERTDIR",
+				Value: "",
+			},
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{
+					Command: []string{"docker", "--host=" + dockerHost, "info"},
+				},
+			},
+			InitialDelaySeconds: 5,
+			PeriodSeconds:       5,
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      "docker-socket",
+				MountPath: dockerSocketDir,
+			},
+			{
+				Name:      "docker-data",
+				MountPath: dataDir,
+			},
+		},
+		Resources:       docker.Resources,
+		SecurityContext: securityContext,
+	})
+	runnerPod.Spec.Volumes = append(runnerPod.Spec.Volumes,
+		corev1.Volume{
+			Name: "docker-socket",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+		corev1.Volume{
+			Name: "docker-data",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	)
+}
+
+const (
+	// containerHooksPath is the path of the actions runner container hooks for Kubernetes in the runner image.
+	containerHooksPath = "/runner/k8s/index.js"
+	// containerHooksRunnerPodLabel is the label set by the container hooks on the
+	// resources they create with the name of the runner pod as the value.
+	containerHooksRunnerPodLabel = "runner-pod"
+)
+
+func containerHooksName(runner *octorunv1.Runner) string {
+	return runner.Name + "-container-hooks"
+}
+
+func workVolumeClaimName(runner *octorunv1.Runner) string {
+	return runner.Name + "-work"
+}
+
+func workVolumeClaimSpec(runner *octorunv1.Runner) corev1.PersistentVolumeClaimSpec {
+	if runner.Spec.WorkVolumeClaim != nil {
+		return *runner.Spec.WorkVolumeClaim
+	}
+
+	spec := corev1.PersistentVolumeClaimSpec{
+		AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+	}
+
+	spec.Resources.Requests = corev1.ResourceList{
+		corev1.ResourceStorage: resource.MustParse("1Gi"),
+	}
+
+	return spec
+}
+
+// addContainerHooks wires the actions runner container hooks for Kubernetes into the given runner pod.
+// The runner work directory is mounted from the work volume claim so the job and service container
+// pods created by the hooks share it with the runner.
+func addContainerHooks(runnerPod *corev1.Pod, runner *octorunv1.Runner) {
+	workdir := runner.Spec.Workdir
+	if workdir == "" {
+		workdir = "_work"
+	}
+
+	runnerContainer := &runnerPod.Spec.Containers[0]
+	runnerContainer.Env = append(runnerContainer.Env,
+		corev1.EnvVar{
+			Name:  "ACTIONS_RUNNER_CONTAINER_HOOKS",
+			Value: containerHooksPath,
+		},
+		corev1.EnvVar{
+			Name: "ACTIONS_RUNNER_POD_NAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: "metadata.name",
+				},
+			},
+		},
+		corev1.EnvVar{
+			Name:  "ACTIONS_RUNNER_CLAIM_NAME",
+			Value: workVolumeClaimName(runner),
+		},
+	)
+	runnerContainer.VolumeMounts = append(runnerContainer.VolumeMounts, corev1.VolumeMount{
+		Name:      "work",
+		MountPath: path.Join("/runner", workdir),
+	})
+
+	runnerPod.Spec.ServiceAccountName = containerHooksName(runner)
+	runnerPod.Spec.Volumes = append(runnerPod.Spec.Volumes, corev1.Volume{
+		Name: "work",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: workVolumeClaimName(runner),
+			},
+		},
+	})
 }
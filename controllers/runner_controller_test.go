@@ -20,8 +20,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"fmt"
+	"net/http"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -31,7 +34,9 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -39,6 +44,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
@@ -48,8 +54,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+	"octorun.github.io/octorun/pkg/github"
+	ghclient "octorun.github.io/octorun/pkg/github/client"
 	mghclient "octorun.github.io/octorun/pkg/github/client/mock"
 	"octorun.github.io/octorun/util"
+	"octorun.github.io/octorun/util/annotations"
 	"octorun.github.io/octorun/util/pod"
 	"octorun.github.io/octorun/util/remoteexec"
 )
@@ -133,7 +142,7 @@ var _ = Describe("RunnerReconciler", func() {
 				}, timeout, interval).ShouldNot(HaveOccurred())
 
 				secret := secretForRunner(runner)
-				runnerpod := podForRunner(runner)
+				runnerpod := podForRunner(runner, "")
 
 				By("Waiting Registration Token Secret created")
 				Eventually(func() error {
@@ -191,7 +200,7 @@ var _ = Describe("RunnerReconciler", func() {
 				}, timeout, interval).ShouldNot(HaveOccurred())
 
 				secret := secretForRunner(runner)
-				runnerpod := podForRunner(runner)
+				runnerpod := podForRunner(runner, "")
 
 				By("Waiting Registration Token Secret created")
 				Eventually(func() error {
@@ -234,7 +243,7 @@ var _ = Describe("RunnerReconciler", func() {
 				}, timeout, interval).ShouldNot(HaveOccurred())
 
 				time.Sleep(2 * time.Second)
-				runnerpod := podForRunner(runner)
+				runnerpod := podForRunner(runner, "")
 				runnersecret := secretForRunner(runner)
 
 				By("Ensuring Runner Secret not to created")
@@ -257,7 +266,7 @@ var _ = Describe("RunnerReconciler", func() {
 				}, timeout, interval).ShouldNot(HaveOccurred())
 
 				secret := secretForRunner(runner)
-				runnerpod := podForRunner(runner)
+				runnerpod := podForRunner(runner, "")
 
 				By("Waiting Registration Token Secret created")
 				Eventually(func() error {
@@ -293,6 +302,7 @@ func TestRunnerReconciler_Reconcile(t *testing.T) {
 		runnerSecretFn func(runner *octorunv1.Runner) *corev1.Secret
 		expectFn       func(cmockr *mghclient.MockClientMockRecorder)
 		executor       remoteexec.RemoteExecutor
+		polledRunners  []*gogithub.Runner
 		want           ctrl.Result
 		wantErr        bool
 	}{
@@ -327,6 +337,129 @@ func TestRunnerReconciler_Reconcile(t *testing.T) {
 			want:     reconcile.Result{},
 			wantErr:  false,
 		},
+		{
+			name: "runner_has_deletion_timestamp_and_credential_not_found",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				now := metav1.Now()
+				runner.Spec.ID = pointer.Int64(1)
+				runner.Spec.CredentialRef = &octorunv1.GitHubCredentialReference{Name: "notfound"}
+				runner.SetDeletionTimestamp(&now)
+				return runner
+			},
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return &corev1.Pod{} },
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn:       func(cmockr *mghclient.MockClientMockRecorder) {},
+			executor:       &remoteexec.FakeRemoteExecutor{},
+			want:           reconcile.Result{},
+			wantErr:        false,
+		},
+		{
+			name: "runner_has_deletion_timestamp_and_has_runner_id",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				now := metav1.Now()
+				runner.Spec.ID = pointer.Int64(1)
+				runner.SetDeletionTimestamp(&now)
+				return runner
+			},
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return podForRunner(runner, "") },
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+				cmockr.RemoveRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{},
+			wantErr:  false,
+		},
+		{
+			name: "runner_has_deletion_timestamp_and_kubernetes_container_mode",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				now := metav1.Now()
+				runner.Spec.ID = pointer.Int64(1)
+				runner.Spec.ContainerMode = octorunv1.RunnerContainerModeKubernetes
+				runner.SetDeletionTimestamp(&now)
+				return runner
+			},
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				// The job pod created by the container hooks.
+				return &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      runner.Name + "-workflow",
+						Namespace: runner.Namespace,
+						Labels:    map[string]string{containerHooksRunnerPodLabel: runner.Name},
+					},
+				}
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+				cmockr.RemoveRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{},
+			wantErr:  false,
+		},
+		{
+			name: "runner_has_deletion_timestamp_and_remove_runner_forbidden",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				now := metav1.Now()
+				runner.Spec.ID = pointer.Int64(1)
+				runner.SetDeletionTimestamp(&now)
+				return runner
+			},
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return podForRunner(runner, "") },
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+				cmockr.RemoveRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(&gogithub.ErrorResponse{
+					Response: &http.Response{StatusCode: http.StatusForbidden},
+				})
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{},
+			wantErr:  false,
+		},
+		{
+			name: "runner_has_deletion_timestamp_and_runnerpod_is_being_deleted",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				now := metav1.Now()
+				runner.Spec.ID = pointer.Int64(1)
+				runner.SetDeletionTimestamp(&now)
+				return runner
+			},
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Finalizers = []string{"octorun.github.io/test"}
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{},
+			wantErr:  false,
+		},
 		{
 			name: "runner_has_deletion_timestamp_and_has_active_phase",
 			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
@@ -351,6 +484,56 @@ func TestRunnerReconciler_Reconcile(t *testing.T) {
 			want:     reconcile.Result{RequeueAfter: 60 * time.Second},
 			wantErr:  false,
 		},
+		{
+			name: "runner_has_deletion_timestamp_and_has_active_phase_but_already_completed_by_poller",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				now := metav1.Now()
+				runner.Spec.ID = pointer.Int64(1)
+				runner.DeletionTimestamp = &now
+				runner.Status = octorunv1.RunnerStatus{
+					Phase: octorunv1.RunnerActivePhase,
+				}
+				return runner
+			},
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return &corev1.Pod{} },
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn:       func(cmockr *mghclient.MockClientMockRecorder) {},
+			executor:       &remoteexec.FakeRemoteExecutor{},
+			polledRunners: []*gogithub.Runner{
+				{
+					ID:     gogithub.Int64(1),
+					Status: gogithub.String("online"),
+					Busy:   gogithub.Bool(false),
+				},
+			},
+			want:    reconcile.Result{Requeue: true},
+			wantErr: false,
+		},
+		{
+			name: "runner_has_deletion_timestamp_and_has_active_phase_but_rate_limited",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				now := metav1.Now()
+				runner.Spec.ID = pointer.Int64(1)
+				runner.DeletionTimestamp = &now
+				runner.Status = octorunv1.RunnerStatus{
+					Phase: octorunv1.RunnerActivePhase,
+				}
+				return runner
+			},
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return &corev1.Pod{} },
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				retryAfter := 30 * time.Second
+				req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/octorun/actions/runners/1", nil)
+				cmockr.GetRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(nil, &gogithub.AbuseRateLimitError{
+					Response:   &http.Response{StatusCode: http.StatusForbidden, Request: req},
+					RetryAfter: &retryAfter,
+				})
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{RequeueAfter: 30 * time.Second},
+			wantErr:  false,
+		},
 		{
 			name: "runner_has_deletion_timestamp_and_has_active_phase_but_already_completed",
 			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
@@ -376,8 +559,17 @@ func TestRunnerReconciler_Reconcile(t *testing.T) {
 			wantErr:  false,
 		},
 		{
-			name:           "runner_just_created",
-			runnerFn:       func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
+			name: "runner_has_deletion_timestamp_and_has_active_phase_but_job_completed",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				now := metav1.Now()
+				runner.Spec.ID = pointer.Int64(1)
+				runner.DeletionTimestamp = &now
+				runner.Status = octorunv1.RunnerStatus{
+					Phase: octorunv1.RunnerActivePhase,
+				}
+				annotations.AnnotateJobCompleted(runner, now.Time, "success")
+				return runner
+			},
 			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return &corev1.Pod{} },
 			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
 			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
@@ -387,19 +579,16 @@ func TestRunnerReconciler_Reconcile(t *testing.T) {
 						Time: time.Now().Add(1 * time.Hour),
 					},
 				}, nil)
+				cmockr.RemoveRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(nil)
 			},
 			executor: &remoteexec.FakeRemoteExecutor{},
 			want:     reconcile.Result{},
 			wantErr:  false,
 		},
 		{
-			name:     "runnerpod_has_pending_phase",
-			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
-			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
-				pod := podForRunner(runner)
-				pod.Status.Phase = corev1.PodPending
-				return pod
-			},
+			name:           "runner_just_created",
+			runnerFn:       func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return &corev1.Pod{} },
 			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
 			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
 				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
@@ -414,19 +603,22 @@ func TestRunnerReconciler_Reconcile(t *testing.T) {
 			wantErr:  false,
 		},
 		{
-			name:     "runnerpod_has_running_phase_but_not_yet_ready",
-			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
-			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
-				pod := podForRunner(runner)
-				pod.Status.Phase = corev1.PodRunning
-				pod.Status.Conditions = []corev1.PodCondition{
-					{
-						Type:   corev1.PodReady,
-						Status: corev1.ConditionFalse,
+			name: "runner_just_created_with_pod_template",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Spec.PodTemplate = &corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						PriorityClassName: "runner",
+						Containers: []corev1.Container{
+							{
+								Name:  "sidecar",
+								Image: "busybox",
+							},
+						},
 					},
 				}
-				return pod
+				return runner
 			},
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return &corev1.Pod{} },
 			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
 			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
 				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
@@ -441,19 +633,12 @@ func TestRunnerReconciler_Reconcile(t *testing.T) {
 			wantErr:  false,
 		},
 		{
-			name:     "runnerpod_has_running_phase_and_github_runner_online",
-			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
-			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
-				pod := podForRunner(runner)
-				pod.Status.Phase = corev1.PodRunning
-				pod.Status.Conditions = []corev1.PodCondition{
-					{
-						Type:   corev1.PodReady,
-						Status: corev1.ConditionTrue,
-					},
-				}
-				return pod
+			name: "runner_just_created_with_kubernetes_container_mode",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Spec.ContainerMode = octorunv1.RunnerContainerModeKubernetes
+				return runner
 			},
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return &corev1.Pod{} },
 			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
 			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
 				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
@@ -462,68 +647,32 @@ func TestRunnerReconciler_Reconcile(t *testing.T) {
 						Time: time.Now().Add(1 * time.Hour),
 					},
 				}, nil)
-				cmockr.GetRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(&gogithub.Runner{
-					ID:     gogithub.Int64(1),
-					Status: gogithub.String("online"),
-				}, nil)
 			},
-			executor: &remoteexec.FakeRemoteExecutor{
-				Out:     bytes.NewBufferString("1"),
-				Errout:  &bytes.Buffer{},
-				Execerr: nil,
-			},
-			want:    reconcile.Result{},
-			wantErr: false,
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{},
+			wantErr:  false,
 		},
 		{
-			name:     "runnerpod_has_running_phase_and_github_runner_offline",
-			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
-			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
-				pod := podForRunner(runner)
-				pod.Status.Phase = corev1.PodRunning
-				pod.Status.Conditions = []corev1.PodCondition{
-					{
-						Type:   corev1.PodReady,
-						Status: corev1.ConditionTrue,
-					},
-				}
-				return pod
-			},
+			name:           "runner_registration_token_forbidden",
+			runnerFn:       func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return &corev1.Pod{} },
 			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
 			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
-				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
-					Token: gogithub.String("faketoken"),
-					ExpiresAt: &gogithub.Timestamp{
-						Time: time.Now().Add(1 * time.Hour),
-					},
-				}, nil)
-				cmockr.GetRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(&gogithub.Runner{
-					ID:     gogithub.Int64(1),
-					Status: gogithub.String("offline"),
-				}, nil)
-			},
-			executor: &remoteexec.FakeRemoteExecutor{
-				Out:     bytes.NewBufferString("1"),
-				Errout:  &bytes.Buffer{},
-				Execerr: nil,
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(nil, &gogithub.ErrorResponse{
+					Response: &http.Response{StatusCode: http.StatusForbidden},
+				})
 			},
-			want:    reconcile.Result{RequeueAfter: 5 * time.Second},
-			wantErr: false,
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{RequeueAfter: registrationRetryPeriod},
+			wantErr:  false,
 		},
 		{
-			name:     "runnerpod_has_running_phase_and_github_runner_busy",
-			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
-			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
-				pod := podForRunner(runner)
-				pod.Status.Phase = corev1.PodRunning
-				pod.Status.Conditions = []corev1.PodCondition{
-					{
-						Type:   corev1.PodReady,
-						Status: corev1.ConditionTrue,
-					},
-				}
-				return pod
+			name: "runner_just_created_with_credential_ref",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Spec.CredentialRef = &octorunv1.GitHubCredentialReference{Name: "octorun"}
+				return runner
 			},
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return &corev1.Pod{} },
 			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
 			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
 				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
@@ -532,49 +681,1007 @@ func TestRunnerReconciler_Reconcile(t *testing.T) {
 						Time: time.Now().Add(1 * time.Hour),
 					},
 				}, nil)
-				cmockr.GetRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(&gogithub.Runner{
-					ID:     gogithub.Int64(1),
-					Status: gogithub.String("online"),
-					Busy:   gogithub.Bool(true),
-				}, nil)
 			},
-			executor: &remoteexec.FakeRemoteExecutor{
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{},
+			wantErr:  false,
+		},
+		{
+			name: "runner_credential_not_found",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Spec.CredentialRef = &octorunv1.GitHubCredentialReference{Name: "notfound"}
+				return runner
+			},
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return &corev1.Pod{} },
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn:       func(cmockr *mghclient.MockClientMockRecorder) {},
+			executor:       &remoteexec.FakeRemoteExecutor{},
+			want:           reconcile.Result{},
+			wantErr:        true,
+		},
+		{
+			name: "runner_just_created_with_jit_registration",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Spec.RegistrationMode = octorunv1.RunnerRegistrationJIT
+				return runner
+			},
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return &corev1.Pod{} },
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.GenerateJITConfig(gomock.Any(), "https://github.com/octorun", gomock.Any()).Return(&ghclient.JITRunnerConfig{
+					Runner:           &gogithub.Runner{ID: gogithub.Int64(1)},
+					EncodedJITConfig: "fakejitconfig",
+				}, nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{},
+			wantErr:  false,
+		},
+		{
+			name: "runner_jit_registration_forbidden",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Spec.RegistrationMode = octorunv1.RunnerRegistrationJIT
+				return runner
+			},
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return &corev1.Pod{} },
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.GenerateJITConfig(gomock.Any(), "https://github.com/octorun", gomock.Any()).Return(nil, &gogithub.ErrorResponse{
+					Response: &http.Response{StatusCode: http.StatusForbidden},
+				})
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{RequeueAfter: registrationRetryPeriod},
+			wantErr:  false,
+		},
+		{
+			name: "runner_has_registration_failed_and_spec_changed",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Generation = 2
+				runner.Status.Phase = octorunv1.RunnerFailedPhase
+				runner.Status.Conditions = []metav1.Condition{
+					{
+						Type:               octorunv1.RunnerConditionRegistrationFailed,
+						Status:             metav1.ConditionTrue,
+						ObservedGeneration: 1,
+						LastTransitionTime: metav1.Now(),
+						Reason:             octorunv1.RunnerRegistrationNotFoundReason,
+					},
+				}
+				return runner
+			},
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return &corev1.Pod{} },
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{},
+			wantErr:  false,
+		},
+		{
+			name: "runner_has_registration_failed_and_retry_period_elapsed",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Status.Phase = octorunv1.RunnerFailedPhase
+				runner.Status.Conditions = []metav1.Condition{
+					{
+						Type:               octorunv1.RunnerConditionRegistrationFailed,
+						Status:             metav1.ConditionTrue,
+						ObservedGeneration: runner.Generation,
+						LastTransitionTime: metav1.NewTime(time.Now().Add(-1 * time.Hour)),
+						Reason:             octorunv1.RunnerRegistrationNotFoundReason,
+					},
+				}
+				return runner
+			},
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return &corev1.Pod{} },
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(nil, &gogithub.ErrorResponse{
+					Response: &http.Response{StatusCode: http.StatusNotFound},
+				})
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{RequeueAfter: registrationRetryPeriod},
+			wantErr:  false,
+		},
+		{
+			name:     "runnerpod_has_pending_phase",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Status.Phase = corev1.PodPending
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{},
+			wantErr:  false,
+		},
+		{
+			name:     "runnerpod_has_running_phase_but_not_yet_ready",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Status.Phase = corev1.PodRunning
+				pod.Status.Conditions = []corev1.PodCondition{
+					{
+						Type:   corev1.PodReady,
+						Status: corev1.ConditionFalse,
+					},
+				}
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{},
+			wantErr:  false,
+		},
+		{
+			name:     "runnerpod_has_running_phase_and_github_runner_online",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Status.Phase = corev1.PodRunning
+				pod.Status.Conditions = []corev1.PodCondition{
+					{
+						Type:   corev1.PodReady,
+						Status: corev1.ConditionTrue,
+					},
+				}
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+				cmockr.FindRunnerByName(gomock.Any(), "https://github.com/octorun", "runner-test").Return(&gogithub.Runner{
+					ID:   gogithub.Int64(1),
+					Name: gogithub.String("runner-test"),
+				}, nil)
+				cmockr.GetRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(&gogithub.Runner{
+					ID:     gogithub.Int64(1),
+					Status: gogithub.String("online"),
+				}, nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{
+				Out:     bytes.NewBufferString("1"),
+				Errout:  &bytes.Buffer{},
+				Execerr: nil,
+			},
+			want:    reconcile.Result{},
+			wantErr: false,
+		},
+		{
+			name:     "runnerpod_has_running_phase_and_github_runner_not_found_by_name",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Status.Phase = corev1.PodRunning
+				pod.Status.Conditions = []corev1.PodCondition{
+					{
+						Type:   corev1.PodReady,
+						Status: corev1.ConditionTrue,
+					},
+				}
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+				cmockr.FindRunnerByName(gomock.Any(), "https://github.com/octorun", "runner-test").Return(nil, &gogithub.ErrorResponse{
+					Response: &http.Response{StatusCode: http.StatusNotFound},
+				})
+			},
+			executor: nil,
+			want:     reconcile.Result{RequeueAfter: 5 * time.Second},
+			wantErr:  false,
+		},
+		{
+			name:     "runnerpod_has_running_phase_and_github_runner_not_found_by_name_with_exec_fallback",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Status.Phase = corev1.PodRunning
+				pod.Status.Conditions = []corev1.PodCondition{
+					{
+						Type:   corev1.PodReady,
+						Status: corev1.ConditionTrue,
+					},
+				}
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+				cmockr.FindRunnerByName(gomock.Any(), "https://github.com/octorun", "runner-test").Return(nil, &gogithub.ErrorResponse{
+					Response: &http.Response{StatusCode: http.StatusNotFound},
+				})
+				cmockr.GetRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(&gogithub.Runner{
+					ID:     gogithub.Int64(1),
+					Status: gogithub.String("online"),
+				}, nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{
+				Out:     bytes.NewBufferString("1"),
+				Errout:  &bytes.Buffer{},
+				Execerr: nil,
+			},
+			want:    reconcile.Result{},
+			wantErr: false,
+		},
+		{
+			name: "runnerpod_has_running_phase_and_job_completed",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Spec.ID = pointer.Int64(1)
+				annotations.AnnotateJobCompleted(runner, time.Now(), "success")
+				return runner
+			},
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Status.Phase = corev1.PodRunning
+				pod.Status.Conditions = []corev1.PodCondition{
+					{
+						Type:   corev1.PodReady,
+						Status: corev1.ConditionTrue,
+					},
+				}
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+			},
+			executor: nil,
+			want:     reconcile.Result{},
+			wantErr:  false,
+		},
+		{
+			name: "runnerpod_has_running_phase_and_github_runner_not_found_by_id",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Spec.ID = pointer.Int64(1)
+				return runner
+			},
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Status.Phase = corev1.PodRunning
+				pod.Status.Conditions = []corev1.PodCondition{
+					{
+						Type:   corev1.PodReady,
+						Status: corev1.ConditionTrue,
+					},
+				}
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+				cmockr.GetRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(nil, &gogithub.ErrorResponse{
+					Response: &http.Response{StatusCode: http.StatusNotFound},
+				})
+			},
+			executor: nil,
+			want:     reconcile.Result{RequeueAfter: 5 * time.Second},
+			wantErr:  false,
+		},
+		{
+			name:     "runnerpod_has_running_phase_and_github_runner_offline",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Status.Phase = corev1.PodRunning
+				pod.Status.Conditions = []corev1.PodCondition{
+					{
+						Type:   corev1.PodReady,
+						Status: corev1.ConditionTrue,
+					},
+				}
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+				cmockr.FindRunnerByName(gomock.Any(), "https://github.com/octorun", "runner-test").Return(&gogithub.Runner{
+					ID:   gogithub.Int64(1),
+					Name: gogithub.String("runner-test"),
+				}, nil)
+				cmockr.GetRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(&gogithub.Runner{
+					ID:     gogithub.Int64(1),
+					Status: gogithub.String("offline"),
+				}, nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{
+				Out:     bytes.NewBufferString("1"),
+				Errout:  &bytes.Buffer{},
+				Execerr: nil,
+			},
+			want:    reconcile.Result{RequeueAfter: 5 * time.Second},
+			wantErr: false,
+		},
+		{
+			name:     "runnerpod_has_running_phase_and_github_runner_busy",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Status.Phase = corev1.PodRunning
+				pod.Status.Conditions = []corev1.PodCondition{
+					{
+						Type:   corev1.PodReady,
+						Status: corev1.ConditionTrue,
+					},
+				}
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+				cmockr.FindRunnerByName(gomock.Any(), "https://github.com/octorun", "runner-test").Return(&gogithub.Runner{
+					ID:   gogithub.Int64(1),
+					Name: gogithub.String("runner-test"),
+				}, nil)
+				cmockr.GetRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(&gogithub.Runner{
+					ID:     gogithub.Int64(1),
+					Status: gogithub.String("online"),
+					Busy:   gogithub.Bool(true),
+				}, nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{
 				Out:     bytes.NewBufferString("1"),
 				Errout:  &bytes.Buffer{},
 				Execerr: nil,
 			},
-			want:    reconcile.Result{},
-			wantErr: false,
+			want:    reconcile.Result{},
+			wantErr: false,
+		},
+		{
+			name: "persistent_runnerpod_has_running_phase_and_github_runner_busy",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Spec.ID = pointer.Int64(1)
+				runner.Spec.Lifecycle = octorunv1.RunnerLifecyclePersistent
+				return runner
+			},
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Status.Phase = corev1.PodRunning
+				pod.Status.Conditions = []corev1.PodCondition{
+					{
+						Type:   corev1.PodReady,
+						Status: corev1.ConditionTrue,
+					},
+				}
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+				cmockr.GetRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(&gogithub.Runner{
+					ID:     gogithub.Int64(1),
+					Status: gogithub.String("online"),
+					Busy:   gogithub.Bool(true),
+				}, nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{RequeueAfter: persistentRunnerSyncPeriod},
+			wantErr:  false,
+		},
+		{
+			name: "persistent_runner_has_active_phase_and_github_runner_not_busy",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Spec.ID = pointer.Int64(1)
+				runner.Spec.Lifecycle = octorunv1.RunnerLifecyclePersistent
+				runner.Spec.EvictionPolicy = octorunv1.RunnerEvictionIfNotActive
+				runner.Status.Phase = octorunv1.RunnerActivePhase
+				return runner
+			},
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Status.Phase = corev1.PodRunning
+				pod.Status.Conditions = []corev1.PodCondition{
+					{
+						Type:   corev1.PodReady,
+						Status: corev1.ConditionTrue,
+					},
+				}
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+				cmockr.GetRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(&gogithub.Runner{
+					ID:     gogithub.Int64(1),
+					Status: gogithub.String("online"),
+					Busy:   gogithub.Bool(false),
+				}, nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{},
+			wantErr:  false,
+		},
+		{
+			name:     "runnerpod_has_success_phase",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Status.Phase = corev1.PodSucceeded
+				pod.Status.StartTime = &metav1.Time{Time: time.Now()}
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("anotherfaketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{},
+			wantErr:  false,
+		},
+		{
+			name: "runnerpod_has_failed_phase_and_backoff_elapsed",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Spec.ID = pointer.Int64(1)
+				return runner
+			},
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Status.Phase = corev1.PodFailed
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+					{
+						Name: "runner",
+						State: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{
+								ExitCode:   1,
+								FinishedAt: metav1.NewTime(time.Now().Add(-1 * time.Hour)),
+							},
+						},
+					},
+				}
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+				cmockr.RemoveRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{},
+			wantErr:  false,
+		},
+		{
+			name: "runnerpod_has_failed_phase_and_backoff_limit_exceeded",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Spec.ID = pointer.Int64(1)
+				runner.Status.Restarts = 3
+				return runner
+			},
+			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
+				pod := podForRunner(runner, "")
+				pod.Status.Phase = corev1.PodFailed
+				return pod
+			},
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
+					Token: gogithub.String("faketoken"),
+					ExpiresAt: &gogithub.Timestamp{
+						Time: time.Now().Add(1 * time.Hour),
+					},
+				}, nil)
+				cmockr.RemoveRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(nil)
+			},
+			executor: &remoteexec.FakeRemoteExecutor{},
+			want:     reconcile.Result{},
+			wantErr:  false,
+		},
+		{
+			name: "runner_has_failed_phase",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Status.Phase = octorunv1.RunnerFailedPhase
+				return runner
+			},
+			runnerPodFn:    func(runner *octorunv1.Runner) *corev1.Pod { return podForRunner(runner, "") },
+			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
+			expectFn:       func(cmockr *mghclient.MockClientMockRecorder) {},
+			executor:       &remoteexec.FakeRemoteExecutor{},
+			want:           reconcile.Result{},
+			wantErr:        false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mctrl := gomock.NewController(t)
+			mghc := mghclient.NewMockClient(mctrl)
+
+			runner := tt.runnerFn(&octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "runner-test",
+					Namespace: "default",
+					Labels: map[string]string{
+						octorunv1.LabelRunnerName: "runner-test",
+					},
+				},
+				Spec: octorunv1.RunnerSpec{
+					URL: "https://github.com/octorun",
+					Image: octorunv1.RunnerImage{
+						Name: "ghcr.io/octorun/runner",
+					},
+				},
+			})
+
+			runnerPod := tt.runnerPodFn(runner)
+			runnerSecret := tt.runnerSecretFn(runner)
+			fakec := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(
+					runner,
+					runnerPod,
+					runnerSecret,
+				).Build()
+
+			tt.expectFn(mghc.EXPECT())
+			r := &RunnerReconciler{
+				Client:      fakec,
+				Github:      mghc,
+				Credentials: &fakeClientGetter{clients: map[string]github.Client{"octorun": mghc}},
+				Scheme:      scheme,
+				Executor:    tt.executor,
+				Recorder:    new(record.FakeRecorder),
+			}
+			if tt.polledRunners != nil {
+				snapshot := runnerSnapshot{runners: make(map[int64]*gogithub.Runner), polledAt: time.Now()}
+				for _, ghrunner := range tt.polledRunners {
+					snapshot.runners[ghrunner.GetID()] = ghrunner
+				}
+
+				r.Poller = &RunnerPoller{
+					Interval:  time.Minute,
+					snapshots: map[runnerTarget]runnerSnapshot{runnerTargetFor(runner.Namespace, &runner.Spec): snapshot},
+				}
+			}
+
+			got, err := r.Reconcile(context.Background(), reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: "default",
+					Name:      "runner-test",
+				},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RunnerReconciler.Reconcile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RunnerReconciler.Reconcile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunnerReconciler_Reconcile_containerHooksFinalizer(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+
+	now := metav1.Now()
+	runner := &octorunv1.Runner{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "runner-test",
+			Namespace:         "default",
+			Finalizers:        []string{RunnerController},
+			DeletionTimestamp: &now,
+		},
+		Spec: octorunv1.RunnerSpec{
+			URL:              "https://github.com/octorun",
+			ID:               pointer.Int64(1),
+			RegistrationMode: octorunv1.RunnerRegistrationJIT,
+			ContainerMode:    octorunv1.RunnerContainerModeKubernetes,
+			Image: octorunv1.RunnerImage{
+				Name: "ghcr.io/octorun/runner",
+			},
+		},
+	}
+
+	hooksMeta := func(name, runnerName string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{containerHooksRunnerPodLabel: runnerName},
+		}
+	}
+
+	deleted := []client.Object{
+		&corev1.Pod{ObjectMeta: hooksMeta("runner-test-workflow", runner.Name)},
+		&batchv1.Job{ObjectMeta: hooksMeta("runner-test-job", runner.Name)},
+		&corev1.Secret{ObjectMeta: hooksMeta("runner-test-secret", runner.Name)},
+	}
+	kept := []client.Object{
+		&corev1.Pod{ObjectMeta: hooksMeta("runner-other-workflow", "runner-other")},
+		&batchv1.Job{ObjectMeta: hooksMeta("runner-other-job", "runner-other")},
+	}
+
+	fakec := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(runner).
+		WithObjects(deleted...).
+		WithObjects(kept...).
+		Build()
+
+	mctrl := gomock.NewController(t)
+	mghc := mghclient.NewMockClient(mctrl)
+	mghc.EXPECT().RemoveRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(nil)
+	r := &RunnerReconciler{
+		Client:      fakec,
+		Github:      mghc,
+		Credentials: &fakeClientGetter{clients: map[string]github.Client{"octorun": mghc}},
+		Scheme:      scheme,
+		Executor:    &remoteexec.FakeRemoteExecutor{},
+		Recorder:    new(record.FakeRecorder),
+	}
+
+	if _, err := r.Reconcile(context.Background(), reconcile.Request{
+		NamespacedName: client.ObjectKeyFromObject(runner),
+	}); err != nil {
+		t.Fatalf("RunnerReconciler.Reconcile() error = %v", err)
+	}
+
+	for _, obj := range deleted {
+		if err := fakec.Get(context.Background(), client.ObjectKeyFromObject(obj), obj); !apierrors.IsNotFound(err) {
+			t.Errorf("RunnerReconciler.Reconcile() kept %T %s, want it deleted", obj, obj.GetName())
+		}
+	}
+
+	for _, obj := range kept {
+		if err := fakec.Get(context.Background(), client.ObjectKeyFromObject(obj), obj); err != nil {
+			t.Errorf("RunnerReconciler.Reconcile() deleted %T %s, want it kept: %v", obj, obj.GetName(), err)
+		}
+	}
+}
+
+// TestContainerHooksRules_managerRole checks the manager role holds the permissions the container hooks Role
+// grants, which Kubernetes requires to create it, and those deleting the container hooks resources.
+func TestContainerHooksRules_managerRole(t *testing.T) {
+	f, err := os.Open("../config/rbac/role.yaml")
+	if err != nil {
+		t.Fatalf("unable to open the manager role: %v", err)
+	}
+
+	defer f.Close()
+	managerRole := &rbacv1.ClusterRole{}
+	if err := yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(managerRole); err != nil {
+		t.Fatalf("unable to decode the manager role: %v", err)
+	}
+
+	allows := func(group, resource, verb string) bool {
+		for _, rule := range managerRole.Rules {
+			if contains(rule.APIGroups, group) && contains(rule.Resources, resource) && contains(rule.Verbs, verb) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	required := containerHooksRules()
+	required = append(required,
+		rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods", "secrets"}, Verbs: []string{"deletecollection"}},
+		rbacv1.PolicyRule{APIGroups: []string{"batch"}, Resources: []string{"jobs"}, Verbs: []string{"deletecollection"}},
+	)
+	for _, rule := range required {
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				for _, verb := range rule.Verbs {
+					if !allows(group, resource, verb) {
+						t.Errorf("manager role does not allow %q on %q in group %q", verb, resource, group)
+					}
+				}
+			}
+		}
+	}
+
+	for _, verb := range []string{"escalate", "bind"} {
+		if allows(rbacv1.GroupName, "roles", verb) {
+			t.Errorf("manager role allows %q on roles", verb)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestRunnerReconciler_Reconcile_registrationFailedCredential(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+
+	tests := []struct {
+		name              string
+		credentialVersion func(credential *octorunv1.GitHubCredential, secret *corev1.Secret) string
+		expectFn          func(cmockr *mghclient.MockClientMockRecorder)
+		wantRequeue       bool
+	}{
+		{
+			name: "credential_unchanged",
+			credentialVersion: func(credential *octorunv1.GitHubCredential, secret *corev1.Secret) string {
+				return fmt.Sprintf("%d/%s", credential.Generation, secret.ResourceVersion)
+			},
+			expectFn:    func(cmockr *mghclient.MockClientMockRecorder) {},
+			wantRequeue: true,
 		},
 		{
-			name:     "runnerpod_has_success_phase",
-			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
-			runnerPodFn: func(runner *octorunv1.Runner) *corev1.Pod {
-				pod := podForRunner(runner)
-				pod.Status.Phase = corev1.PodSucceeded
-				pod.Status.StartTime = &metav1.Time{Time: time.Now()}
-				return pod
+			name: "credential_secret_changed",
+			credentialVersion: func(credential *octorunv1.GitHubCredential, secret *corev1.Secret) string {
+				return fmt.Sprintf("%d/%s", credential.Generation, "1")
 			},
-			runnerSecretFn: func(runner *octorunv1.Runner) *corev1.Secret { return &corev1.Secret{} },
 			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
 				cmockr.CreateRunnerToken(gomock.Any(), "https://github.com/octorun").Return(&gogithub.RegistrationToken{
-					Token: gogithub.String("anotherfaketoken"),
+					Token: gogithub.String("faketoken"),
 					ExpiresAt: &gogithub.Timestamp{
 						Time: time.Now().Add(1 * time.Hour),
 					},
 				}, nil)
 			},
-			executor: &remoteexec.FakeRemoteExecutor{},
-			want:     reconcile.Result{},
-			wantErr:  false,
+			wantRequeue: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mctrl := gomock.NewController(t)
 			mghc := mghclient.NewMockClient(mctrl)
+			tt.expectFn(mghc.EXPECT())
 
-			runner := tt.runnerFn(&octorunv1.Runner{
+			credential := &octorunv1.GitHubCredential{
+				ObjectMeta: metav1.ObjectMeta{Name: "octorun", Namespace: "default", Generation: 1},
+				Spec:       octorunv1.GitHubCredentialSpec{SecretName: "octorun-github"},
+			}
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "octorun-github", Namespace: "default"}}
+			fakec := fake.NewClientBuilder().WithScheme(scheme).WithObjects(credential, secret).Build()
+			if err := fakec.Get(context.Background(), client.ObjectKeyFromObject(secret), secret); err != nil {
+				t.Fatalf("unable to get the credential Secret: %v", err)
+			}
+
+			runner := &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "runner-test",
+					Namespace: "default",
+				},
+				Spec: octorunv1.RunnerSpec{
+					URL:           "https://github.com/octorun",
+					CredentialRef: &octorunv1.GitHubCredentialReference{Name: "octorun"},
+					Image: octorunv1.RunnerImage{
+						Name: "ghcr.io/octorun/runner",
+					},
+				},
+				Status: octorunv1.RunnerStatus{
+					Phase: octorunv1.RunnerFailedPhase,
+					Conditions: []metav1.Condition{
+						{
+							Type:               octorunv1.RunnerConditionRegistrationFailed,
+							Status:             metav1.ConditionTrue,
+							LastTransitionTime: metav1.Now(),
+							Reason:             octorunv1.RunnerRegistrationForbiddenReason,
+						},
+					},
+				},
+			}
+
+			annotations.AnnotateRegistrationCredentialVersion(runner, tt.credentialVersion(credential, secret))
+			if err := fakec.Create(context.Background(), runner); err != nil {
+				t.Fatalf("unable to create the Runner: %v", err)
+			}
+
+			r := &RunnerReconciler{
+				Client:      fakec,
+				Credentials: &fakeClientGetter{clients: map[string]github.Client{"octorun": mghc}},
+				Scheme:      scheme,
+				Executor:    &remoteexec.FakeRemoteExecutor{},
+				Recorder:    new(record.FakeRecorder),
+			}
+
+			got, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(runner)})
+			if err != nil {
+				t.Fatalf("RunnerReconciler.Reconcile() error = %v", err)
+			}
+			if (got.RequeueAfter > 0) != tt.wantRequeue {
+				t.Errorf("RunnerReconciler.Reconcile() = %v, wantRequeue %v", got, tt.wantRequeue)
+			}
+		})
+	}
+}
+
+func TestRunnerReconciler_secretToRunners(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+
+	runnerFn := func(name, credential string, failed bool) *octorunv1.Runner {
+		runner := &octorunv1.Runner{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		}
+
+		if credential != "" {
+			runner.Spec.CredentialRef = &octorunv1.GitHubCredentialReference{Name: credential}
+		}
+
+		if failed {
+			runner.Status.Conditions = []metav1.Condition{
+				{Type: octorunv1.RunnerConditionRegistrationFailed, Status: metav1.ConditionTrue},
+			}
+		}
+
+		return runner
+	}
+
+	fakec := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&octorunv1.GitHubCredential{
+				ObjectMeta: metav1.ObjectMeta{Name: "octorun", Namespace: "default"},
+				Spec:       octorunv1.GitHubCredentialSpec{SecretName: "octorun-github"},
+			},
+			&octorunv1.GitHubCredential{
+				ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+				Spec:       octorunv1.GitHubCredentialSpec{SecretName: "other-github"},
+			},
+			runnerFn("runner-failed", "octorun", true),
+			runnerFn("runner-registered", "octorun", false),
+			runnerFn("runner-other-credential", "other", true),
+			runnerFn("runner-default-credential", "", true),
+		).Build()
+
+	r := &RunnerReconciler{Client: fakec, Scheme: scheme}
+	want := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "runner-failed"}}}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "octorun-github", Namespace: "default"}}
+	if got := r.secretToRunners(secret); !reflect.DeepEqual(got, want) {
+		t.Errorf("RunnerReconciler.secretToRunners() = %v, want %v", got, want)
+	}
+
+	unrelated := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "runner-test", Namespace: "default"}}
+	if got := r.secretToRunners(unrelated); len(got) != 0 {
+		t.Errorf("RunnerReconciler.secretToRunners() = %v, want none", got)
+	}
+}
+
+// secretWriteFailingClient fails to create or update any Secret.
+type secretWriteFailingClient struct {
+	client.Client
+}
+
+func (c *secretWriteFailingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if _, ok := obj.(*corev1.Secret); ok {
+		return apierrors.NewInternalError(fmt.Errorf("etcdserver: request timed out"))
+	}
+
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *secretWriteFailingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if _, ok := obj.(*corev1.Secret); ok {
+		return apierrors.NewInternalError(fmt.Errorf("etcdserver: request timed out"))
+	}
+
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func TestRunnerReconciler_Reconcile_jitSecretFailed(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+
+	tests := []struct {
+		name     string
+		runnerID *int64
+		expectFn func(cmockr *mghclient.MockClientMockRecorder)
+	}{
+		{
+			name: "registered_runner_removed",
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.GenerateJITConfig(gomock.Any(), "https://github.com/octorun", gomock.Any()).Return(&ghclient.JITRunnerConfig{
+					Runner:           &gogithub.Runner{ID: gogithub.Int64(1)},
+					EncodedJITConfig: "fakejitconfig",
+				}, nil)
+				cmockr.RemoveRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(nil)
+			},
+		},
+		{
+			name:     "previously_registered_runner_removed",
+			runnerID: pointer.Int64(1),
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				gomock.InOrder(
+					cmockr.RemoveRunner(gomock.Any(), "https://github.com/octorun", int64(1)).Return(nil),
+					cmockr.GenerateJITConfig(gomock.Any(), "https://github.com/octorun", gomock.Any()).Return(&ghclient.JITRunnerConfig{
+						Runner:           &gogithub.Runner{ID: gogithub.Int64(2)},
+						EncodedJITConfig: "fakejitconfig",
+					}, nil),
+					cmockr.RemoveRunner(gomock.Any(), "https://github.com/octorun", int64(2)).Return(nil),
+				)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mctrl := gomock.NewController(t)
+			mghc := mghclient.NewMockClient(mctrl)
+
+			runner := &octorunv1.Runner{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "runner-test",
 					Namespace: "default",
@@ -583,44 +1690,296 @@ func TestRunnerReconciler_Reconcile(t *testing.T) {
 					},
 				},
 				Spec: octorunv1.RunnerSpec{
-					URL: "https://github.com/octorun",
+					URL:              "https://github.com/octorun",
+					ID:               tt.runnerID,
+					RegistrationMode: octorunv1.RunnerRegistrationJIT,
 					Image: octorunv1.RunnerImage{
 						Name: "ghcr.io/octorun/runner",
 					},
 				},
-			})
-
-			runnerPod := tt.runnerPodFn(runner)
-			runnerSecret := tt.runnerSecretFn(runner)
-			fakec := fake.NewClientBuilder().
-				WithScheme(scheme).
-				WithObjects(
-					runner,
-					runnerPod,
-					runnerSecret,
-				).Build()
+			}
 
+			fakec := fake.NewClientBuilder().WithScheme(scheme).WithObjects(runner).Build()
 			tt.expectFn(mghc.EXPECT())
 			r := &RunnerReconciler{
-				Client:   fakec,
-				Github:   mghc,
-				Scheme:   scheme,
-				Executor: tt.executor,
-				Recorder: new(record.FakeRecorder),
+				Client:      &secretWriteFailingClient{Client: fakec},
+				Github:      mghc,
+				Credentials: &fakeClientGetter{clients: map[string]github.Client{"octorun": mghc}},
+				Scheme:      scheme,
+				Executor:    &remoteexec.FakeRemoteExecutor{},
+				Recorder:    new(record.FakeRecorder),
 			}
 
-			got, err := r.Reconcile(context.Background(), reconcile.Request{
-				NamespacedName: types.NamespacedName{
-					Namespace: "default",
-					Name:      "runner-test",
-				},
+			if _, err := r.Reconcile(context.Background(), reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(runner),
+			}); err == nil {
+				t.Errorf("RunnerReconciler.Reconcile() error = nil, want the secret error")
+			}
+
+			got := &octorunv1.Runner{}
+			if err := fakec.Get(context.Background(), client.ObjectKeyFromObject(runner), got); err != nil {
+				t.Fatalf("unable to get the Runner: %v", err)
+			}
+			if got.Spec.ID != nil {
+				t.Errorf("RunnerReconciler.Reconcile() runner ID = %v, want nil", *got.Spec.ID)
+			}
+		})
+	}
+}
+
+func TestRunnerReconciler_reconcileJob(t *testing.T) {
+	completedAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	job := &octorunv1.RunnerJobStatus{
+		ID:           1,
+		Name:         "build",
+		RunID:        2,
+		WorkflowName: "CI",
+		Repository:   "octorun/octorun",
+		HeadBranch:   "main",
+		URL:          "https://github.com/octorun/octorun/runs/1",
+	}
+
+	tests := []struct {
+		name       string
+		runnerFn   func(runner *octorunv1.Runner) *octorunv1.Runner
+		want       bool
+		wantJob    *octorunv1.RunnerJobStatus
+		wantEvents int
+	}{
+		{
+			name:       "runner_without_job",
+			runnerFn:   func(runner *octorunv1.Runner) *octorunv1.Runner { return runner },
+			want:       false,
+			wantJob:    nil,
+			wantEvents: 0,
+		},
+		{
+			name: "runner_picked_up_job",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				annotations.AnnotateJob(runner, job)
+				return runner
+			},
+			want:       false,
+			wantJob:    job,
+			wantEvents: 1,
+		},
+		{
+			name: "runner_picked_up_job_already_recorded",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				annotations.AnnotateJob(runner, job)
+				runner.Status.Job = job.DeepCopy()
+				return runner
+			},
+			want:       false,
+			wantJob:    job,
+			wantEvents: 0,
+		},
+		{
+			name: "runner_completed_job",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				annotations.AnnotateJob(runner, job)
+				annotations.AnnotateJobCompleted(runner, completedAt, "success")
+				return runner
+			},
+			want: true,
+			wantJob: func() *octorunv1.RunnerJobStatus {
+				want := job.DeepCopy()
+				want.Conclusion = "success"
+				want.CompletionTime = &metav1.Time{Time: completedAt}
+				return want
+			}(),
+			wantEvents: 2,
+		},
+		{
+			name: "persistent_runner_completed_job",
+			runnerFn: func(runner *octorunv1.Runner) *octorunv1.Runner {
+				runner.Spec.Lifecycle = octorunv1.RunnerLifecyclePersistent
+				annotations.AnnotateJob(runner, job)
+				annotations.AnnotateJobCompleted(runner, completedAt, "failure")
+				return runner
+			},
+			want: false,
+			wantJob: func() *octorunv1.RunnerJobStatus {
+				want := job.DeepCopy()
+				want.Conclusion = "failure"
+				want.CompletionTime = &metav1.Time{Time: completedAt}
+				return want
+			}(),
+			wantEvents: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &RunnerReconciler{Recorder: recorder}
+			runner := tt.runnerFn(&octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{Name: "runner-test", Namespace: "default"},
+				Spec:       octorunv1.RunnerSpec{URL: "https://github.com/octorun"},
 			})
-			if (err != nil) != tt.wantErr {
-				t.Errorf("RunnerReconciler.Reconcile() error = %v, wantErr %v", err, tt.wantErr)
-				return
+
+			if got := r.reconcileJob(runner); got != tt.want {
+				t.Errorf("RunnerReconciler.reconcileJob() = %v, want %v", got, tt.want)
 			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("RunnerReconciler.Reconcile() = %v, want %v", got, tt.want)
+			if !reflect.DeepEqual(runner.Status.Job, tt.wantJob) {
+				t.Errorf("RunnerReconciler.reconcileJob() job = %v, want %v", runner.Status.Job, tt.wantJob)
+			}
+			if got := len(recorder.Events); got != tt.wantEvents {
+				t.Errorf("RunnerReconciler.reconcileJob() events = %v, want %v", got, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestIsFirstOnline(t *testing.T) {
+	tests := []struct {
+		name        string
+		lastPhase   octorunv1.RunnerPhase
+		restarts    int32
+		provisioned bool
+		want        bool
+	}{
+		{name: "new_runner", lastPhase: "", want: true},
+		{name: "pending_runner", lastPhase: octorunv1.RunnerPendingPhase, want: true},
+		{name: "restarted_pending_runner", lastPhase: octorunv1.RunnerPendingPhase, restarts: 1, want: true},
+		{name: "pending_again_runner", lastPhase: octorunv1.RunnerPendingPhase, provisioned: true, want: false},
+		{name: "idle_runner", lastPhase: octorunv1.RunnerIdlePhase, provisioned: true, want: false},
+		{name: "active_runner", lastPhase: octorunv1.RunnerActivePhase, provisioned: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &octorunv1.Runner{Status: octorunv1.RunnerStatus{Restarts: tt.restarts}}
+			if tt.provisioned {
+				annotations.AnnotateProvisioned(runner)
+			}
+			if got := isFirstOnline(runner, tt.lastPhase); got != tt.want {
+				t.Errorf("isFirstOnline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunnerPodBackoff(t *testing.T) {
+	tests := []struct {
+		restarts int32
+		want     time.Duration
+	}{
+		{restarts: 0, want: 10 * time.Second},
+		{restarts: 1, want: 20 * time.Second},
+		{restarts: 2, want: 40 * time.Second},
+		{restarts: 4, want: 160 * time.Second},
+		{restarts: 5, want: 5 * time.Minute},
+		{restarts: 100, want: 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("restarts_%d", tt.restarts), func(t *testing.T) {
+			if got := runnerPodBackoff(tt.restarts); got != tt.want {
+				t.Errorf("runnerPodBackoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodForRunner_Docker(t *testing.T) {
+	tests := []struct {
+		name          string
+		docker        *octorunv1.RunnerDocker
+		wantImage     string
+		wantRunAsUser *int64
+	}{
+		{
+			name:          "rootless",
+			docker:        &octorunv1.RunnerDocker{Mode: octorunv1.RunnerDockerRootless},
+			wantImage:     "docker:dind-rootless",
+			wantRunAsUser: nil,
+		},
+		{
+			name:          "privileged",
+			docker:        &octorunv1.RunnerDocker{Mode: octorunv1.RunnerDockerPrivileged},
+			wantImage:     "docker:dind",
+			wantRunAsUser: pointer.Int64(0),
+		},
+		{
+			name:          "custom_image",
+			docker:        &octorunv1.RunnerDocker{Mode: octorunv1.RunnerDockerRootless, Image: "docker:24-dind-rootless"},
+			wantImage:     "docker:24-dind-rootless",
+			wantRunAsUser: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runnerPod := podForRunner(&octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{Name: "runner-test", Namespace: "default"},
+				Spec:       octorunv1.RunnerSpec{URL: "https://github.com/octorun", Docker: tt.docker},
+			}, "")
+
+			if len(runnerPod.Spec.Containers) != 2 {
+				t.Fatalf("podForRunner() containers = %d, want 2", len(runnerPod.Spec.Containers))
+			}
+
+			sidecar := runnerPod.Spec.Containers[1]
+			if sidecar.Image != tt.wantImage {
+				t.Errorf("podForRunner() docker image = %v, want %v", sidecar.Image, tt.wantImage)
+			}
+			if !reflect.DeepEqual(sidecar.SecurityContext.RunAsUser, tt.wantRunAsUser) {
+				t.Errorf("podForRunner() docker runAsUser = %v, want %v", sidecar.SecurityContext.RunAsUser, tt.wantRunAsUser)
+			}
+
+			var dockerHostEnv string
+			for _, env := range runnerPod.Spec.Containers[0].Env {
+				if env.Name == "DOCKER_HOST" {
+					dockerHostEnv = env.Value
+				}
+			}
+			if dockerHostEnv != dockerHost {
+				t.Errorf("podForRunner() runner DOCKER_HOST = %v, want %v", dockerHostEnv, dockerHost)
+			}
+		})
+	}
+}
+
+func TestGithubRunnerLabels(t *testing.T) {
+	runner := &octorunv1.Runner{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "runner-test",
+			Namespace: "default",
+			Labels: map[string]string{
+				octorunv1.LabelRunnerName:   "runner-test",
+				octorunv1.LabelControllerID: "spoofed",
+				"app":                       "foo",
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		controllerID string
+		want         []string
+	}{
+		{
+			name:         "with_controller_id",
+			controllerID: "cluster-a",
+			want:         []string{"controller-id=cluster-a", "runner=runner-test"},
+		},
+		{
+			name:         "without_controller_id",
+			controllerID: "",
+			want:         []string{"controller-id=spoofed", "runner=runner-test"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := githubRunnerLabels(runner, tt.controllerID); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("githubRunnerLabels() = %v, want %v", got, tt.want)
+			}
+
+			var runnerLabelsEnv string
+			for _, env := range podForRunner(runner, tt.controllerID).Spec.Containers[0].Env {
+				if env.Name == "RUNNER_LABELS" {
+					runnerLabelsEnv = env.Value
+				}
+			}
+			if want := strings.Join(tt.want, ","); runnerLabelsEnv != want {
+				t.Errorf("podForRunner() RUNNER_LABELS = %v, want %v", runnerLabelsEnv, want)
 			}
 		})
 	}
@@ -0,0 +1,203 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	gogithub "github.com/google/go-github/v41/github"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+	"octorun.github.io/octorun/pkg/github"
+)
+
+const RunnerPollerController = "runnerpoller.octorun.github.io/controller"
+
+// RunnerPoller periodically lists the Github runners of every Runner URL and keeps them indexed by runner ID,
+// so the RunnerReconciler reads the runner status from the snapshot instead of getting every runner from Github.
+// The Github API usage scales with the number of organizations and repositories instead of the number of runners.
+// Runners whose Github runner has changed between two polls are sent to the Events channel.
+type RunnerPoller struct {
+	client.Client
+	Github github.Client
+
+	// Credentials provides the Github client of the runners referencing a GitHubCredential.
+	Credentials github.ClientGetter
+
+	// Interval is the period between polls.
+	Interval time.Duration
+
+	mu        sync.RWMutex
+	snapshots map[runnerTarget]runnerSnapshot
+
+	eventsOnce sync.Once
+	events     chan event.GenericEvent
+}
+
+// runnerSnapshot is the Github runners of a runner target indexed by runner ID.
+type runnerSnapshot struct {
+	runners  map[int64]*gogithub.Runner
+	polledAt time.Time
+}
+
+// SetupWithManager sets up the poller with the Manager.
+func (p *RunnerPoller) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	return mgr.Add(p)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+// Only the leader reconciles the Runners.
+func (p *RunnerPoller) NeedLeaderElection() bool { return true }
+
+// Start implements manager.Runnable.
+func (p *RunnerPoller) Start(ctx context.Context) error {
+	log := ctrl.Log.WithName(RunnerPollerController)
+	ctx = ctrl.LoggerInto(ctx, log)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := p.Poll(ctx); err != nil {
+			log.Error(err, "unable to poll Github runners")
+		}
+	}, p.Interval)
+	return nil
+}
+
+// Events returns the source of the Runners whose Github runner has changed.
+func (p *RunnerPoller) Events() source.Source {
+	return &source.Channel{Source: p.eventsChannel()}
+}
+
+func (p *RunnerPoller) eventsChannel() chan event.GenericEvent {
+	p.eventsOnce.Do(func() {
+		p.events = make(chan event.GenericEvent, 1024)
+	})
+
+	return p.events
+}
+
+// Runner returns the Github runner with the given id of the given Runner from the last poll.
+// It returns false if the runner target has not been polled recently or the runner was not
+// registered yet when it was polled.
+func (p *RunnerPoller) Runner(runner *octorunv1.Runner, runnerID int64) (*gogithub.Runner, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	snapshot, ok := p.snapshots[runnerTargetFor(runner.Namespace, &runner.Spec)]
+	if !ok || time.Since(snapshot.polledAt) > 2*p.Interval {
+		return nil, false
+	}
+
+	ghrunner, ok := snapshot.runners[runnerID]
+	return ghrunner, ok
+}
+
+// Poll lists the Github runners of every distinct Runner URL and replaces the snapshot of each one.
+// The Runners whose Github runner has appeared, disappeared or changed its status are sent to the Events channel.
+func (p *RunnerPoller) Poll(ctx context.Context) error {
+	log := ctrl.LoggerFrom(ctx)
+	runnerList := &octorunv1.RunnerList{}
+	if err := p.List(ctx, runnerList); err != nil {
+		return err
+	}
+
+	targets := make(map[runnerTarget][]*octorunv1.Runner)
+	for i := range runnerList.Items {
+		runner := &runnerList.Items[i]
+		if runner.Spec.URL == "" || runner.Spec.ID == nil {
+			continue
+		}
+
+		t := runnerTargetFor(runner.Namespace, &runner.Spec)
+		targets[t] = append(targets[t], runner)
+	}
+
+	var errs []error
+	snapshots := make(map[runnerTarget]runnerSnapshot, len(targets))
+	for t, runners := range targets {
+		snapshot, err := p.poll(ctx, t)
+		if err != nil {
+			// Reconcilers get the runners of the target from Github until it is polled again successfully.
+			log.Error(err, "unable to list Github runners", "url", t.URL, "credential", t.Credential)
+			errs = append(errs, err)
+			continue
+		}
+
+		snapshots[t] = snapshot
+		p.mu.RLock()
+		previous, ok := p.snapshots[t]
+		p.mu.RUnlock()
+		if !ok {
+			continue
+		}
+
+		for _, runner := range runners {
+			if runnerChanged(previous.runners[*runner.Spec.ID], snapshot.runners[*runner.Spec.ID]) {
+				p.enqueue(ctx, runner)
+			}
+		}
+	}
+
+	p.mu.Lock()
+	p.snapshots = snapshots
+	p.mu.Unlock()
+	return kerrors.NewAggregate(errs)
+}
+
+func (p *RunnerPoller) poll(ctx context.Context, t runnerTarget) (runnerSnapshot, error) {
+	ghc, err := t.githubClient(ctx, p.Github, p.Credentials)
+	if err != nil {
+		return runnerSnapshot{}, err
+	}
+
+	ghrunners, err := ghc.ListRunners(ctx, t.URL)
+	if err != nil {
+		return runnerSnapshot{}, err
+	}
+
+	snapshot := runnerSnapshot{
+		runners:  make(map[int64]*gogithub.Runner, len(ghrunners)),
+		polledAt: time.Now(),
+	}
+
+	for _, ghrunner := range ghrunners {
+		snapshot.runners[ghrunner.GetID()] = ghrunner
+	}
+
+	return snapshot, nil
+}
+
+func (p *RunnerPoller) enqueue(ctx context.Context, runner *octorunv1.Runner) {
+	select {
+	case p.eventsChannel() <- event.GenericEvent{Object: runner}:
+	case <-ctx.Done():
+	}
+}
+
+// runnerChanged returns true if the Github runner has appeared, disappeared or changed its status between two polls.
+func runnerChanged(previous, current *gogithub.Runner) bool {
+	if previous == nil || current == nil {
+		return previous != current
+	}
+
+	return previous.GetStatus() != current.GetStatus() || previous.GetBusy() != current.GetBusy()
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	gogithub "github.com/google/go-github/v41/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+	mghclient "octorun.github.io/octorun/pkg/github/client/mock"
+)
+
+func TestRunnerPoller_Poll(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+
+	ghrunnerFn := func(id int64, status string, busy bool) *gogithub.Runner {
+		return &gogithub.Runner{
+			ID:     gogithub.Int64(id),
+			Status: gogithub.String(status),
+			Busy:   gogithub.Bool(busy),
+		}
+	}
+
+	runner1 := &octorunv1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Name: "runner-1", Namespace: "default"},
+		Spec:       octorunv1.RunnerSpec{URL: "https://github.com/octorun", ID: pointer.Int64(1)},
+	}
+	runner2 := &octorunv1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Name: "runner-2", Namespace: "default"},
+		Spec:       octorunv1.RunnerSpec{URL: "https://github.com/octorun", ID: pointer.Int64(2)},
+	}
+	runner3 := &octorunv1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Name: "runner-3", Namespace: "default"},
+		Spec:       octorunv1.RunnerSpec{URL: "https://github.com/octorun", ID: pointer.Int64(3)},
+	}
+
+	tests := []struct {
+		name          string
+		secondPollFn  func(cmockr *mghclient.MockClientMockRecorder)
+		wantEnqueued  []string
+		wantSnapshot  bool
+		wantRunner1   string
+		wantSecondErr bool
+	}{
+		{
+			name: "runners_unchanged",
+			secondPollFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.ListRunners(gomock.Any(), "https://github.com/octorun").Return([]*gogithub.Runner{
+					ghrunnerFn(1, "online", false),
+					ghrunnerFn(2, "online", true),
+				}, nil)
+			},
+			wantSnapshot: true,
+			wantRunner1:  "online",
+		},
+		{
+			name: "runners_changed",
+			secondPollFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.ListRunners(gomock.Any(), "https://github.com/octorun").Return([]*gogithub.Runner{
+					ghrunnerFn(1, "offline", false),
+					ghrunnerFn(2, "online", true),
+					ghrunnerFn(3, "online", false),
+				}, nil)
+			},
+			wantEnqueued: []string{"runner-1", "runner-3"},
+			wantSnapshot: true,
+			wantRunner1:  "offline",
+		},
+		{
+			name: "runners_list_failed",
+			secondPollFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.ListRunners(gomock.Any(), "https://github.com/octorun").Return(nil, errors.New("boom"))
+			},
+			wantSnapshot:  false,
+			wantSecondErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mghc := mghclient.NewMockClient(mockCtrl)
+			mghc.EXPECT().ListRunners(gomock.Any(), "https://github.com/octorun").Return([]*gogithub.Runner{
+				ghrunnerFn(1, "online", false),
+				ghrunnerFn(2, "online", true),
+			}, nil)
+			tt.secondPollFn(mghc.EXPECT())
+
+			p := &RunnerPoller{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects([]client.Object{runner1.DeepCopy(), runner2.DeepCopy(), runner3.DeepCopy()}...).
+					Build(),
+				Github:   mghc,
+				Interval: time.Minute,
+			}
+
+			if err := p.Poll(context.Background()); err != nil {
+				t.Errorf("RunnerPoller.Poll() error = %v", err)
+				return
+			}
+			if n := len(p.eventsChannel()); n != 0 {
+				t.Errorf("RunnerPoller.Poll() enqueued %d runners on the first poll, want 0", n)
+			}
+
+			if err := p.Poll(context.Background()); (err != nil) != tt.wantSecondErr {
+				t.Errorf("RunnerPoller.Poll() error = %v, wantErr %v", err, tt.wantSecondErr)
+				return
+			}
+
+			var gotEnqueued []string
+			for len(p.eventsChannel()) > 0 {
+				gotEnqueued = append(gotEnqueued, (<-p.eventsChannel()).Object.GetName())
+			}
+			sort.Strings(gotEnqueued)
+			if !reflect.DeepEqual(gotEnqueued, tt.wantEnqueued) {
+				t.Errorf("RunnerPoller.Poll() enqueued = %v, want %v", gotEnqueued, tt.wantEnqueued)
+			}
+
+			ghrunner, ok := p.Runner(runner1, 1)
+			if ok != tt.wantSnapshot {
+				t.Errorf("RunnerPoller.Runner() ok = %v, want %v", ok, tt.wantSnapshot)
+				return
+			}
+			if ok && ghrunner.GetStatus() != tt.wantRunner1 {
+				t.Errorf("RunnerPoller.Runner() status = %v, want %v", ghrunner.GetStatus(), tt.wantRunner1)
+			}
+		})
+	}
+}
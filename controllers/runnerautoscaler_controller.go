@@ -0,0 +1,407 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/scale"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+	"octorun.github.io/octorun/util/patch"
+)
+
+const RunnerAutoscalerController = "runnerautoscaler.octorun.github.io/controller"
+
+// maxQueuedJobAge is how long Github keeps a workflow job queued before it is cancelled. A WorkflowJob
+// queued for longer has missed its later events and is no longer counted as an inflight job.
+const maxQueuedJobAge = 24 * time.Hour
+
+// scheduleResyncPeriod is how often the RunnerAutoscaler with schedules is
+// reconciled to pick up the start or the end of a schedule time window.
+const scheduleResyncPeriod = time.Minute
+
+// runnerSetResource is the resource whose scale subresource the RunnerAutoscaler scales.
+var runnerSetResource = octorunv1.GroupVersion.WithResource("runnersets").GroupResource()
+
+// RunnerAutoscalerReconciler reconciles a RunnerAutoscaler object
+type RunnerAutoscalerReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// ScaleClient reads and updates the number of runners of the target RunnerSet
+	// through its scale subresource.
+	ScaleClient scale.ScalesGetter
+}
+
+// +kubebuilder:rbac:groups=octorun.github.io,resources=runnerautoscalers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=octorun.github.io,resources=runnerautoscalers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=octorun.github.io,resources=runnerautoscalers/finalizers,verbs=update
+// +kubebuilder:rbac:groups=octorun.github.io,resources=runnersets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=octorun.github.io,resources=runnersets/scale,verbs=get;update;patch
+// +kubebuilder:rbac:groups=octorun.github.io,resources=runners,verbs=get;list;watch
+// +kubebuilder:rbac:groups=octorun.github.io,resources=workflowjobs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;update;patch
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RunnerAutoscalerReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&octorunv1.RunnerAutoscaler{}).
+		Watches(&source.Kind{Type: &octorunv1.WorkflowJob{}}, handler.EnqueueRequestsFromMapFunc(r.workflowJobToAutoscalers)).
+		Watches(&source.Kind{Type: &octorunv1.Runner{}}, handler.EnqueueRequestsFromMapFunc(r.runnerToAutoscalers)).
+		Complete(r)
+}
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *RunnerAutoscalerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+	autoscaler := &octorunv1.RunnerAutoscaler{}
+	if err := r.Get(ctx, req.NamespacedName, autoscaler); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Return early if requested runner autoscaler is not found.
+			log.V(1).Info("RunnerAutoscaler resource not found or already deleted")
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	patcher, err := patch.NewPatcher(r.Client, autoscaler)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		if err := patcher.Patch(ctx, autoscaler, client.FieldOwner(RunnerAutoscalerController)); err != nil {
+			reterr = err
+		}
+	}()
+
+	if !autoscaler.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	runnerset := &octorunv1.RunnerSet{}
+	runnersetKey := client.ObjectKey{Namespace: autoscaler.Namespace, Name: autoscaler.Spec.ScaleTargetRef.Name}
+	if err := r.Get(ctx, runnersetKey, runnerset); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Nothing to scale. It will be reconciled again once the autoscaler
+			// spec or status has changed.
+			log.V(1).Info("target RunnerSet not found", "runnerset", runnersetKey.String())
+			meta.SetStatusCondition(&autoscaler.Status.Conditions, metav1.Condition{
+				Type:    octorunv1.RunnerAutoscalerConditionAbleToScale,
+				Status:  metav1.ConditionFalse,
+				Reason:  octorunv1.RunnerSetNotFoundReason,
+				Message: fmt.Sprintf("RunnerSet %s not found", runnersetKey.Name),
+			})
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	inflightJobs, err := r.countInflightJobs(ctx, runnerset)
+	if err != nil {
+		log.Error(err, "unable to count inflight jobs", "runnerset", runnerset.Name)
+		return ctrl.Result{}, err
+	}
+
+	autoscaler.Status.InflightJobs = inflightJobs
+	var result ctrl.Result
+	if len(autoscaler.Spec.Schedules) > 0 {
+		result.RequeueAfter = scheduleResyncPeriod
+	}
+
+	minRunners := pointer.Int32Deref(autoscaler.Spec.MinRunners, 1)
+	schedule, err := activeSchedule(autoscaler, time.Now())
+	if err != nil {
+		log.Error(err, "invalid RunnerAutoscaler schedule")
+		meta.SetStatusCondition(&autoscaler.Status.Conditions, metav1.Condition{
+			Type:    octorunv1.RunnerAutoscalerConditionAbleToScale,
+			Status:  metav1.ConditionFalse,
+			Reason:  octorunv1.InvalidScheduleReason,
+			Message: err.Error(),
+		})
+		return ctrl.Result{}, nil
+	}
+
+	autoscaler.Status.ActiveSchedule = ""
+	if schedule != nil {
+		autoscaler.Status.ActiveSchedule = schedule.Name
+		minRunners = schedule.MinRunners
+	}
+
+	runnersetScale, err := r.ScaleClient.Scales(runnerset.Namespace).Get(ctx, runnerSetResource, runnerset.Name, metav1.GetOptions{})
+	if err != nil {
+		log.Error(err, "unable to get RunnerSet scale", "runnerset", runnerset.Name)
+		return ctrl.Result{}, err
+	}
+
+	desiredRunners := desiredRunnersForAutoscaler(autoscaler, minRunners)
+	currentRunners := runnersetScale.Spec.Replicas
+	autoscaler.Status.DesiredRunners = desiredRunners
+	if desiredRunners == currentRunners {
+		log.V(1).Info("RunnerSet already has desired runners", "runnerset", runnerset.Name, "desired", desiredRunners)
+		meta.SetStatusCondition(&autoscaler.Status.Conditions, metav1.Condition{
+			Type:    octorunv1.RunnerAutoscalerConditionAbleToScale,
+			Status:  metav1.ConditionTrue,
+			Reason:  octorunv1.ReadyForNewScaleReason,
+			Message: "RunnerSet has desired runners",
+		})
+		return result, nil
+	}
+
+	if desiredRunners < currentRunners && autoscaler.Status.LastScaleTime != nil {
+		// Only scale down once the cooldown period since the last scale has passed
+		// to prevent flapping when jobs are queued in bursts.
+		cooldown := 5 * time.Minute
+		if autoscaler.Spec.CooldownPeriod != nil {
+			cooldown = autoscaler.Spec.CooldownPeriod.Duration
+		}
+
+		if remaining := time.Until(autoscaler.Status.LastScaleTime.Add(cooldown)); remaining > 0 {
+			log.V(1).Info("waiting for cooldown period before scaling down", "runnerset", runnerset.Name, "remaining", remaining)
+			meta.SetStatusCondition(&autoscaler.Status.Conditions, metav1.Condition{
+				Type:    octorunv1.RunnerAutoscalerConditionAbleToScale,
+				Status:  metav1.ConditionTrue,
+				Reason:  octorunv1.ScaleDownCooldownReason,
+				Message: "Waiting for cooldown period before scaling down",
+			})
+			if result.RequeueAfter == 0 || remaining < result.RequeueAfter {
+				result.RequeueAfter = remaining
+			}
+
+			return result, nil
+		}
+	}
+
+	log.Info("scaling RunnerSet", "runnerset", runnerset.Name, "current", currentRunners, "desired", desiredRunners)
+	runnersetScale.Spec.Replicas = desiredRunners
+	if _, err := r.ScaleClient.Scales(runnerset.Namespace).Update(ctx, runnerSetResource, runnersetScale, metav1.UpdateOptions{}); err != nil {
+		log.Error(err, "unable to scale RunnerSet", "runnerset", runnerset.Name)
+		meta.SetStatusCondition(&autoscaler.Status.Conditions, metav1.Condition{
+			Type:    octorunv1.RunnerAutoscalerConditionAbleToScale,
+			Status:  metav1.ConditionFalse,
+			Reason:  octorunv1.RunnerSetScaleFailedReason,
+			Message: err.Error(),
+		})
+		return ctrl.Result{}, err
+	}
+
+	now := metav1.Now()
+	autoscaler.Status.LastScaleTime = &now
+	r.Recorder.Eventf(autoscaler, corev1.EventTypeNormal, octorunv1.RunnerSetScaledReason, "Scaled RunnerSet %s from %d to %d", runnerset.Name, currentRunners, desiredRunners)
+	meta.SetStatusCondition(&autoscaler.Status.Conditions, metav1.Condition{
+		Type:    octorunv1.RunnerAutoscalerConditionAbleToScale,
+		Status:  metav1.ConditionTrue,
+		Reason:  octorunv1.RunnerSetScaledReason,
+		Message: fmt.Sprintf("RunnerSet scaled to %d", desiredRunners),
+	})
+	return result, nil
+}
+
+// countInflightJobs returns the number of workflow jobs for the given RunnerSet, that is the jobs queued for it
+// as recorded by the WorkflowJobs labeled with its runnerset label plus the jobs run by its active Runners.
+// It is counted from the cluster state every time rather than from the workflow_job events since Github
+// may deliver an event more than once or not at all.
+func (r *RunnerAutoscalerReconciler) countInflightJobs(ctx context.Context, runnerset *octorunv1.RunnerSet) (int32, error) {
+	var inflightJobs int32
+	if name, ok := runnerset.Spec.Template.Labels[octorunv1.LabelRunnerSetName]; ok {
+		workflowJobList := &octorunv1.WorkflowJobList{}
+		if err := r.List(ctx, workflowJobList, client.InNamespace(runnerset.Namespace), client.MatchingLabels{octorunv1.LabelRunnerSetName: name}); err != nil {
+			return 0, err
+		}
+
+		for i := range workflowJobList.Items {
+			status := &workflowJobList.Items[i].Status
+			if status.Phase != octorunv1.WorkflowJobQueuedPhase {
+				continue
+			}
+
+			if status.QueuedTime != nil && time.Since(status.QueuedTime.Time) > maxQueuedJobAge {
+				continue
+			}
+
+			inflightJobs++
+		}
+	}
+
+	selectorMap, err := metav1.LabelSelectorAsMap(&runnerset.Spec.Selector)
+	if err != nil {
+		return 0, err
+	}
+
+	runnerList := &octorunv1.RunnerList{}
+	if err := r.List(ctx, runnerList, client.InNamespace(runnerset.Namespace), client.MatchingLabels(selectorMap)); err != nil {
+		return 0, err
+	}
+
+	for i := range runnerList.Items {
+		runner := &runnerList.Items[i]
+		if metav1.IsControlledBy(runner, runnerset) && runner.Status.Phase == octorunv1.RunnerActivePhase {
+			inflightJobs++
+		}
+	}
+
+	return inflightJobs, nil
+}
+
+// workflowJobToAutoscalers returns a request for every RunnerAutoscaler in the namespace of given WorkflowJob,
+// the WorkflowJob runnerset label is the label of the RunnerSet template which may differ from the RunnerSet name.
+func (r *RunnerAutoscalerReconciler) workflowJobToAutoscalers(o client.Object) []reconcile.Request {
+	autoscalerList := &octorunv1.RunnerAutoscalerList{}
+	if err := r.List(context.Background(), autoscalerList, client.InNamespace(o.GetNamespace())); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range autoscalerList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&autoscalerList.Items[i])})
+	}
+
+	return requests
+}
+
+// runnerToAutoscalers returns a request for every RunnerAutoscaler targeting the RunnerSet that controls given Runner.
+func (r *RunnerAutoscalerReconciler) runnerToAutoscalers(o client.Object) []reconcile.Request {
+	owner := metav1.GetControllerOf(o)
+	if owner == nil || owner.Kind != "RunnerSet" {
+		return nil
+	}
+
+	autoscalerList := &octorunv1.RunnerAutoscalerList{}
+	if err := r.List(context.Background(), autoscalerList, client.InNamespace(o.GetNamespace())); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range autoscalerList.Items {
+		autoscaler := &autoscalerList.Items[i]
+		if autoscaler.Spec.ScaleTargetRef.Name == owner.Name {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(autoscaler)})
+		}
+	}
+
+	return requests
+}
+
+// desiredRunnersForAutoscaler returns the number of runners needed for the
+// observed inflight jobs bounded by given min and the autoscaler max runners.
+func desiredRunnersForAutoscaler(autoscaler *octorunv1.RunnerAutoscaler, minRunners int32) int32 {
+	desired := autoscaler.Status.InflightJobs
+	if desired < minRunners {
+		desired = minRunners
+	}
+
+	if max := autoscaler.Spec.MaxRunners; desired > max {
+		desired = max
+	}
+
+	return desired
+}
+
+// activeSchedule returns the first schedule of given RunnerAutoscaler whose
+// time window contains given time. It returns nil if there is no active schedule.
+func activeSchedule(autoscaler *octorunv1.RunnerAutoscaler, now time.Time) (*octorunv1.RunnerAutoscalerSchedule, error) {
+	for i := range autoscaler.Spec.Schedules {
+		schedule := &autoscaler.Spec.Schedules[i]
+		active, err := scheduleIsActive(schedule, now)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", schedule.Name, err)
+		}
+
+		if active {
+			return schedule, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// scheduleIsActive determines if given time is within the schedule time window.
+// Since a window may end on the following day, the window started on the previous
+// day is checked as well.
+func scheduleIsActive(schedule *octorunv1.RunnerAutoscalerSchedule, now time.Time) (bool, error) {
+	loc := time.UTC
+	if schedule.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(schedule.TimeZone); err != nil {
+			return false, err
+		}
+	}
+
+	start, err := time.Parse("15:04", schedule.Start)
+	if err != nil {
+		return false, err
+	}
+
+	end, err := time.Parse("15:04", schedule.End)
+	if err != nil {
+		return false, err
+	}
+
+	now = now.In(loc)
+	for _, offset := range []int{0, -1} {
+		day := now.AddDate(0, 0, offset)
+		if !scheduleRunsOn(schedule, day.Weekday()) {
+			continue
+		}
+
+		windowStart := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		windowEnd := time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+		if !windowEnd.After(windowStart) {
+			windowEnd = windowEnd.AddDate(0, 0, 1)
+		}
+
+		if !now.Before(windowStart) && now.Before(windowEnd) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// scheduleRunsOn determines if the schedule time window starts on given weekday.
+func scheduleRunsOn(schedule *octorunv1.RunnerAutoscalerSchedule, weekday time.Weekday) bool {
+	if len(schedule.Days) == 0 {
+		return true
+	}
+
+	for _, day := range schedule.Days {
+		if string(day) == weekday.String() {
+			return true
+		}
+	}
+
+	return false
+}
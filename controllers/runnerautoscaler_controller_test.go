@@ -0,0 +1,419 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/scale"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+)
+
+func TestRunnerAutoscalerReconciler_Reconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+
+	tests := []struct {
+		name             string
+		autoscalerFn     func(ra *octorunv1.RunnerAutoscaler) *octorunv1.RunnerAutoscaler
+		runnersetFn      func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet
+		queuedJobs       int
+		activeRunners    int
+		wantRequeue      bool
+		wantRunners      int32
+		wantInflightJobs int32
+		wantReason       string
+		wantErr          bool
+	}{
+		{
+			name:         "autoscaler_not_found",
+			autoscalerFn: func(ra *octorunv1.RunnerAutoscaler) *octorunv1.RunnerAutoscaler { return &octorunv1.RunnerAutoscaler{} },
+			runnersetFn:  func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet { return rs },
+			wantRunners:  1,
+			wantErr:      false,
+		},
+		{
+			name:         "runnerset_not_found",
+			autoscalerFn: func(ra *octorunv1.RunnerAutoscaler) *octorunv1.RunnerAutoscaler { return ra },
+			runnersetFn:  func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet { return &octorunv1.RunnerSet{} },
+			wantReason:   octorunv1.RunnerSetNotFoundReason,
+			wantErr:      false,
+		},
+		{
+			name:         "runnerset_has_desired_runners",
+			autoscalerFn: func(ra *octorunv1.RunnerAutoscaler) *octorunv1.RunnerAutoscaler { return ra },
+			runnersetFn:  func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet { return rs },
+			wantRunners:  1,
+			wantReason:   octorunv1.ReadyForNewScaleReason,
+			wantErr:      false,
+		},
+		{
+			name:             "inflight_jobs_scale_up",
+			autoscalerFn:     func(ra *octorunv1.RunnerAutoscaler) *octorunv1.RunnerAutoscaler { return ra },
+			runnersetFn:      func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet { return rs },
+			queuedJobs:       2,
+			activeRunners:    1,
+			wantRunners:      3,
+			wantInflightJobs: 3,
+			wantReason:       octorunv1.RunnerSetScaledReason,
+			wantErr:          false,
+		},
+		{
+			name:             "inflight_jobs_exceed_max_runners",
+			autoscalerFn:     func(ra *octorunv1.RunnerAutoscaler) *octorunv1.RunnerAutoscaler { return ra },
+			runnersetFn:      func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet { return rs },
+			queuedJobs:       8,
+			activeRunners:    2,
+			wantRunners:      5,
+			wantInflightJobs: 10,
+			wantReason:       octorunv1.RunnerSetScaledReason,
+			wantErr:          false,
+		},
+		{
+			name: "stale_inflight_jobs_recounted",
+			autoscalerFn: func(ra *octorunv1.RunnerAutoscaler) *octorunv1.RunnerAutoscaler {
+				ra.Status.InflightJobs = 4
+				return ra
+			},
+			runnersetFn:      func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet { return rs },
+			wantRunners:      1,
+			wantInflightJobs: 0,
+			wantReason:       octorunv1.ReadyForNewScaleReason,
+			wantErr:          false,
+		},
+		{
+			name: "active_schedule_scale_up",
+			autoscalerFn: func(ra *octorunv1.RunnerAutoscaler) *octorunv1.RunnerAutoscaler {
+				ra.Spec.Schedules = []octorunv1.RunnerAutoscalerSchedule{
+					{
+						Name:       "always",
+						Start:      "00:00",
+						End:        "00:00",
+						MinRunners: 4,
+					},
+				}
+				return ra
+			},
+			runnersetFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet { return rs },
+			wantRequeue: true,
+			wantRunners: 4,
+			wantReason:  octorunv1.RunnerSetScaledReason,
+			wantErr:     false,
+		},
+		{
+			name: "invalid_schedule_time_zone",
+			autoscalerFn: func(ra *octorunv1.RunnerAutoscaler) *octorunv1.RunnerAutoscaler {
+				ra.Spec.Schedules = []octorunv1.RunnerAutoscalerSchedule{
+					{
+						Name:       "invalid",
+						Start:      "08:00",
+						End:        "19:00",
+						TimeZone:   "Mars/Olympus_Mons",
+						MinRunners: 4,
+					},
+				}
+				return ra
+			},
+			runnersetFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet { return rs },
+			wantRunners: 1,
+			wantReason:  octorunv1.InvalidScheduleReason,
+			wantErr:     false,
+		},
+		{
+			name: "scale_down_within_cooldown_period",
+			autoscalerFn: func(ra *octorunv1.RunnerAutoscaler) *octorunv1.RunnerAutoscaler {
+				ra.Status.LastScaleTime = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+				return ra
+			},
+			runnersetFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet {
+				rs.Spec.Runners = pointer.Int32(4)
+				return rs
+			},
+			wantRequeue: true,
+			wantRunners: 4,
+			wantReason:  octorunv1.ScaleDownCooldownReason,
+			wantErr:     false,
+		},
+		{
+			name: "scale_down_after_cooldown_period",
+			autoscalerFn: func(ra *octorunv1.RunnerAutoscaler) *octorunv1.RunnerAutoscaler {
+				ra.Status.LastScaleTime = &metav1.Time{Time: time.Now().Add(-10 * time.Minute)}
+				return ra
+			},
+			runnersetFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet {
+				rs.Spec.Runners = pointer.Int32(4)
+				return rs
+			},
+			wantRunners: 1,
+			wantReason:  octorunv1.RunnerSetScaledReason,
+			wantErr:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			autoscaler := tt.autoscalerFn(&octorunv1.RunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "runnerautoscaler-test",
+					Namespace: "default",
+				},
+				Spec: octorunv1.RunnerAutoscalerSpec{
+					ScaleTargetRef: octorunv1.RunnerAutoscalerTargetRef{
+						Name: "runnerset-test",
+					},
+					MinRunners:     pointer.Int32(1),
+					MaxRunners:     5,
+					CooldownPeriod: &metav1.Duration{Duration: 5 * time.Minute},
+				},
+			})
+
+			runnerset := tt.runnersetFn(&octorunv1.RunnerSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "runnerset-test",
+					Namespace: "default",
+				},
+				Spec: octorunv1.RunnerSetSpec{
+					Runners: pointer.Int32(1),
+					Selector: metav1.LabelSelector{
+						MatchLabels: map[string]string{octorunv1.LabelRunnerSetName: "runnerset-test"},
+					},
+					Template: octorunv1.RunnerTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{octorunv1.LabelRunnerSetName: "runnerset-test"},
+						},
+					},
+				},
+			})
+
+			objects := []client.Object{autoscaler, runnerset}
+			for i := 0; i < tt.queuedJobs; i++ {
+				objects = append(objects, &octorunv1.WorkflowJob{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "queued-" + strconv.Itoa(i),
+						Namespace: "default",
+						Labels:    map[string]string{octorunv1.LabelRunnerSetName: "runnerset-test"},
+					},
+					Status: octorunv1.WorkflowJobStatus{
+						Phase:      octorunv1.WorkflowJobQueuedPhase,
+						QueuedTime: &metav1.Time{Time: time.Now().Add(-time.Minute)},
+					},
+				})
+			}
+
+			// A job queued longer than Github keeps it queued is not counted.
+			objects = append(objects, &octorunv1.WorkflowJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "expired",
+					Namespace: "default",
+					Labels:    map[string]string{octorunv1.LabelRunnerSetName: "runnerset-test"},
+				},
+				Status: octorunv1.WorkflowJobStatus{
+					Phase:      octorunv1.WorkflowJobQueuedPhase,
+					QueuedTime: &metav1.Time{Time: time.Now().Add(-48 * time.Hour)},
+				},
+			})
+
+			for i := 0; i < tt.activeRunners && runnerset.Name != ""; i++ {
+				runner := &octorunv1.Runner{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "runnerset-test-" + strconv.Itoa(i),
+						Namespace: "default",
+						Labels:    runnerset.Spec.Template.Labels,
+					},
+					Status: octorunv1.RunnerStatus{Phase: octorunv1.RunnerActivePhase},
+				}
+				utilruntime.Must(ctrl.SetControllerReference(runnerset, runner, scheme))
+				objects = append(objects, runner)
+			}
+
+			fakec := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(objects...).
+				Build()
+
+			r := &RunnerAutoscalerReconciler{
+				Client:      fakec,
+				Scheme:      scheme,
+				Recorder:    record.NewFakeRecorder(10),
+				ScaleClient: &runnerSetScales{client: fakec},
+			}
+
+			got, err := r.Reconcile(context.Background(), reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      "runnerautoscaler-test",
+					Namespace: "default",
+				},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RunnerAutoscalerReconciler.Reconcile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if (got.RequeueAfter > 0) != tt.wantRequeue {
+				t.Errorf("RunnerAutoscalerReconciler.Reconcile() = %v, wantRequeue %v", got, tt.wantRequeue)
+			}
+
+			if runnerset.Name != "" {
+				gotRunnerSet := &octorunv1.RunnerSet{}
+				if err := fakec.Get(context.Background(), client.ObjectKeyFromObject(runnerset), gotRunnerSet); err != nil {
+					t.Errorf("unexpected Get error: %v", err)
+					return
+				}
+				if runners := pointer.Int32Deref(gotRunnerSet.Spec.Runners, 0); runners != tt.wantRunners {
+					t.Errorf("RunnerSet runners = %v, want %v", runners, tt.wantRunners)
+				}
+			}
+
+			if autoscaler.Name != "" && tt.wantReason != "" {
+				gotAutoscaler := &octorunv1.RunnerAutoscaler{}
+				if err := fakec.Get(context.Background(), client.ObjectKeyFromObject(autoscaler), gotAutoscaler); err != nil {
+					t.Errorf("unexpected Get error: %v", err)
+					return
+				}
+				if len(gotAutoscaler.Status.Conditions) == 0 || gotAutoscaler.Status.Conditions[0].Reason != tt.wantReason {
+					t.Errorf("RunnerAutoscaler conditions = %v, want reason %v", gotAutoscaler.Status.Conditions, tt.wantReason)
+				}
+				if tt.wantReason != octorunv1.RunnerSetNotFoundReason && gotAutoscaler.Status.InflightJobs != tt.wantInflightJobs {
+					t.Errorf("RunnerAutoscaler inflightJobs = %v, want %v", gotAutoscaler.Status.InflightJobs, tt.wantInflightJobs)
+				}
+			}
+		})
+	}
+}
+
+// runnerSetScales serves the RunnerSet scale subresource from given client the way the API server does.
+type runnerSetScales struct {
+	client    client.Client
+	namespace string
+}
+
+func (s *runnerSetScales) Scales(namespace string) scale.ScaleInterface {
+	return &runnerSetScales{client: s.client, namespace: namespace}
+}
+
+func (s *runnerSetScales) Get(ctx context.Context, resource schema.GroupResource, name string, opts metav1.GetOptions) (*autoscalingv1.Scale, error) {
+	runnerset := &octorunv1.RunnerSet{}
+	if err := s.client.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: name}, runnerset); err != nil {
+		return nil, err
+	}
+
+	return &autoscalingv1.Scale{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: s.namespace},
+		Spec:       autoscalingv1.ScaleSpec{Replicas: pointer.Int32Deref(runnerset.Spec.Runners, 0)},
+		Status:     autoscalingv1.ScaleStatus{Replicas: runnerset.Status.Runners, Selector: runnerset.Status.Selector},
+	}, nil
+}
+
+func (s *runnerSetScales) Update(ctx context.Context, resource schema.GroupResource, sc *autoscalingv1.Scale, opts metav1.UpdateOptions) (*autoscalingv1.Scale, error) {
+	runnerset := &octorunv1.RunnerSet{}
+	if err := s.client.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: sc.Name}, runnerset); err != nil {
+		return nil, err
+	}
+
+	runnersetPatch := client.MergeFrom(runnerset.DeepCopy())
+	runnerset.Spec.Runners = pointer.Int32(sc.Spec.Replicas)
+	if err := s.client.Patch(ctx, runnerset, runnersetPatch); err != nil {
+		return nil, err
+	}
+
+	return sc, nil
+}
+
+func (s *runnerSetScales) Patch(ctx context.Context, gvr schema.GroupVersionResource, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions) (*autoscalingv1.Scale, error) {
+	return nil, errors.New("not implemented")
+}
+
+func Test_scheduleIsActive(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+
+	weekdays := []octorunv1.ScheduleDay{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}
+	tests := []struct {
+		name     string
+		schedule *octorunv1.RunnerAutoscalerSchedule
+		now      time.Time
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name:     "weekday_within_working_hours",
+			schedule: &octorunv1.RunnerAutoscalerSchedule{Days: weekdays, Start: "08:00", End: "19:00", TimeZone: "Europe/Berlin"},
+			now:      time.Date(2022, time.May, 2, 10, 0, 0, 0, berlin), // Monday
+			want:     true,
+		},
+		{
+			name:     "weekday_within_working_hours_in_utc",
+			schedule: &octorunv1.RunnerAutoscalerSchedule{Days: weekdays, Start: "08:00", End: "19:00", TimeZone: "Europe/Berlin"},
+			now:      time.Date(2022, time.May, 2, 17, 30, 0, 0, time.UTC), // 19:30 in Berlin
+			want:     false,
+		},
+		{
+			name:     "weekend_within_working_hours",
+			schedule: &octorunv1.RunnerAutoscalerSchedule{Days: weekdays, Start: "08:00", End: "19:00", TimeZone: "Europe/Berlin"},
+			now:      time.Date(2022, time.May, 1, 10, 0, 0, 0, berlin), // Sunday
+			want:     false,
+		},
+		{
+			name:     "overnight_window_after_midnight",
+			schedule: &octorunv1.RunnerAutoscalerSchedule{Days: []octorunv1.ScheduleDay{"Friday"}, Start: "22:00", End: "02:00"},
+			now:      time.Date(2022, time.May, 7, 1, 0, 0, 0, time.UTC), // Saturday
+			want:     true,
+		},
+		{
+			name:     "overnight_window_ended",
+			schedule: &octorunv1.RunnerAutoscalerSchedule{Days: []octorunv1.ScheduleDay{"Friday"}, Start: "22:00", End: "02:00"},
+			now:      time.Date(2022, time.May, 7, 2, 0, 0, 0, time.UTC), // Saturday
+			want:     false,
+		},
+		{
+			name:     "invalid_start_time",
+			schedule: &octorunv1.RunnerAutoscalerSchedule{Start: "8am", End: "19:00"},
+			now:      time.Date(2022, time.May, 2, 10, 0, 0, 0, time.UTC),
+			want:     false,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scheduleIsActive(tt.schedule, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("scheduleIsActive() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("scheduleIsActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
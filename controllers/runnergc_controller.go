@@ -0,0 +1,239 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	gogithub "github.com/google/go-github/v41/github"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+	"octorun.github.io/octorun/metrics"
+	"octorun.github.io/octorun/pkg/github"
+	gherrors "octorun.github.io/octorun/pkg/github/errors"
+)
+
+const RunnerGCController = "runner.octorun.github.io/garbage-collector"
+
+// RunnerGCReconciler periodically removes offline Github runners registered by octorun
+// that are no longer tracked by any Runner. eg: the Runner was deleted while the controller
+// was not running or the runner pod was not able to remove itself.
+type RunnerGCReconciler struct {
+	client.Client
+	Github github.Client
+
+	// Credentials provides the Github client of the runners referencing a GitHubCredential.
+	Credentials github.ClientGetter
+
+	// Interval is the period between garbage collections.
+	Interval time.Duration
+
+	// DryRun only reports the orphaned Github runners without removing them.
+	DryRun bool
+
+	// ControllerID is the controller-id label the Runner controller passes to the Github runners
+	// it registers. Only the Github runners with this label are removed.
+	ControllerID string
+}
+
+// runnerGCKey identifies the Github runner the same way the runner composite index does.
+// The runner group is not part of the key since Github does not report it when listing runners.
+type runnerGCKey struct {
+	URL  string
+	Name string
+}
+
+// runnerTarget is a runner URL together with the GitHubCredential used to register runners to it.
+// The Credential has an empty key for the Github client the controller has been started with.
+type runnerTarget struct {
+	URL        string
+	Credential client.ObjectKey
+}
+
+// SetupWithManager sets up the garbage collector with the Manager.
+func (r *RunnerGCReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	return mgr.Add(r)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+// Only the leader removes the orphaned Github runners.
+func (r *RunnerGCReconciler) NeedLeaderElection() bool { return true }
+
+// Start implements manager.Runnable.
+func (r *RunnerGCReconciler) Start(ctx context.Context) error {
+	log := ctrl.Log.WithName(RunnerGCController)
+	ctx = ctrl.LoggerInto(ctx, log)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.Collect(ctx); err != nil {
+			log.Error(err, "unable to collect orphaned Github runners")
+		}
+	}, r.Interval)
+	return nil
+}
+
+// Collect lists the Github runners of every distinct Runner and RunnerSet URL
+// and removes the offline ones that were registered by octorun but no longer
+// have a matching Runner by name and ID.
+func (r *RunnerGCReconciler) Collect(ctx context.Context) error {
+	log := ctrl.LoggerFrom(ctx)
+	defer metrics.RunnerGCLastRunTimestamp.SetToCurrentTime()
+
+	runnerList := &octorunv1.RunnerList{}
+	if err := r.List(ctx, runnerList); err != nil {
+		return err
+	}
+
+	runnersetList := &octorunv1.RunnerSetList{}
+	if err := r.List(ctx, runnersetList); err != nil {
+		return err
+	}
+
+	targets := make(map[runnerTarget]struct{})
+	tracked := make(map[runnerGCKey][]int64)
+	for _, runner := range runnerList.Items {
+		targets[runnerTargetFor(runner.Namespace, &runner.Spec)] = struct{}{}
+		key := runnerGCKey{URL: runner.Spec.URL, Name: runner.Name}
+		// Runner without an ID may have registered its Github runner already.
+		// Keep track of it using -1 so that any Github runner with its name is kept.
+		tracked[key] = append(tracked[key], pointer.Int64Deref(runner.Spec.ID, -1))
+	}
+
+	for _, runnerset := range runnersetList.Items {
+		targets[runnerTargetFor(runnerset.Namespace, &runnerset.Spec.Template.Spec)] = struct{}{}
+	}
+
+	sortedTargets := make([]runnerTarget, 0, len(targets))
+	for t := range targets {
+		if t.URL != "" {
+			sortedTargets = append(sortedTargets, t)
+		}
+	}
+
+	sort.Slice(sortedTargets, func(i, j int) bool {
+		if sortedTargets[i].URL != sortedTargets[j].URL {
+			return sortedTargets[i].URL < sortedTargets[j].URL
+		}
+
+		return sortedTargets[i].Credential.String() < sortedTargets[j].Credential.String()
+	})
+
+	var errs []error
+	for _, t := range sortedTargets {
+		u := t.URL
+		ghc, err := t.githubClient(ctx, r.Github, r.Credentials)
+		if err != nil {
+			log.Error(err, "unable to get the Github client of the credential", "url", u, "credential", t.Credential)
+			metrics.RunnerGCErrors.WithLabelValues(u).Inc()
+			errs = append(errs, err)
+			continue
+		}
+
+		ghrunners, err := ghc.ListRunners(ctx, u)
+		if err != nil {
+			log.Error(err, "unable to list Github runners", "url", u)
+			metrics.RunnerGCErrors.WithLabelValues(u).Inc()
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, ghrunner := range ghrunners {
+			if ghrunner.GetStatus() != "offline" || !registeredByController(ghrunner, r.ControllerID) ||
+				isTrackedRunner(tracked[runnerGCKey{URL: u, Name: ghrunner.GetName()}], ghrunner.GetID()) {
+				continue
+			}
+
+			log.Info("found orphaned Github runner", "url", u, "runner", ghrunner.GetName(), "runner-id", ghrunner.GetID(), "dry-run", r.DryRun)
+			metrics.RunnerGCOrphanedRunners.WithLabelValues(u, strconv.FormatBool(r.DryRun)).Inc()
+			if r.DryRun {
+				continue
+			}
+
+			if err := ghc.RemoveRunner(ctx, u, ghrunner.GetID()); err != nil && !gherrors.IsNotFound(err) {
+				log.Error(err, "unable to remove orphaned Github runner", "url", u, "runner", ghrunner.GetName(), "runner-id", ghrunner.GetID())
+				metrics.RunnerGCErrors.WithLabelValues(u).Inc()
+				errs = append(errs, err)
+				continue
+			}
+
+			metrics.RunnerGCRemovedRunners.WithLabelValues(u).Inc()
+		}
+	}
+
+	return kerrors.NewAggregate(errs)
+}
+
+// runnerTargetFor returns the target of the given runner spec in the given namespace.
+func runnerTargetFor(namespace string, spec *octorunv1.RunnerSpec) runnerTarget {
+	t := runnerTarget{URL: spec.URL}
+	if spec.CredentialRef != nil {
+		t.Credential = client.ObjectKey{Namespace: namespace, Name: spec.CredentialRef.Name}
+	}
+
+	return t
+}
+
+// githubClient returns the Github client of the target credential, or the given default client
+// if the target has no credential.
+func (t runnerTarget) githubClient(ctx context.Context, defaultClient github.Client, credentials github.ClientGetter) (github.Client, error) {
+	if t.Credential.Name == "" {
+		return defaultClient, nil
+	}
+
+	return credentials.ClientFor(ctx, t.Credential)
+}
+
+// registeredByController returns true if the Github runner has the runner or runnerset
+// label that the controller passes to every Github runner it registers together with
+// the controller-id label of the given controller. Runners registered by another controller
+// sharing the same runner URL are left to that controller.
+func registeredByController(ghrunner *gogithub.Runner, controllerID string) bool {
+	registered, owned := false, false
+	for _, label := range ghrunner.Labels {
+		for _, key := range []string{octorunv1.LabelRunnerName, octorunv1.LabelRunnerSetName} {
+			if strings.HasPrefix(label.GetName(), strings.TrimPrefix(key, octorunv1.LabelPrefix)+"=") {
+				registered = true
+			}
+		}
+
+		if label.GetName() == strings.TrimPrefix(octorunv1.LabelControllerID, octorunv1.LabelPrefix)+"="+controllerID {
+			owned = true
+		}
+	}
+
+	return registered && owned
+}
+
+// isTrackedRunner returns true if one of the given Runner IDs matches the Github runner ID.
+// Runner without an ID (-1) matches any Github runner with the same name.
+func isTrackedRunner(runnerIDs []int64, ghrunnerID int64) bool {
+	for _, id := range runnerIDs {
+		if id == -1 || id == ghrunnerID {
+			return true
+		}
+	}
+
+	return false
+}
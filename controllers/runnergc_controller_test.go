@@ -0,0 +1,165 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	gogithub "github.com/google/go-github/v41/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+	mghclient "octorun.github.io/octorun/pkg/github/client/mock"
+)
+
+func TestRunnerGCReconciler_Collect(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+
+	ghrunnerFn := func(id int64, name, status string, labels ...string) *gogithub.Runner {
+		ghrunner := &gogithub.Runner{
+			ID:     gogithub.Int64(id),
+			Name:   gogithub.String(name),
+			Status: gogithub.String(status),
+		}
+
+		for _, l := range labels {
+			ghrunner.Labels = append(ghrunner.Labels, &gogithub.RunnerLabels{Name: gogithub.String(l)})
+		}
+
+		return ghrunner
+	}
+
+	objs := []client.Object{
+		&octorunv1.Runner{
+			ObjectMeta: metav1.ObjectMeta{Name: "runner-1", Namespace: "default"},
+			Spec:       octorunv1.RunnerSpec{URL: "https://github.com/octorun", ID: pointer.Int64(1)},
+		},
+		&octorunv1.Runner{
+			ObjectMeta: metav1.ObjectMeta{Name: "runner-2", Namespace: "default"},
+			Spec:       octorunv1.RunnerSpec{URL: "https://github.com/octorun"},
+		},
+		&octorunv1.RunnerSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "runnerset", Namespace: "default"},
+			Spec: octorunv1.RunnerSetSpec{
+				Template: octorunv1.RunnerTemplateSpec{
+					Spec: octorunv1.RunnerSpec{URL: "https://github.com/octorun/repo"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		dryRun   bool
+		expectFn func(cmockr *mghclient.MockClientMockRecorder)
+		wantErr  bool
+	}{
+		{
+			name: "no_orphaned_runners",
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.ListRunners(gomock.Any(), "https://github.com/octorun").Return([]*gogithub.Runner{
+					ghrunnerFn(1, "runner-1", "offline", "runner=runner-1", "controller-id=cluster-a"),
+					ghrunnerFn(2, "runner-2", "offline", "runner=runner-2", "controller-id=cluster-a"),
+					ghrunnerFn(3, "runner-3", "online", "runner=runner-3", "controller-id=cluster-a"),
+					ghrunnerFn(4, "not-octorun", "offline", "gpu"),
+				}, nil)
+				cmockr.ListRunners(gomock.Any(), "https://github.com/octorun/repo").Return(nil, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "orphaned_runners_are_removed",
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.ListRunners(gomock.Any(), "https://github.com/octorun").Return([]*gogithub.Runner{
+					ghrunnerFn(1, "runner-1", "offline", "runner=runner-1", "controller-id=cluster-a"),
+					ghrunnerFn(5, "runner-1", "offline", "runner=runner-1", "controller-id=cluster-a"),
+				}, nil)
+				cmockr.ListRunners(gomock.Any(), "https://github.com/octorun/repo").Return([]*gogithub.Runner{
+					ghrunnerFn(6, "runnerset-abcde", "offline", "runnerset=runnerset", "controller-id=cluster-a"),
+				}, nil)
+				cmockr.RemoveRunner(gomock.Any(), "https://github.com/octorun", int64(5)).Return(nil)
+				cmockr.RemoveRunner(gomock.Any(), "https://github.com/octorun/repo", int64(6)).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "runners_of_other_controllers_are_not_removed",
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.ListRunners(gomock.Any(), "https://github.com/octorun").Return([]*gogithub.Runner{
+					ghrunnerFn(5, "runner-1", "offline", "runner=runner-1", "controller-id=cluster-b"),
+					ghrunnerFn(7, "runner-4", "offline", "runner=runner-4"),
+				}, nil)
+				cmockr.ListRunners(gomock.Any(), "https://github.com/octorun/repo").Return([]*gogithub.Runner{
+					ghrunnerFn(6, "runnerset-abcde", "offline", "runnerset=runnerset", "controller-id=cluster-b"),
+					ghrunnerFn(8, "not-octorun", "offline", "controller-id=cluster-a"),
+				}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:   "orphaned_runners_are_not_removed_on_dry_run",
+			dryRun: true,
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.ListRunners(gomock.Any(), "https://github.com/octorun").Return([]*gogithub.Runner{
+					ghrunnerFn(5, "runner-1", "offline", "runner=runner-1", "controller-id=cluster-a"),
+				}, nil)
+				cmockr.ListRunners(gomock.Any(), "https://github.com/octorun/repo").Return([]*gogithub.Runner{
+					ghrunnerFn(6, "runnerset-abcde", "offline", "runnerset=runnerset", "controller-id=cluster-a"),
+				}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "unable_to_list_runners",
+			expectFn: func(cmockr *mghclient.MockClientMockRecorder) {
+				cmockr.ListRunners(gomock.Any(), "https://github.com/octorun").Return(nil, errors.New("unexpected error"))
+				cmockr.ListRunners(gomock.Any(), "https://github.com/octorun/repo").Return([]*gogithub.Runner{
+					ghrunnerFn(6, "runnerset-abcde", "offline", "runnerset=runnerset", "controller-id=cluster-a"),
+				}, nil)
+				cmockr.RemoveRunner(gomock.Any(), "https://github.com/octorun/repo", int64(6)).Return(nil)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mctrl := gomock.NewController(t)
+			mghc := mghclient.NewMockClient(mctrl)
+			tt.expectFn(mghc.EXPECT())
+
+			r := &RunnerGCReconciler{
+				Client:       fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+				Github:       mghc,
+				DryRun:       tt.dryRun,
+				ControllerID: "cluster-a",
+			}
+
+			if err := r.Collect(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("RunnerGCReconciler.Collect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
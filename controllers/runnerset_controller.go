@@ -18,11 +18,14 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"sort"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -34,6 +37,8 @@ import (
 
 	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
 	"octorun.github.io/octorun/pkg/revision"
+	"octorun.github.io/octorun/util"
+	"octorun.github.io/octorun/util/annotations"
 	"octorun.github.io/octorun/util/patch"
 	"octorun.github.io/octorun/util/sortable"
 )
@@ -46,6 +51,10 @@ type RunnerSetReconciler struct {
 	Scheme     *runtime.Scheme
 	Recorder   record.EventRecorder
 	Revisioner revision.Revisioner
+
+	// APIReader reads from the API server bypassing the cache. It is used to make
+	// sure the Runner a volume pool claim was handed out to has really gone.
+	APIReader client.Reader
 }
 
 // +kubebuilder:rbac:groups=octorun.github.io,resources=runnersets,verbs=get;list;watch;create;update;patch;delete
@@ -54,6 +63,7 @@ type RunnerSetReconciler struct {
 // +kubebuilder:rbac:groups=octorun.github.io,resources=runners,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=octorun.github.io,resources=runners/status,verbs=get
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;update;patch
 
 // SetupWithManager sets up the controller with the Manager.
@@ -109,7 +119,13 @@ func (r *RunnerSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, err
 	}
 
-	if err := r.syncRunners(ctx, runnerset, runners, rev); err != nil {
+	freeClaims, err := r.reconcileVolumePools(ctx, runnerset)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	result, err := r.syncRunners(ctx, runnerset, runners, rev, freeClaims)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -130,7 +146,7 @@ func (r *RunnerSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	}
 
 	runnerset.Status.Selector = selector.String()
-	return ctrl.Result{}, revision.TruncateHistory(ctx, r.Client, r.Revisioner, runnerset, runnerObj)
+	return result, revision.TruncateHistory(ctx, r.Client, r.Revisioner, runnerset, runnerObj)
 }
 
 // findRunners find Runners managed by given RunnerSet. It will adopt the orphan runner if have matching labels but does not have
@@ -147,7 +163,8 @@ func (r *RunnerSetReconciler) findRunners(ctx context.Context, runnerset *octoru
 		return nil, err
 	}
 
-	var idleRunners, activeRunners int32
+	var idleRunners, activeRunners, onDemandRunners int32
+	var registrationFailedRunners []*octorunv1.Runner
 	runners := make([]*octorunv1.Runner, 0, len(runnerList.Items))
 	for i := range runnerList.Items {
 		runner := &runnerList.Items[i]
@@ -161,6 +178,23 @@ func (r *RunnerSetReconciler) findRunners(ctx context.Context, runnerset *octoru
 			continue
 		}
 
+		if annotations.IsOnDemand(runner) {
+			// On-demand runners are created for a single queued workflow job,
+			// they are not part of the desired runners and will only be deleted
+			// once they are complete.
+			if runner.Status.Phase == octorunv1.RunnerCompletePhase || runner.Status.Phase == octorunv1.RunnerFailedPhase {
+				log.V(1).Info("deleting on-demand Runner that has "+string(runner.Status.Phase)+" phase", "runner", runner)
+				if err := r.Delete(ctx, runner); client.IgnoreNotFound(err) != nil {
+					log.Error(err, "unable to delete complete runner", "runner", runner)
+				}
+
+				continue
+			}
+
+			onDemandRunners += 1
+			continue
+		}
+
 		switch runner.Status.Phase {
 		case octorunv1.RunnerIdlePhase:
 			if runnerset.Spec.UpdateStrategy.Type == octorunv1.RollingUpdateRunnerSetStrategyType &&
@@ -178,11 +212,45 @@ func (r *RunnerSetReconciler) findRunners(ctx context.Context, runnerset *octoru
 		case octorunv1.RunnerActivePhase:
 			activeRunners += 1
 		case octorunv1.RunnerCompletePhase:
+			if runner.Spec.Lifecycle == octorunv1.RunnerLifecyclePersistent {
+				// Persistent runners are never complete with their job, they only have
+				// Complete phase once they are being deleted. Don't recycle them.
+				continue
+			}
+
 			log.V(1).Info("deleting Runner that has Complete phase", "runner", runner)
 			if err := r.Delete(ctx, runner); client.IgnoreNotFound(err) != nil {
 				log.Error(err, "unable to delete complete runner", "runner", runner)
 			}
 
+			continue
+		case octorunv1.RunnerFailedPhase:
+			if meta.IsStatusConditionTrue(runner.Status.Conditions, octorunv1.RunnerConditionRegistrationFailed) {
+				if runner.Labels[r.Revisioner.HashLabelKey()] != rev.Name {
+					// The runner template has changed. Replace the runner that failed to register
+					// so the registration is retried with the new template.
+					log.V(1).Info("deleting Runner that failed to register with previous revision", "runner", runner)
+					if err := r.Delete(ctx, runner); client.IgnoreNotFound(err) != nil {
+						log.Error(err, "unable to delete failed runner", "runner", runner)
+					}
+
+					continue
+				}
+
+				// Keep the runner that failed to register. Replacing it would only fail the
+				// same way, the Runner controller retries the registration by itself.
+				registrationFailedRunners = append(registrationFailedRunners, runner)
+				break
+			}
+
+			// Failed runners have exhausted their pod retries. Replace them
+			// with a new runner instead of keeping them around.
+			log.V(1).Info("deleting Runner that has Failed phase", "runner", runner)
+			r.Recorder.Eventf(runnerset, corev1.EventTypeWarning, octorunv1.RunnerFailedReason, "Replacing failed Runner %s: %s", runner.Name, runner.Status.Message)
+			if err := r.Delete(ctx, runner); client.IgnoreNotFound(err) != nil {
+				log.Error(err, "unable to delete failed runner", "runner", runner)
+			}
+
 			continue
 		}
 
@@ -192,9 +260,35 @@ func (r *RunnerSetReconciler) findRunners(ctx context.Context, runnerset *octoru
 	runnerset.Status.Runners = int32(len(runners))
 	runnerset.Status.IdleRunners = idleRunners
 	runnerset.Status.ActiveRunners = activeRunners
+	runnerset.Status.OnDemandRunners = onDemandRunners
+	setRegistrationFailedCondition(runnerset, runners, registrationFailedRunners)
 	return runners, nil
 }
 
+// setRegistrationFailedCondition rolls up the RegistrationFailed condition of the given failed runners
+// into the RunnerSet RegistrationFailed condition.
+func setRegistrationFailedCondition(runnerset *octorunv1.RunnerSet, runners, failedRunners []*octorunv1.Runner) {
+	if len(failedRunners) == 0 {
+		meta.SetStatusCondition(&runnerset.Status.Conditions, metav1.Condition{
+			Type:               octorunv1.RunnerSetConditionRegistrationFailed,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: runnerset.Generation,
+			Reason:             octorunv1.RunnersRegisteredReason,
+			Message:            "No Runner has failed to register",
+		})
+		return
+	}
+
+	cond := meta.FindStatusCondition(failedRunners[0].Status.Conditions, octorunv1.RunnerConditionRegistrationFailed)
+	meta.SetStatusCondition(&runnerset.Status.Conditions, metav1.Condition{
+		Type:               octorunv1.RunnerSetConditionRegistrationFailed,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: runnerset.Generation,
+		Reason:             cond.Reason,
+		Message:            fmt.Sprintf("%d of %d Runners failed to register. Runner %s: %s", len(failedRunners), len(runners), failedRunners[0].Name, cond.Message),
+	})
+}
+
 // adoptRunner adopt orphan runner who has not OwnerReference by sets
 // given RunnerSet as controller OwnerReference to given Runner.
 //
@@ -215,7 +309,231 @@ func (r *RunnerSetReconciler) adoptRunner(ctx context.Context, runnerset *octoru
 	return r.Patch(ctx, runner, runnerPatch)
 }
 
-func (r *RunnerSetReconciler) syncRunners(ctx context.Context, runnerset *octorunv1.RunnerSet, runners []*octorunv1.Runner, rev *appsv1.ControllerRevision) error {
+// desiredRunnersForRunnerSet returns the number of runners the given RunnerSet should have.
+// With an idle policy it is the number of active runners plus the minimum idle runners
+// bounded by the RunnerSet runners.
+func desiredRunnersForRunnerSet(runnerset *octorunv1.RunnerSet) int {
+	desired := int(*(runnerset.Spec.Runners))
+	if policy := runnerset.Spec.IdlePolicy; policy != nil {
+		if needed := int(runnerset.Status.ActiveRunners + policy.MinIdleRunners); needed < desired {
+			desired = needed
+		}
+	}
+
+	return desired
+}
+
+// expiredIdleRunners returns the idle runners from given runners that have been idle for
+// longer than given maxIdleDuration, or since an unknown time. It also returns the duration
+// until the next idle runner expires or zero if there is none.
+func expiredIdleRunners(runners []*octorunv1.Runner, maxIdleDuration time.Duration, now time.Time) ([]*octorunv1.Runner, time.Duration) {
+	var next time.Duration
+	expired := make([]*octorunv1.Runner, 0, len(runners))
+	for _, runner := range runners {
+		if runner.Status.Phase != octorunv1.RunnerIdlePhase {
+			continue
+		}
+
+		if runner.Status.IdleSince == nil {
+			expired = append(expired, runner)
+			continue
+		}
+
+		remaining := runner.Status.IdleSince.Add(maxIdleDuration).Sub(now)
+		if remaining <= 0 {
+			expired = append(expired, runner)
+			continue
+		}
+
+		if next == 0 || remaining < next {
+			next = remaining
+		}
+	}
+
+	return expired, next
+}
+
+// reconcileVolumePools creates the PersistentVolumeClaims of the given RunnerSet volume pools and returns
+// the names of the claims that are not handed out to any Runner by pool name.
+//
+// A claim is handed out to a Runner as long as the Runner has a volume using it, even if the Runner
+// is being deleted since its pod may still be using the claim. Once the Runner has gone the claim is
+// returned to its pool, or deleted if its pool wipes the returned claims so it is created again empty.
+func (r *RunnerSetReconciler) reconcileVolumePools(ctx context.Context, runnerset *octorunv1.RunnerSet) (map[string][]string, error) {
+	log := ctrl.LoggerFrom(ctx)
+	if len(runnerset.Spec.VolumePools) == 0 {
+		return nil, nil
+	}
+
+	selectorMap, err := metav1.LabelSelectorAsMap(&runnerset.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	runnerList := &octorunv1.RunnerList{}
+	if err := r.List(ctx, runnerList, client.InNamespace(runnerset.Namespace), client.MatchingLabels(selectorMap)); err != nil {
+		return nil, err
+	}
+
+	claimedBy := make(map[string]string)
+	for i := range runnerList.Items {
+		runner := &runnerList.Items[i]
+		if !metav1.IsControlledBy(runner, runnerset) {
+			continue
+		}
+
+		for _, volume := range runner.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				claimedBy[volume.PersistentVolumeClaim.ClaimName] = runner.Name
+			}
+		}
+	}
+
+	freeClaims := make(map[string][]string, len(runnerset.Spec.VolumePools))
+	for i := range runnerset.Spec.VolumePools {
+		pool := &runnerset.Spec.VolumePools[i]
+		claimLabels := client.MatchingLabels{
+			octorunv1.LabelRunnerSetName: runnerset.Name,
+			octorunv1.LabelVolumePool:    pool.Name,
+		}
+
+		claimList := &corev1.PersistentVolumeClaimList{}
+		if err := r.List(ctx, claimList, client.InNamespace(runnerset.Namespace), claimLabels); err != nil {
+			return nil, err
+		}
+
+		desiredClaims := make(map[string]bool, pool.Size)
+		for n := 0; n < int(pool.Size); n++ {
+			desiredClaims[volumePoolClaimName(runnerset, pool, n)] = true
+		}
+
+		existingClaims := make(map[string]bool, len(claimList.Items))
+		for j := range claimList.Items {
+			claim := &claimList.Items[j]
+			existingClaims[claim.Name] = true
+			if !claim.GetDeletionTimestamp().IsZero() {
+				continue
+			}
+
+			if runnerName, ok := claimedBy[claim.Name]; ok {
+				if annotations.VolumeClaimedBy(claim) != runnerName {
+					claimPatch := client.MergeFrom(claim.DeepCopy())
+					annotations.AnnotateVolumeClaimedBy(claim, runnerName)
+					if err := r.Patch(ctx, claim, claimPatch); err != nil {
+						return nil, err
+					}
+				}
+
+				continue
+			}
+
+			if !desiredClaims[claim.Name] {
+				// The pool has shrunk. Retained claims are left alone.
+				if pool.ReclaimPolicy != octorunv1.VolumePoolReclaimRetain {
+					log.V(1).Info("deleting PersistentVolumeClaim removed from the volume pool", "pool", pool.Name, "claim", claim.Name)
+					if err := r.Delete(ctx, claim); client.IgnoreNotFound(err) != nil {
+						return nil, err
+					}
+				}
+
+				continue
+			}
+
+			if runnerName := annotations.VolumeClaimedBy(claim); runnerName != "" {
+				// The Runner the claim was handed out to is missing from the cache, it may have been
+				// created so recently that the cache has not caught up yet. The claim is only returned
+				// once the API server confirms the Runner has gone.
+				gone, err := r.isRunnerGone(ctx, runnerset.Namespace, runnerName)
+				if err != nil {
+					return nil, err
+				}
+
+				if !gone {
+					continue
+				}
+
+				if pool.WipePolicy == octorunv1.VolumePoolWipeRecreate {
+					log.V(1).Info("deleting returned PersistentVolumeClaim to wipe it", "pool", pool.Name, "claim", claim.Name)
+					if err := r.Delete(ctx, claim); client.IgnoreNotFound(err) != nil {
+						return nil, err
+					}
+
+					continue
+				}
+
+				claimPatch := client.MergeFrom(claim.DeepCopy())
+				delete(claim.Annotations, octorunv1.AnnotationVolumeClaimedBy)
+				if err := r.Patch(ctx, claim, claimPatch); err != nil {
+					return nil, err
+				}
+			}
+
+			freeClaims[pool.Name] = append(freeClaims[pool.Name], claim.Name)
+		}
+
+		for n := 0; n < int(pool.Size); n++ {
+			name := volumePoolClaimName(runnerset, pool, n)
+			if existingClaims[name] {
+				continue
+			}
+
+			claim := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: runnerset.Namespace,
+					Labels:    claimLabels,
+				},
+				Spec: pool.VolumeClaimSpec,
+			}
+
+			if pool.ReclaimPolicy != octorunv1.VolumePoolReclaimRetain {
+				if err := ctrl.SetControllerReference(runnerset, claim, r.Scheme); err != nil {
+					return nil, err
+				}
+			}
+
+			log.V(1).Info("creating volume pool PersistentVolumeClaim", "pool", pool.Name, "claim", name)
+			if err := r.Create(ctx, claim); err != nil {
+				return nil, err
+			}
+
+			freeClaims[pool.Name] = append(freeClaims[pool.Name], claim.Name)
+		}
+
+		sort.Strings(freeClaims[pool.Name])
+	}
+
+	return freeClaims, nil
+}
+
+// isRunnerGone returns true if the API server has no Runner with the given name.
+func (r *RunnerSetReconciler) isRunnerGone(ctx context.Context, namespace, name string) (bool, error) {
+	err := r.APIReader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &octorunv1.Runner{})
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+
+	return false, err
+}
+
+// claimVolume annotates the given PersistentVolumeClaim as claimed by the given Runner.
+func (r *RunnerSetReconciler) claimVolume(ctx context.Context, namespace, claimName, runnerName string) error {
+	claim := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: claimName}, claim); err != nil {
+		return err
+	}
+
+	claimPatch := client.MergeFrom(claim.DeepCopy())
+	annotations.AnnotateVolumeClaimedBy(claim, runnerName)
+	return r.Patch(ctx, claim, claimPatch)
+}
+
+// volumePoolClaimName returns the name of the n-th PersistentVolumeClaim of the given RunnerSet volume pool.
+func volumePoolClaimName(runnerset *octorunv1.RunnerSet, pool *octorunv1.RunnerSetVolumePool, n int) string {
+	return fmt.Sprintf("%s-%s-%d", runnerset.Name, pool.Name, n)
+}
+
+func (r *RunnerSetReconciler) syncRunners(ctx context.Context, runnerset *octorunv1.RunnerSet, runners []*octorunv1.Runner, rev *appsv1.ControllerRevision, freeClaims map[string][]string) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 	prioritizedRunnersToDelete := func(runners []*octorunv1.Runner, diff int) []*octorunv1.Runner {
 		if diff >= len(runners) {
@@ -228,7 +546,7 @@ func (r *RunnerSetReconciler) syncRunners(ctx context.Context, runnerset *octoru
 		return runners[:diff]
 	}
 
-	desiredRunners := int(*(runnerset.Spec.Runners))
+	desiredRunners := desiredRunnersForRunnerSet(runnerset)
 	switch diff := len(runners) - desiredRunners; {
 	case diff < 0:
 		diff *= -1
@@ -256,6 +574,23 @@ func (r *RunnerSetReconciler) syncRunners(ctx context.Context, runnerset *octoru
 				Spec: runnerset.Spec.Template.Spec,
 			}
 
+			// Hand out a free PersistentVolumeClaim of each volume pool to the new runner.
+			var volumes []corev1.Volume
+			var exhaustedPools []string
+			volumes = append(volumes, runner.Spec.Volumes...)
+			for p := range runnerset.Spec.VolumePools {
+				pool := &runnerset.Spec.VolumePools[p]
+				var claimName string
+				if free := freeClaims[pool.Name]; len(free) > 0 {
+					claimName, freeClaims[pool.Name] = free[0], free[1:]
+				} else {
+					exhaustedPools = append(exhaustedPools, pool.Name)
+				}
+
+				volumes = append(volumes, util.VolumePoolVolume(pool, claimName))
+			}
+
+			runner.Spec.Volumes = volumes
 			if _, err := ctrl.CreateOrUpdate(ctx, r.Client, runner, func() error {
 				log.V(1).Info("creating new Runner", "runner", runner.Name)
 
@@ -263,17 +598,50 @@ func (r *RunnerSetReconciler) syncRunners(ctx context.Context, runnerset *octoru
 			}); err != nil {
 				log.Error(err, "unable to create runner", "runner", runner.Name)
 				errs = append(errs, err)
+				continue
+			}
+
+			// Mark the handed out claims right away. A claim marked as claimed by a Runner
+			// missing from a stale Runner cache is not returned to its pool, nor wiped,
+			// until the API server confirms the Runner has gone.
+			for _, volume := range runner.Spec.Volumes {
+				if volume.PersistentVolumeClaim == nil {
+					continue
+				}
+
+				if err := r.claimVolume(ctx, runnerset.Namespace, volume.PersistentVolumeClaim.ClaimName, runner.Name); err != nil {
+					log.Error(err, "unable to mark PersistentVolumeClaim as claimed", "claim", volume.PersistentVolumeClaim.ClaimName)
+					errs = append(errs, err)
+				}
 			}
 
 			log.Info("created runner", "runner", runner.Name)
 			r.Recorder.Eventf(runnerset, corev1.EventTypeNormal, octorunv1.RunnerCreatedReason, "Successful create Runner %s", runner.Name)
+			for _, pool := range exhaustedPools {
+				log.Info("no free PersistentVolumeClaim in the volume pool, using an emptyDir volume", "pool", pool, "runner", runner.Name)
+				r.Recorder.Eventf(runnerset, corev1.EventTypeWarning, octorunv1.VolumePoolExhaustedReason,
+					"No free PersistentVolumeClaim in volume pool %s, Runner %s uses an emptyDir volume instead", pool, runner.Name)
+			}
 		}
 
-		return kerrors.NewAggregate(errs)
+		return ctrl.Result{}, kerrors.NewAggregate(errs)
 	case diff > 0:
 		log.Info("too many Runner", "runners", len(runners), "desired", desiredRunners, "to be deleted", diff)
+		var result ctrl.Result
+		runnersToDelete := prioritizedRunnersToDelete(runners, diff)
+		if policy := runnerset.Spec.IdlePolicy; policy != nil && policy.MaxIdleDuration != nil {
+			// The runners above the desired runners of the spec are deleted right away. Only delete the idle
+			// runners above the idle buffer that have been waiting for a job longer than allowed, the rest
+			// will be deleted once they expire unless they got a job in the meantime.
+			runnersToDelete = prioritizedRunnersToDelete(runners, len(runners)-int(*runnerset.Spec.Runners))
+			expired, nextExpiration := expiredIdleRunners(runners[len(runnersToDelete):], policy.MaxIdleDuration.Duration, time.Now())
+			runnersToDelete = append(runnersToDelete, prioritizedRunnersToDelete(expired, diff-len(runnersToDelete))...)
+			result.RequeueAfter = nextExpiration
+			log.V(1).Info("deleting surplus and expired idle Runner", "runners", len(runnersToDelete), "next expiration", nextExpiration)
+		}
+
 		var errs []error
-		for _, runner := range prioritizedRunnersToDelete(runners, diff) {
+		for _, runner := range runnersToDelete {
 			log.V(1).Info("deleting runner", "runner", runner.Name)
 			if err := r.Delete(ctx, runner); client.IgnoreNotFound(err) != nil {
 				log.Error(err, "unable to delete runner", "runner", runner)
@@ -284,11 +652,11 @@ func (r *RunnerSetReconciler) syncRunners(ctx context.Context, runnerset *octoru
 			r.Recorder.Eventf(runnerset, corev1.EventTypeNormal, octorunv1.RunnerDeletedReason, "Successful delete Runner %s", runner.Name)
 		}
 
-		return kerrors.NewAggregate(errs)
+		return result, kerrors.NewAggregate(errs)
 	}
 
 	log.Info("synced RunnerSet runners", "runners", len(runners), "desired", desiredRunners)
-	return nil
+	return ctrl.Result{}, nil
 }
 
 type RunnerSetRevisioner struct{}
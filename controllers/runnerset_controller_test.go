@@ -18,9 +18,12 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -46,6 +49,7 @@ import (
 
 	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
 	"octorun.github.io/octorun/util"
+	"octorun.github.io/octorun/util/annotations"
 )
 
 var _ = Describe("RunnerSetReconciler", func() {
@@ -228,6 +232,7 @@ func TestRunnerSetReconciler_Reconcile(t *testing.T) {
 	scheme := runtime.NewScheme()
 	utilruntime.Must(octorunv1.AddToScheme(scheme))
 	utilruntime.Must(appsv1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
 
 	runnerListForRunnerSet := func(rs *octorunv1.RunnerSet) *octorunv1.RunnerList {
 		runners := int(pointer.Int32Deref(rs.Spec.Runners, 0))
@@ -334,6 +339,125 @@ func TestRunnerSetReconciler_Reconcile(t *testing.T) {
 			want:    reconcile.Result{},
 			wantErr: false,
 		},
+		{
+			name:        "persistent_runners_has_complete_phase",
+			runnersetFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet { return rs },
+			runnerListFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerList {
+				var items []octorunv1.Runner
+				runnerList := runnerListForRunnerSet(rs)
+				for _, item := range runnerList.Items {
+					item.Spec.Lifecycle = octorunv1.RunnerLifecyclePersistent
+					item.Status.Phase = octorunv1.RunnerCompletePhase
+					items = append(items, item)
+				}
+
+				runnerList.Items = items
+				return runnerList
+			},
+			want:    reconcile.Result{},
+			wantErr: false,
+		},
+		{
+			name:        "runners_has_failed_phase",
+			runnersetFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet { return rs },
+			runnerListFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerList {
+				var items []octorunv1.Runner
+				runnerList := runnerListForRunnerSet(rs)
+				for _, item := range runnerList.Items {
+					item.Status.Phase = octorunv1.RunnerFailedPhase
+					item.Status.Reason = octorunv1.RunnerBackoffLimitExceededReason
+					items = append(items, item)
+				}
+
+				runnerList.Items = items
+				return runnerList
+			},
+			want:    reconcile.Result{},
+			wantErr: false,
+		},
+		{
+			name:        "runners_has_registration_failed",
+			runnersetFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet { return rs },
+			runnerListFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerList {
+				var items []octorunv1.Runner
+				runnerList := runnerListForRunnerSet(rs)
+				for _, item := range runnerList.Items {
+					item.Status.Phase = octorunv1.RunnerFailedPhase
+					item.Status.Reason = octorunv1.RunnerRegistrationForbiddenReason
+					item.Status.Conditions = []metav1.Condition{
+						{
+							Type:   octorunv1.RunnerConditionRegistrationFailed,
+							Status: metav1.ConditionTrue,
+							Reason: octorunv1.RunnerRegistrationForbiddenReason,
+						},
+					}
+					items = append(items, item)
+				}
+
+				runnerList.Items = items
+				return runnerList
+			},
+			want:    reconcile.Result{},
+			wantErr: false,
+		},
+		{
+			name:        "runnerset_has_on_demand_runners",
+			runnersetFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet { return rs },
+			runnerListFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerList {
+				var items []octorunv1.Runner
+				runnerList := runnerListForRunnerSet(rs)
+				items = append(items, runnerList.Items...)
+				for _, phase := range []octorunv1.RunnerPhase{octorunv1.RunnerActivePhase, octorunv1.RunnerCompletePhase} {
+					items = append(items, octorunv1.Runner{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:            rs.Name + "-" + strconv.Itoa(len(items)+1),
+							Namespace:       rs.Namespace,
+							Labels:          rs.Spec.Selector.MatchLabels,
+							OwnerReferences: rs.GetOwnerReferences(),
+							Annotations: map[string]string{
+								octorunv1.AnnotationRunnerOnDemand: "true",
+							},
+						},
+						Spec: octorunv1.RunnerSpec{
+							URL: rs.Spec.Template.Spec.URL,
+						},
+						Status: octorunv1.RunnerStatus{
+							Phase: phase,
+						},
+					})
+				}
+
+				runnerList.Items = items
+				return runnerList
+			},
+			want:    reconcile.Result{},
+			wantErr: false,
+		},
+		{
+			name: "idle_policy_has_expired_idle_runners",
+			runnersetFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet {
+				rs.Spec.IdlePolicy = &octorunv1.RunnerSetIdlePolicy{
+					MinIdleRunners:  1,
+					MaxIdleDuration: &metav1.Duration{Duration: 10 * time.Minute},
+				}
+				return rs
+			},
+			runnerListFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerList {
+				idleSince := metav1.NewTime(time.Now().Add(-time.Hour))
+				var items []octorunv1.Runner
+				runnerList := runnerListForRunnerSet(rs)
+				for _, item := range runnerList.Items {
+					item.Status.Phase = octorunv1.RunnerIdlePhase
+					item.Status.IdleSince = &idleSince
+					items = append(items, item)
+				}
+
+				runnerList.Items = items
+				return runnerList
+			},
+			want:    reconcile.Result{},
+			wantErr: false,
+		},
 		{
 			name:        "too_many_runners",
 			runnersetFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet { return rs },
@@ -408,6 +532,19 @@ func TestRunnerSetReconciler_Reconcile(t *testing.T) {
 			want:    reconcile.Result{},
 			wantErr: false,
 		},
+		{
+			name: "runnerset_has_volume_pools",
+			runnersetFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet {
+				rs.Spec.VolumePools = []octorunv1.RunnerSetVolumePool{
+					{Name: "work", Size: 2},
+					{Name: "toolcache", Size: 1, ReclaimPolicy: octorunv1.VolumePoolReclaimRetain},
+				}
+				return rs
+			},
+			runnerListFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerList { return &octorunv1.RunnerList{} },
+			want:         reconcile.Result{},
+			wantErr:      false,
+		},
 		{
 			name: "oneof_idle_runners_has_missmatch_rev_label",
 			runnersetFn: func(rs *octorunv1.RunnerSet) *octorunv1.RunnerSet {
@@ -485,6 +622,7 @@ func TestRunnerSetReconciler_Reconcile(t *testing.T) {
 				Scheme:     scheme,
 				Recorder:   new(record.FakeRecorder),
 				Revisioner: new(RunnerSetRevisioner),
+				APIReader:  fakec,
 			}
 
 			got, err := r.Reconcile(context.Background(), reconcile.Request{
@@ -503,3 +641,564 @@ func TestRunnerSetReconciler_Reconcile(t *testing.T) {
 		})
 	}
 }
+
+func Test_desiredRunnersForRunnerSet(t *testing.T) {
+	tests := []struct {
+		name      string
+		runnerset *octorunv1.RunnerSet
+		want      int
+	}{
+		{
+			name: "runnerset_without_idle_policy",
+			runnerset: &octorunv1.RunnerSet{
+				Spec:   octorunv1.RunnerSetSpec{Runners: pointer.Int32(5)},
+				Status: octorunv1.RunnerSetStatus{ActiveRunners: 1},
+			},
+			want: 5,
+		},
+		{
+			name: "idle_policy_keeps_min_idle_runners_on_top_of_active_runners",
+			runnerset: &octorunv1.RunnerSet{
+				Spec: octorunv1.RunnerSetSpec{
+					Runners:    pointer.Int32(5),
+					IdlePolicy: &octorunv1.RunnerSetIdlePolicy{MinIdleRunners: 2},
+				},
+				Status: octorunv1.RunnerSetStatus{ActiveRunners: 1},
+			},
+			want: 3,
+		},
+		{
+			name: "idle_policy_bounded_by_runners",
+			runnerset: &octorunv1.RunnerSet{
+				Spec: octorunv1.RunnerSetSpec{
+					Runners:    pointer.Int32(5),
+					IdlePolicy: &octorunv1.RunnerSetIdlePolicy{MinIdleRunners: 2},
+				},
+				Status: octorunv1.RunnerSetStatus{ActiveRunners: 4},
+			},
+			want: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := desiredRunnersForRunnerSet(tt.runnerset); got != tt.want {
+				t.Errorf("desiredRunnersForRunnerSet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_expiredIdleRunners(t *testing.T) {
+	now := time.Now()
+	runnerFn := func(name string, phase octorunv1.RunnerPhase, idleFor time.Duration) *octorunv1.Runner {
+		runner := &octorunv1.Runner{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     octorunv1.RunnerStatus{Phase: phase},
+		}
+
+		if phase == octorunv1.RunnerIdlePhase {
+			idleSince := metav1.NewTime(now.Add(-idleFor))
+			runner.Status.IdleSince = &idleSince
+		}
+
+		return runner
+	}
+
+	runners := []*octorunv1.Runner{
+		runnerFn("active", octorunv1.RunnerActivePhase, 0),
+		runnerFn("pending", octorunv1.RunnerPendingPhase, 0),
+		runnerFn("idle-1h", octorunv1.RunnerIdlePhase, time.Hour),
+		runnerFn("idle-5m", octorunv1.RunnerIdlePhase, 5*time.Minute),
+		runnerFn("idle-8m", octorunv1.RunnerIdlePhase, 8*time.Minute),
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "idle-unknown"},
+			Status:     octorunv1.RunnerStatus{Phase: octorunv1.RunnerIdlePhase},
+		},
+	}
+
+	expired, next := expiredIdleRunners(runners, 10*time.Minute, now)
+	if len(expired) != 2 || expired[0].Name != "idle-1h" || expired[1].Name != "idle-unknown" {
+		t.Errorf("expiredIdleRunners() expired = %v, want [idle-1h idle-unknown]", expired)
+	}
+	if next != 2*time.Minute {
+		t.Errorf("expiredIdleRunners() next = %v, want %v", next, 2*time.Minute)
+	}
+}
+
+func Test_setRegistrationFailedCondition(t *testing.T) {
+	failed := &octorunv1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Name: "runner-failed"},
+		Status: octorunv1.RunnerStatus{
+			Phase: octorunv1.RunnerFailedPhase,
+			Conditions: []metav1.Condition{
+				{
+					Type:    octorunv1.RunnerConditionRegistrationFailed,
+					Status:  metav1.ConditionTrue,
+					Reason:  octorunv1.RunnerRegistrationForbiddenReason,
+					Message: "forbidden",
+				},
+			},
+		},
+	}
+	idle := &octorunv1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Name: "runner-idle"},
+		Status:     octorunv1.RunnerStatus{Phase: octorunv1.RunnerIdlePhase},
+	}
+
+	tests := []struct {
+		name          string
+		failedRunners []*octorunv1.Runner
+		wantStatus    metav1.ConditionStatus
+		wantReason    string
+	}{
+		{
+			name:          "no_runners_failed_to_register",
+			failedRunners: nil,
+			wantStatus:    metav1.ConditionFalse,
+			wantReason:    octorunv1.RunnersRegisteredReason,
+		},
+		{
+			name:          "oneof_runners_failed_to_register",
+			failedRunners: []*octorunv1.Runner{failed},
+			wantStatus:    metav1.ConditionTrue,
+			wantReason:    octorunv1.RunnerRegistrationForbiddenReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runnerset := &octorunv1.RunnerSet{}
+			setRegistrationFailedCondition(runnerset, []*octorunv1.Runner{failed, idle}, tt.failedRunners)
+			cond := meta.FindStatusCondition(runnerset.Status.Conditions, octorunv1.RunnerSetConditionRegistrationFailed)
+			if cond == nil {
+				t.Fatalf("setRegistrationFailedCondition() condition not found")
+			}
+			if cond.Status != tt.wantStatus || cond.Reason != tt.wantReason {
+				t.Errorf("setRegistrationFailedCondition() = %v/%v, want %v/%v", cond.Status, cond.Reason, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestRunnerSetReconciler_reconcileVolumePools(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+
+	claimFn := func(name, claimedBy string) *corev1.PersistentVolumeClaim {
+		claim := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels: map[string]string{
+					octorunv1.LabelRunnerSetName: "runnerset-test",
+					octorunv1.LabelVolumePool:    "work",
+				},
+			},
+		}
+
+		if claimedBy != "" {
+			claim.Annotations = map[string]string{octorunv1.AnnotationVolumeClaimedBy: claimedBy}
+		}
+
+		return claim
+	}
+
+	tests := []struct {
+		name           string
+		reclaimPolicy  octorunv1.VolumePoolReclaimPolicy
+		wipePolicy     octorunv1.VolumePoolWipePolicy
+		staleCache     bool
+		wantFreeClaims []string
+		wantClaims     []string
+	}{
+		{
+			name:           "wipe_policy_never",
+			wipePolicy:     octorunv1.VolumePoolWipeNever,
+			wantFreeClaims: []string{"runnerset-test-work-1", "runnerset-test-work-2"},
+			wantClaims:     []string{"runnerset-test-work-0", "runnerset-test-work-1", "runnerset-test-work-2"},
+		},
+		{
+			name:           "wipe_policy_recreate",
+			wipePolicy:     octorunv1.VolumePoolWipeRecreate,
+			wantFreeClaims: []string{"runnerset-test-work-2"},
+			wantClaims:     []string{"runnerset-test-work-0", "runnerset-test-work-2"},
+		},
+		{
+			name:           "reclaim_policy_retain",
+			reclaimPolicy:  octorunv1.VolumePoolReclaimRetain,
+			wipePolicy:     octorunv1.VolumePoolWipeNever,
+			wantFreeClaims: []string{"runnerset-test-work-1", "runnerset-test-work-2"},
+			wantClaims:     []string{"runnerset-test-work-0", "runnerset-test-work-1", "runnerset-test-work-2", "runnerset-test-work-5"},
+		},
+		{
+			name:           "stale_cache_wipe_policy_never",
+			wipePolicy:     octorunv1.VolumePoolWipeNever,
+			staleCache:     true,
+			wantFreeClaims: []string{"runnerset-test-work-2"},
+			wantClaims:     []string{"runnerset-test-work-0", "runnerset-test-work-1", "runnerset-test-work-2"},
+		},
+		{
+			name:           "stale_cache_wipe_policy_recreate",
+			wipePolicy:     octorunv1.VolumePoolWipeRecreate,
+			staleCache:     true,
+			wantFreeClaims: []string{"runnerset-test-work-2"},
+			wantClaims:     []string{"runnerset-test-work-0", "runnerset-test-work-1", "runnerset-test-work-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runnerset := &octorunv1.RunnerSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "runnerset-test",
+					Namespace: "default",
+					UID:       types.UID(uuid.New().String()),
+				},
+				Spec: octorunv1.RunnerSetSpec{
+					Selector: metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"octorun.github.io/runnerset": "myrunnerset",
+						},
+					},
+					VolumePools: []octorunv1.RunnerSetVolumePool{
+						{
+							Name:          "work",
+							Size:          3,
+							ReclaimPolicy: tt.reclaimPolicy,
+							WipePolicy:    tt.wipePolicy,
+						},
+					},
+				},
+			}
+
+			runner := &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "runnerset-test-a",
+					Namespace: "default",
+					Labels:    runnerset.Spec.Selector.MatchLabels,
+				},
+				Spec: octorunv1.RunnerSpec{
+					Volumes: []corev1.Volume{
+						util.VolumePoolVolume(&runnerset.Spec.VolumePools[0], "runnerset-test-work-0"),
+					},
+				},
+			}
+			utilruntime.Must(ctrl.SetControllerReference(runnerset, runner, scheme))
+
+			fakec := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(
+					runnerset,
+					runner,
+					claimFn("runnerset-test-work-0", ""),
+					claimFn("runnerset-test-work-1", "runnerset-test-gone"),
+					claimFn("runnerset-test-work-5", ""),
+				).
+				Build()
+
+			// A stale cache misses the Runner that the API server already has.
+			var apiReader client.Reader = fakec
+			if tt.staleCache {
+				apiReader = fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(&octorunv1.Runner{
+						ObjectMeta: metav1.ObjectMeta{Name: "runnerset-test-gone", Namespace: "default"},
+					}).
+					Build()
+			}
+
+			r := &RunnerSetReconciler{
+				Client:    fakec,
+				Scheme:    scheme,
+				Recorder:  new(record.FakeRecorder),
+				APIReader: apiReader,
+			}
+
+			freeClaims, err := r.reconcileVolumePools(context.Background(), runnerset)
+			if err != nil {
+				t.Errorf("RunnerSetReconciler.reconcileVolumePools() error = %v", err)
+				return
+			}
+			if got := freeClaims["work"]; !reflect.DeepEqual(got, tt.wantFreeClaims) {
+				t.Errorf("RunnerSetReconciler.reconcileVolumePools() = %v, want %v", got, tt.wantFreeClaims)
+			}
+
+			claimList := &corev1.PersistentVolumeClaimList{}
+			if err := fakec.List(context.Background(), claimList); err != nil {
+				t.Fatal(err)
+			}
+
+			var gotClaims []string
+			for _, claim := range claimList.Items {
+				gotClaims = append(gotClaims, claim.Name)
+				if claim.Name == "runnerset-test-work-0" && claim.Annotations[octorunv1.AnnotationVolumeClaimedBy] != runner.Name {
+					t.Errorf("claim %s claimed by = %q, want %q", claim.Name, claim.Annotations[octorunv1.AnnotationVolumeClaimedBy], runner.Name)
+				}
+				if claim.Name == "runnerset-test-work-1" && !tt.staleCache && claim.Annotations[octorunv1.AnnotationVolumeClaimedBy] != "" {
+					t.Errorf("claim %s is still claimed by %q", claim.Name, claim.Annotations[octorunv1.AnnotationVolumeClaimedBy])
+				}
+				if claim.Name == "runnerset-test-work-1" && tt.staleCache && claim.Annotations[octorunv1.AnnotationVolumeClaimedBy] != "runnerset-test-gone" {
+					t.Errorf("claim %s claimed by = %q, want %q", claim.Name, claim.Annotations[octorunv1.AnnotationVolumeClaimedBy], "runnerset-test-gone")
+				}
+			}
+			sort.Strings(gotClaims)
+			if !reflect.DeepEqual(gotClaims, tt.wantClaims) {
+				t.Errorf("PersistentVolumeClaims = %v, want %v", gotClaims, tt.wantClaims)
+			}
+		})
+	}
+}
+
+func TestRunnerSetReconciler_syncRunners_volumePoolExhausted(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+
+	runnerset := &octorunv1.RunnerSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "runnerset-test",
+			Namespace: "default",
+			UID:       types.UID(uuid.New().String()),
+		},
+		Spec: octorunv1.RunnerSetSpec{
+			Runners:     pointer.Int32(2),
+			VolumePools: []octorunv1.RunnerSetVolumePool{{Name: "work", Size: 1}},
+			Template: octorunv1.RunnerTemplateSpec{
+				Spec: octorunv1.RunnerSpec{URL: "https://github.com/octorun"},
+			},
+		},
+	}
+
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "runnerset-test-work-0", Namespace: "default"},
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	r := &RunnerSetReconciler{
+		Client:     fake.NewClientBuilder().WithScheme(scheme).WithObjects(runnerset, claim).Build(),
+		Scheme:     scheme,
+		Recorder:   recorder,
+		Revisioner: new(RunnerSetRevisioner),
+	}
+
+	rev := &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "runnerset-test-abc"}}
+	freeClaims := map[string][]string{"work": {claim.Name}}
+	if _, err := r.syncRunners(context.Background(), runnerset, nil, rev, freeClaims); err != nil {
+		t.Errorf("RunnerSetReconciler.syncRunners() error = %v", err)
+		return
+	}
+
+	close(recorder.Events)
+	var exhausted int
+	for event := range recorder.Events {
+		if strings.Contains(event, octorunv1.VolumePoolExhaustedReason) {
+			exhausted++
+		}
+	}
+	if exhausted != 1 {
+		t.Errorf("RunnerSetReconciler.syncRunners() %s events = %v, want 1", octorunv1.VolumePoolExhaustedReason, exhausted)
+	}
+}
+
+// runnerCreateFailingClient fails to create any Runner.
+type runnerCreateFailingClient struct {
+	client.Client
+}
+
+func (c *runnerCreateFailingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if _, ok := obj.(*octorunv1.Runner); ok {
+		return apierrors.NewInternalError(fmt.Errorf("etcdserver: request timed out"))
+	}
+
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestRunnerSetReconciler_syncRunners_createFailed(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+
+	runnerset := &octorunv1.RunnerSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "runnerset-test",
+			Namespace: "default",
+			UID:       types.UID(uuid.New().String()),
+		},
+		Spec: octorunv1.RunnerSetSpec{
+			Runners:     pointer.Int32(1),
+			VolumePools: []octorunv1.RunnerSetVolumePool{{Name: "work", Size: 1}},
+			Template: octorunv1.RunnerTemplateSpec{
+				Spec: octorunv1.RunnerSpec{URL: "https://github.com/octorun"},
+			},
+		},
+	}
+
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "runnerset-test-work-0", Namespace: "default"},
+	}
+
+	fakec := fake.NewClientBuilder().WithScheme(scheme).WithObjects(runnerset, claim).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &RunnerSetReconciler{
+		Client:     &runnerCreateFailingClient{Client: fakec},
+		Scheme:     scheme,
+		Recorder:   recorder,
+		Revisioner: new(RunnerSetRevisioner),
+	}
+
+	rev := &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "runnerset-test-abc"}}
+	freeClaims := map[string][]string{"work": {claim.Name}}
+	if _, err := r.syncRunners(context.Background(), runnerset, nil, rev, freeClaims); err == nil {
+		t.Errorf("RunnerSetReconciler.syncRunners() error = nil, want the create error")
+	}
+
+	if err := fakec.Get(context.Background(), client.ObjectKeyFromObject(claim), claim); err != nil {
+		t.Fatalf("unable to get the PersistentVolumeClaim: %v", err)
+	}
+	if claimedBy := annotations.VolumeClaimedBy(claim); claimedBy != "" {
+		t.Errorf("RunnerSetReconciler.syncRunners() claimed %s by %q, want it free", claim.Name, claimedBy)
+	}
+
+	close(recorder.Events)
+	for event := range recorder.Events {
+		if strings.Contains(event, octorunv1.RunnerCreatedReason) {
+			t.Errorf("RunnerSetReconciler.syncRunners() recorded %q, want no %s event", event, octorunv1.RunnerCreatedReason)
+		}
+	}
+}
+
+func TestRunnerSetReconciler_syncRunners_idlePolicy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+
+	now := time.Now()
+	runnerFn := func(name string, phase octorunv1.RunnerPhase, idleFor time.Duration) *octorunv1.Runner {
+		runner := &octorunv1.Runner{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status:     octorunv1.RunnerStatus{Phase: phase},
+		}
+
+		if idleFor > 0 {
+			idleSince := metav1.NewTime(now.Add(-idleFor))
+			runner.Status.IdleSince = &idleSince
+		}
+
+		return runner
+	}
+
+	tests := []struct {
+		name          string
+		runners       int32
+		activeRunners int32
+		objects       []*octorunv1.Runner
+		wantDeleted   []string
+		wantRequeue   bool
+	}{
+		{
+			name:          "runners_lowered_below_idle_runners",
+			runners:       1,
+			activeRunners: 1,
+			objects: []*octorunv1.Runner{
+				runnerFn("active", octorunv1.RunnerActivePhase, 0),
+				runnerFn("idle-1m", octorunv1.RunnerIdlePhase, time.Minute),
+				runnerFn("idle-2m", octorunv1.RunnerIdlePhase, 2*time.Minute),
+			},
+			wantDeleted: []string{"idle-1m", "idle-2m"},
+		},
+		{
+			name:          "runners_lowered_below_busy_runners",
+			runners:       1,
+			activeRunners: 2,
+			objects: []*octorunv1.Runner{
+				runnerFn("active-a", octorunv1.RunnerActivePhase, 0),
+				runnerFn("active-b", octorunv1.RunnerActivePhase, 0),
+			},
+			wantDeleted: []string{"active-a"},
+		},
+		{
+			name:          "surplus_idle_runners_wait_for_expiration",
+			runners:       5,
+			activeRunners: 0,
+			objects: []*octorunv1.Runner{
+				runnerFn("idle-1h", octorunv1.RunnerIdlePhase, time.Hour),
+				runnerFn("idle-1m", octorunv1.RunnerIdlePhase, time.Minute),
+				runnerFn("idle-2m", octorunv1.RunnerIdlePhase, 2*time.Minute),
+			},
+			wantDeleted: []string{"idle-1h"},
+			wantRequeue: true,
+		},
+		{
+			name:          "surplus_idle_runners_idle_since_unknown",
+			runners:       5,
+			activeRunners: 0,
+			objects: []*octorunv1.Runner{
+				runnerFn("idle-unknown", octorunv1.RunnerIdlePhase, 0),
+				runnerFn("idle-1m", octorunv1.RunnerIdlePhase, time.Minute),
+			},
+			wantDeleted: []string{"idle-unknown"},
+			wantRequeue: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runnerset := &octorunv1.RunnerSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "runnerset-test",
+					Namespace: "default",
+					UID:       types.UID(uuid.New().String()),
+				},
+				Spec: octorunv1.RunnerSetSpec{
+					Runners: pointer.Int32(tt.runners),
+					IdlePolicy: &octorunv1.RunnerSetIdlePolicy{
+						MinIdleRunners:  1,
+						MaxIdleDuration: &metav1.Duration{Duration: 10 * time.Minute},
+					},
+				},
+				Status: octorunv1.RunnerSetStatus{ActiveRunners: tt.activeRunners},
+			}
+
+			builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(runnerset)
+			for _, runner := range tt.objects {
+				builder = builder.WithObjects(runner)
+			}
+
+			fakec := builder.Build()
+			r := &RunnerSetReconciler{
+				Client:     fakec,
+				Scheme:     scheme,
+				Recorder:   record.NewFakeRecorder(10),
+				Revisioner: new(RunnerSetRevisioner),
+			}
+
+			got, err := r.syncRunners(context.Background(), runnerset, tt.objects, &appsv1.ControllerRevision{}, nil)
+			if err != nil {
+				t.Fatalf("RunnerSetReconciler.syncRunners() error = %v", err)
+			}
+			if (got.RequeueAfter > 0) != tt.wantRequeue {
+				t.Errorf("RunnerSetReconciler.syncRunners() = %v, wantRequeue %v", got, tt.wantRequeue)
+			}
+
+			runnerList := &octorunv1.RunnerList{}
+			if err := fakec.List(context.Background(), runnerList); err != nil {
+				t.Fatalf("unexpected List error: %v", err)
+			}
+
+			remaining := make(map[string]bool)
+			for _, runner := range runnerList.Items {
+				remaining[runner.Name] = true
+			}
+
+			var deleted []string
+			for _, runner := range tt.objects {
+				if !remaining[runner.Name] {
+					deleted = append(deleted, runner.Name)
+				}
+			}
+
+			sort.Strings(deleted)
+			if !reflect.DeepEqual(deleted, tt.wantDeleted) {
+				t.Errorf("RunnerSetReconciler.syncRunners() deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
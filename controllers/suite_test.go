@@ -125,6 +125,7 @@ var _ = BeforeSuite(func() {
 		Scheme:     mgr.GetScheme(),
 		Recorder:   new(record.FakeRecorder),
 		Revisioner: new(RunnerSetRevisioner),
+		APIReader:  mgr.GetAPIReader(),
 	}).SetupWithManager(ctx, mgr)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+)
+
+const WorkflowJobController = "workflowjob.octorun.github.io/controller"
+
+// WorkflowJobReconciler prunes the WorkflowJobs recorded by the Github webhook hook once their TTL has passed.
+type WorkflowJobReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// TTL is how long a WorkflowJob is retained after its job has completed.
+	// A WorkflowJob that never completed, eg: its completed event was not delivered,
+	// is retained for TTL after its creation.
+	TTL time.Duration
+}
+
+// +kubebuilder:rbac:groups=octorun.github.io,resources=workflowjobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=octorun.github.io,resources=workflowjobs/status,verbs=get;update;patch
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *WorkflowJobReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&octorunv1.WorkflowJob{}).
+		Complete(r)
+}
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *WorkflowJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	workflowJob := &octorunv1.WorkflowJob{}
+	if err := r.Get(ctx, req.NamespacedName, workflowJob); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Return early if requested workflowjob is not found.
+			log.V(1).Info("WorkflowJob not found")
+			return ctrl.Result{}, nil
+		}
+
+		log.Error(err, "unable to get WorkflowJob")
+		return ctrl.Result{}, err
+	}
+
+	if !workflowJob.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	if remaining := time.Until(r.expirationTime(workflowJob)); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	log.Info("deleting expired WorkflowJob")
+	if err := r.Delete(ctx, workflowJob, client.Preconditions{UID: &workflowJob.UID}); client.IgnoreNotFound(err) != nil {
+		log.Error(err, "unable to delete expired WorkflowJob")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// expirationTime returns the time given WorkflowJob expires, that is TTL after its job has completed
+// or TTL after its creation if its job has not completed.
+func (r *WorkflowJobReconciler) expirationTime(workflowJob *octorunv1.WorkflowJob) time.Time {
+	if workflowJob.Status.CompletionTime != nil {
+		return workflowJob.Status.CompletionTime.Add(r.TTL)
+	}
+
+	return workflowJob.CreationTimestamp.Add(r.TTL)
+}
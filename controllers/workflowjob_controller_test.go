@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+)
+
+func TestWorkflowJobReconciler_Reconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+
+	ttl := time.Hour
+	tests := []struct {
+		name          string
+		workflowJobFn func(workflowJob *octorunv1.WorkflowJob) *octorunv1.WorkflowJob
+		wantRequeue   bool
+		wantDeleted   bool
+	}{
+		{
+			name: "workflowjob_not_found",
+			workflowJobFn: func(workflowJob *octorunv1.WorkflowJob) *octorunv1.WorkflowJob {
+				return &octorunv1.WorkflowJob{}
+			},
+			wantRequeue: false,
+			wantDeleted: true,
+		},
+		{
+			name: "workflowjob_completed_recently",
+			workflowJobFn: func(workflowJob *octorunv1.WorkflowJob) *octorunv1.WorkflowJob {
+				workflowJob.Status.Phase = octorunv1.WorkflowJobCompletedPhase
+				workflowJob.Status.CompletionTime = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+				return workflowJob
+			},
+			wantRequeue: true,
+			wantDeleted: false,
+		},
+		{
+			name: "workflowjob_completed_expired",
+			workflowJobFn: func(workflowJob *octorunv1.WorkflowJob) *octorunv1.WorkflowJob {
+				workflowJob.Status.Phase = octorunv1.WorkflowJobCompletedPhase
+				workflowJob.Status.CompletionTime = &metav1.Time{Time: time.Now().Add(-2 * ttl)}
+				return workflowJob
+			},
+			wantRequeue: false,
+			wantDeleted: true,
+		},
+		{
+			name: "workflowjob_in_progress_created_recently",
+			workflowJobFn: func(workflowJob *octorunv1.WorkflowJob) *octorunv1.WorkflowJob {
+				workflowJob.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Minute))
+				workflowJob.Status.Phase = octorunv1.WorkflowJobInProgressPhase
+				return workflowJob
+			},
+			wantRequeue: true,
+			wantDeleted: false,
+		},
+		{
+			name: "workflowjob_in_progress_expired",
+			workflowJobFn: func(workflowJob *octorunv1.WorkflowJob) *octorunv1.WorkflowJob {
+				workflowJob.CreationTimestamp = metav1.NewTime(time.Now().Add(-2 * ttl))
+				workflowJob.Status.Phase = octorunv1.WorkflowJobInProgressPhase
+				return workflowJob
+			},
+			wantRequeue: false,
+			wantDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflowJob := tt.workflowJobFn(&octorunv1.WorkflowJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "1",
+					Namespace:         "default",
+					CreationTimestamp: metav1.Now(),
+				},
+				Spec: octorunv1.WorkflowJobSpec{ID: 1},
+			})
+
+			r := &WorkflowJobReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(workflowJob).Build(),
+				Scheme: scheme,
+				TTL:    ttl,
+			}
+
+			key := types.NamespacedName{Namespace: "default", Name: "1"}
+			got, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+			if err != nil {
+				t.Errorf("WorkflowJobReconciler.Reconcile() error = %v", err)
+				return
+			}
+			if requeue := got.RequeueAfter > 0; requeue != tt.wantRequeue {
+				t.Errorf("WorkflowJobReconciler.Reconcile() = %v, want requeue %v", got, tt.wantRequeue)
+			}
+			if got.RequeueAfter > ttl {
+				t.Errorf("WorkflowJobReconciler.Reconcile() requeue after = %v, want at most %v", got.RequeueAfter, ttl)
+			}
+
+			err = r.Get(context.Background(), key, &octorunv1.WorkflowJob{})
+			if deleted := apierrors.IsNotFound(err); deleted != tt.wantDeleted {
+				t.Errorf("WorkflowJob deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+			if err := client.IgnoreNotFound(err); err != nil {
+				t.Errorf("unexpected Get error: %v", err)
+			}
+		})
+	}
+}
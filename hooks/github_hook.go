@@ -20,11 +20,14 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/google/go-github/v41/github"
+	corev1 "k8s.io/api/core/v1"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -32,6 +35,8 @@ import (
 
 	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
 	"octorun.github.io/octorun/pkg/github/webhook"
+	"octorun.github.io/octorun/util"
+	"octorun.github.io/octorun/util/annotations"
 )
 
 type GithubHook struct {
@@ -42,6 +47,10 @@ const (
 	// runnerCompositeIndexField is field for controller-runtime cache indexing.
 	// it is not a real runner object field.
 	runnerCompositeIndexField = "composite_idx"
+
+	// maxQueuedJobAge is how long Github keeps a workflow job queued before it is cancelled.
+	// A WorkflowJob queued for longer has missed its later events and is no longer waiting for a runner.
+	maxQueuedJobAge = 24 * time.Hour
 )
 
 // SetupWithManager sets up the GithubHook with the controller-runtime Manager.
@@ -67,12 +76,46 @@ func (gh *GithubHook) SetupWithManager(ctx context.Context, mgr manager.Manager,
 func (gh *GithubHook) Handle(ctx context.Context, req webhook.Request) {
 	switch event := req.Event.(type) {
 	case *github.WorkflowJobEvent:
-		gh.processWorkflowJobEvent(ctx, event)
+		jobEvent := &workflowJobEvent{WorkflowJobEvent: event, Enterprise: req.Enterprise}
+		if req.WorkflowJob != nil {
+			jobEvent.WorkflowName = req.WorkflowJob.WorkflowName
+			jobEvent.HeadBranch = req.WorkflowJob.HeadBranch
+			if req.WorkflowJob.CreatedAt != nil {
+				jobEvent.CreatedAt = req.WorkflowJob.CreatedAt.Time
+			}
+		}
+
+		gh.processWorkflowJobEvent(ctx, jobEvent)
 	default:
 		// ignore the rest event
 	}
 }
 
+// workflowJobEvent is the workflow job event together with the enterprise
+// of the repository owner if any and the workflow job fields go-github does not decode.
+type workflowJobEvent struct {
+	*github.WorkflowJobEvent
+
+	Enterprise   *github.Enterprise
+	WorkflowName string
+	HeadBranch   string
+	// CreatedAt is the time the workflow job was created, i.e. queued.
+	CreatedAt time.Time
+}
+
+// runnerJob returns the details of the workflow job from given event as recorded in the Runner status.
+func runnerJob(event *workflowJobEvent) *octorunv1.RunnerJobStatus {
+	return &octorunv1.RunnerJobStatus{
+		ID:           event.WorkflowJob.GetID(),
+		Name:         event.WorkflowJob.GetName(),
+		RunID:        event.WorkflowJob.GetRunID(),
+		WorkflowName: event.WorkflowName,
+		Repository:   event.Repo.GetFullName(),
+		HeadBranch:   event.HeadBranch,
+		URL:          event.WorkflowJob.GetHTMLURL(),
+	}
+}
+
 // runnerCompositeIndex returns b64 encoded string of cache field key
 // with format "name:{runnerName};id:{runnerID};group:{runnerGroup};url:{runnerURL}"
 func (gh *GithubHook) runnerCompositeIndex(runnerName, runnerID, runnerGroup, runnerURL string) string {
@@ -102,11 +145,13 @@ func (gh *GithubHook) runnerCompositeIndexer(o client.Object) []string {
 	))
 }
 
-// Trigger runner reconciler by annotate the runner with runner.octorun.github.io/assigned-job-at annotation.
+// Trigger runner reconciler by annotate the runner with runner.octorun.github.io/assigned-job-at annotation
+// together with the details of the workflow job from given event, which the runner controller copies into
+// the Runner status.
 //
 // Just trigger the runner reconciler instead of directly patching the status here is because we expected
 // the Runner status.phase field is only managed by the runner-controller (not by other systems, including this hook).
-func (gh *GithubHook) triggerRunnerReconciliation(ctx context.Context, runnerKey client.ObjectKey) (reterr error) {
+func (gh *GithubHook) triggerRunnerReconciliation(ctx context.Context, runnerKey client.ObjectKey, event *workflowJobEvent) (reterr error) {
 	log := ctrl.LoggerFrom(ctx)
 	runner := &octorunv1.Runner{}
 	if err := gh.Client.Get(ctx, runnerKey, runner); err != nil {
@@ -128,61 +173,350 @@ func (gh *GithubHook) triggerRunnerReconciliation(ctx context.Context, runnerKey
 
 	annotation[octorunv1.AnnotationRunnerAssignedJobAt] = time.Now().Format(time.RFC3339)
 	runner.SetAnnotations(annotation)
+	annotations.AnnotateJob(runner, runnerJob(event))
 	return nil
 }
 
-func (gh *GithubHook) processWorkflowJobEvent(ctx context.Context, event *github.WorkflowJobEvent) {
+// Mark the runner workflow job as completed by annotate the runner with runner.octorun.github.io/job-completed-at
+// and runner.octorun.github.io/job-conclusion annotations. The runner controller records the job completion in
+// the Runner status and completes an ephemeral runner right away instead of waiting for its pod to succeed.
+// The job details are annotated as well in case the in_progress event has not been delivered.
+func (gh *GithubHook) markRunnerJobCompleted(ctx context.Context, runnerKey client.ObjectKey, event *workflowJobEvent) (reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+	runner := &octorunv1.Runner{}
+	if err := gh.Client.Get(ctx, runnerKey, runner); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	runnerBase := runner.DeepCopy()
+	defer func() {
+		log.V(1).Info("marking Runner job completed", "runner", runnerKey.String(), "conclusion", event.WorkflowJob.GetConclusion())
+		if err := gh.Patch(ctx, runner, client.MergeFrom(runnerBase)); err != nil {
+			reterr = err
+		}
+	}()
+
+	completedAt := event.WorkflowJob.GetCompletedAt().Time
+	if completedAt.IsZero() {
+		completedAt = time.Now()
+	}
+
+	annotations.AnnotateJob(runner, runnerJob(event))
+	annotations.AnnotateJobCompleted(runner, completedAt, event.WorkflowJob.GetConclusion())
+	return nil
+}
+
+// eventRunnerURLs returns the runner URLs that able to pick up the workflow job from given event.
+// The enterprise url comes first if the repo owner belongs to an Enterprise, then the organization
+// url if repo owned by Organization.
+func eventRunnerURLs(event *workflowJobEvent) []string {
+	var urls []string
+	if event.Enterprise != nil {
+		urls = append(urls, event.Enterprise.GetHTMLURL())
+	}
+
+	if event.Repo.Owner.GetType() == "Organization" {
+		urls = append(urls, event.Repo.Owner.GetHTMLURL())
+	}
+
+	return append(urls, event.Repo.GetHTMLURL())
+}
+
+// runnerSetMatchesJob returns true if runners created from given RunnerSet template
+// are registered to one of given urls and have all the workflow job labels.
+func runnerSetMatchesJob(runnerset *octorunv1.RunnerSet, urls []string, jobLabels []string) bool {
+	for _, u := range urls {
+		if runnerset.Spec.Template.Spec.URL == u {
+			return util.MatchRunnerLabels(jobLabels, util.RunnerLabels(runnerset.Spec.Template.Labels))
+		}
+	}
+
+	return false
+}
+
+// findRunnerSet finds the RunnerSet whose runners are able to run the workflow job from given event,
+// only the on-demand RunnerSets are considered if onDemand is true. If there are several matching
+// RunnerSets the first one ordered by namespace and name is returned.
+func (gh *GithubHook) findRunnerSet(ctx context.Context, event *workflowJobEvent, onDemand bool) (*octorunv1.RunnerSet, error) {
+	runnersetList := &octorunv1.RunnerSetList{}
+	if err := gh.List(ctx, runnersetList); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(runnersetList.Items, func(i, j int) bool {
+		return client.ObjectKeyFromObject(&runnersetList.Items[i]).String() < client.ObjectKeyFromObject(&runnersetList.Items[j]).String()
+	})
+
+	urls := eventRunnerURLs(event)
+	for i := range runnersetList.Items {
+		runnerset := &runnersetList.Items[i]
+		if (onDemand && !runnerset.Spec.OnDemand) || !runnerset.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		if runnerSetMatchesJob(runnerset, urls, event.WorkflowJob.Labels) {
+			return runnerset, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// isAutoscaled returns true if given RunnerSet is the scale target of a RunnerAutoscaler.
+func (gh *GithubHook) isAutoscaled(ctx context.Context, runnerset *octorunv1.RunnerSet) (bool, error) {
+	autoscalerList := &octorunv1.RunnerAutoscalerList{}
+	if err := gh.List(ctx, autoscalerList, client.InNamespace(runnerset.Namespace)); err != nil {
+		return false, err
+	}
+
+	for i := range autoscalerList.Items {
+		if autoscalerList.Items[i].Spec.ScaleTargetRef.Name == runnerset.Name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// needsOnDemandRunner returns true unless given RunnerSet already has a Runner for the workflow job from
+// given event, ie: an on-demand Runner created for it by a previous delivery of the queued event, or an
+// Idle Runner not created on demand left for it once the other queued workflow jobs without an on-demand
+// Runner have picked up theirs.
+func (gh *GithubHook) needsOnDemandRunner(ctx context.Context, runnerset *octorunv1.RunnerSet, event *workflowJobEvent) (bool, error) {
+	runnerList := &octorunv1.RunnerList{}
+	if err := gh.List(ctx, runnerList, client.InNamespace(runnerset.Namespace)); err != nil {
+		return false, err
+	}
+
+	var idleRunners int
+	onDemandJobs := make(map[int64]bool)
+	for i := range runnerList.Items {
+		runner := &runnerList.Items[i]
+		if !metav1.IsControlledBy(runner, runnerset) || !runner.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		if annotations.IsOnDemand(runner) {
+			onDemandJobs[annotations.OnDemandJobID(runner)] = true
+			continue
+		}
+
+		if runner.Status.Phase == octorunv1.RunnerIdlePhase {
+			idleRunners++
+		}
+	}
+
+	if onDemandJobs[event.WorkflowJob.GetID()] {
+		return false, nil
+	}
+
+	queuedJobs, err := gh.countQueuedJobs(ctx, runnerset, event.WorkflowJob.GetID(), onDemandJobs)
+	if err != nil {
+		return false, err
+	}
+
+	return queuedJobs+1 > idleRunners, nil
+}
+
+// countQueuedJobs returns the number of workflow jobs queued for given RunnerSet as recorded by the WorkflowJobs
+// labeled with its runnerset label, except the job with given ID and the jobs with an on-demand Runner.
+func (gh *GithubHook) countQueuedJobs(ctx context.Context, runnerset *octorunv1.RunnerSet, jobID int64, onDemandJobs map[int64]bool) (int, error) {
+	name, ok := runnerset.Spec.Template.Labels[octorunv1.LabelRunnerSetName]
+	if !ok {
+		return 0, nil
+	}
+
+	workflowJobList := &octorunv1.WorkflowJobList{}
+	if err := gh.List(ctx, workflowJobList, client.InNamespace(runnerset.Namespace), client.MatchingLabels{octorunv1.LabelRunnerSetName: name}); err != nil {
+		return 0, err
+	}
+
+	var queuedJobs int
+	for i := range workflowJobList.Items {
+		workflowJob := &workflowJobList.Items[i]
+		if workflowJob.Spec.ID == jobID || onDemandJobs[workflowJob.Spec.ID] || workflowJob.Status.Phase != octorunv1.WorkflowJobQueuedPhase {
+			continue
+		}
+
+		if workflowJob.Status.QueuedTime != nil && time.Since(workflowJob.Status.QueuedTime.Time) > maxQueuedJobAge {
+			continue
+		}
+
+		queuedJobs++
+	}
+
+	return queuedJobs, nil
+}
+
+// wakeUpRunnerSet creates an on-demand Runner from the template of the on-demand RunnerSet
+// that matches the workflow job from given event if any. No Runner is created if the RunnerSet
+// is scaled by a RunnerAutoscaler, which counts the queued job itself, or if it already has
+// a Runner for the job.
+//
+// The created Runner is controlled by the RunnerSet so it will be deleted by the runnerset-controller
+// once it has completed the job.
+func (gh *GithubHook) wakeUpRunnerSet(ctx context.Context, event *workflowJobEvent) {
+	log := ctrl.LoggerFrom(ctx)
+	runnerset, err := gh.findRunnerSet(ctx, event, true)
+	if err != nil {
+		log.Error(err, "unable to find on-demand RunnerSet")
+		return
+	}
+
+	if runnerset == nil {
+		log.V(1).Info("no on-demand RunnerSet found for the workflowjob", "labels", event.WorkflowJob.Labels)
+		return
+	}
+
+	autoscaled, err := gh.isAutoscaled(ctx, runnerset)
+	if err != nil {
+		log.Error(err, "unable to list RunnerAutoscalers", "runnerset", runnerset.Name)
+		return
+	}
+
+	if autoscaled {
+		log.V(1).Info("on-demand RunnerSet is scaled by a RunnerAutoscaler", "runnerset", runnerset.Name)
+		return
+	}
+
+	needed, err := gh.needsOnDemandRunner(ctx, runnerset, event)
+	if err != nil {
+		log.Error(err, "unable to list RunnerSet Runners", "runnerset", runnerset.Name)
+		return
+	}
+
+	if !needed {
+		log.V(1).Info("on-demand RunnerSet already has a Runner for the workflowjob", "runnerset", runnerset.Name)
+		return
+	}
+
+	runner := &octorunv1.Runner{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: runnerset.Name + "-",
+			Namespace:    runnerset.Namespace,
+			Annotations:  make(map[string]string),
+			Labels:       make(map[string]string),
+		},
+		Spec: runnerset.Spec.Template.Spec,
+	}
+
+	for k, v := range runnerset.Spec.Template.Annotations {
+		runner.Annotations[k] = v
+	}
+
+	for k, v := range runnerset.Spec.Template.Labels {
+		runner.Labels[k] = v
+	}
+
+	// On-demand runners are not handed out volume pool PersistentVolumeClaims.
+	// Give them emptyDir volumes so the volume mounts of the template still work.
+	var volumes []corev1.Volume
+	volumes = append(volumes, runner.Spec.Volumes...)
+	for i := range runnerset.Spec.VolumePools {
+		volumes = append(volumes, util.VolumePoolVolume(&runnerset.Spec.VolumePools[i], ""))
+	}
+
+	runner.Spec.Volumes = volumes
+	annotations.AnnotateOnDemand(runner)
+	annotations.AnnotateOnDemandJobID(runner, event.WorkflowJob.GetID())
+	if err := ctrl.SetControllerReference(runnerset, runner, gh.Scheme()); err != nil {
+		log.Error(err, "unable to set on-demand Runner controller reference")
+		return
+	}
+
+	if err := gh.Create(ctx, runner); err != nil {
+		log.Error(err, "unable to create on-demand Runner", "runnerset", runnerset.Name)
+		return
+	}
+
+	log.Info("created on-demand Runner", "runnerset", runnerset.Name, "runner", runner.Name)
+}
+
+func (gh *GithubHook) processWorkflowJobEvent(ctx context.Context, event *workflowJobEvent) {
 	log := ctrl.LoggerFrom(ctx)
 	switch action := event.GetAction(); action {
-	case "in_progress":
+	case "queued":
 		log.Info("processing workflowjob event", "action", action)
-		runnerID := strconv.Itoa(int(event.WorkflowJob.GetRunnerID()))
-		runnerName := event.WorkflowJob.GetRunnerName()
-		runnerGroup := event.WorkflowJob.GetRunnerGroupName()
-		runnerList := &octorunv1.RunnerList{}
-		if event.Repo.Owner.GetType() == "Organization" {
-			// If repo owned by Organization try to find runner based on organization url first.
-			u := event.Repo.Owner.GetHTMLURL()
-			log.V(1).Info("try to find Runner based on organization url", "url", u)
-			if err := gh.List(ctx, runnerList,
-				client.MatchingFields{runnerCompositeIndexField: gh.runnerCompositeIndex(runnerName, runnerID, runnerGroup, u)},
-			); err != nil {
-				log.Error(err, "unable to find Runner")
-				return
-			}
+		gh.wakeUpRunnerSet(ctx, event)
+		gh.processWorkflowJob(ctx, event, nil)
+	case "completed":
+		log.Info("processing workflowjob event", "action", action)
+		if event.WorkflowJob.GetRunnerName() == "" {
+			// The job has been cancelled before a runner picked it up.
+			gh.processWorkflowJob(ctx, event, nil)
+			return
 		}
 
-		if len(runnerList.Items) == 0 {
-			// runnerList.Items is 0 means runner is not registered using organization URL
-			// or repository owner is not an Organization.
-			// Find the runner based on repository url then.
-			u := event.Repo.GetHTMLURL()
-			log.V(1).Info("try to find Runner based on repository url", "url", u)
-			if err := gh.List(ctx, runnerList,
-				client.MatchingFields{runnerCompositeIndexField: gh.runnerCompositeIndex(runnerName, runnerID, runnerGroup, u)},
-			); err != nil {
-				log.Error(err, "unable to find Runner")
-				return
-			}
+		runner, err := gh.findRunner(ctx, event)
+		if err != nil {
+			return
 		}
 
-		switch i := len(runnerList.Items); {
-		case i == 0:
-			// If the runner is still not found, it means that Github scheduled
-			// the WorkflowJob to the runner that is not controlled by octorun.
-			log.Info("no Runner found in the cluster", "runner", runnerName, "runner-id", runnerID)
+		gh.processWorkflowJob(ctx, event, runner)
+		if runner == nil {
 			return
-		case i > 1:
-			log.Info("unexpected found Runner more than 1", "found Runner", i)
+		}
+
+		if err := gh.markRunnerJobCompleted(ctx, client.ObjectKeyFromObject(runner), event); err != nil {
+			log.Error(err, "failed marking runner job completed")
+			return
+		}
+	case "in_progress":
+		log.Info("processing workflowjob event", "action", action)
+		runner, err := gh.findRunner(ctx, event)
+		if err != nil {
+			return
+		}
+
+		gh.processWorkflowJob(ctx, event, runner)
+		if runner == nil {
+			return
+		}
+
+		if err := gh.triggerRunnerReconciliation(ctx, client.ObjectKeyFromObject(runner), event); err != nil {
+			log.Error(err, "failed triggering runner reconciliation")
 			return
-		default:
-			log.Info("found Runner", "runner", runnerName, "runner-id", runnerID)
-			if err := gh.triggerRunnerReconciliation(ctx, client.ObjectKeyFromObject(&runnerList.Items[0])); err != nil {
-				log.Error(err, "failed triggering runner reconciliation")
-				return
-			}
 		}
 	default:
 		return
 	}
 }
+
+// findRunner finds the Runner that picked up the workflow job from given event.
+// It returns nil if the runner is not controlled by octorun.
+func (gh *GithubHook) findRunner(ctx context.Context, event *workflowJobEvent) (*octorunv1.Runner, error) {
+	log := ctrl.LoggerFrom(ctx)
+	runnerID := strconv.Itoa(int(event.WorkflowJob.GetRunnerID()))
+	runnerName := event.WorkflowJob.GetRunnerName()
+	runnerGroup := event.WorkflowJob.GetRunnerGroupName()
+	runnerList := &octorunv1.RunnerList{}
+	// Try to find the runner based on the enterprise url first, then the organization url
+	// and the repository url until the runner is found.
+	for _, u := range eventRunnerURLs(event) {
+		log.V(1).Info("try to find Runner based on url", "url", u)
+		if err := gh.List(ctx, runnerList,
+			client.MatchingFields{runnerCompositeIndexField: gh.runnerCompositeIndex(runnerName, runnerID, runnerGroup, u)},
+		); err != nil {
+			log.Error(err, "unable to find Runner")
+			return nil, err
+		}
+
+		if len(runnerList.Items) > 0 {
+			break
+		}
+	}
+
+	switch i := len(runnerList.Items); {
+	case i == 0:
+		// If the runner is still not found, it means that Github scheduled
+		// the WorkflowJob to the runner that is not controlled by octorun.
+		log.Info("no Runner found in the cluster", "runner", runnerName, "runner-id", runnerID)
+		return nil, nil
+	case i > 1:
+		log.Info("unexpected found Runner more than 1", "found Runner", i)
+		return nil, nil
+	default:
+		log.Info("found Runner", "runner", runnerName, "runner-id", runnerID)
+		return &runnerList.Items[0], nil
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-github/v41/github"
 	corev1 "k8s.io/api/core/v1"
@@ -29,6 +30,8 @@ import (
 	"k8s.io/utils/pointer"
 
 	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+	"octorun.github.io/octorun/util/annotations"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -105,10 +108,26 @@ func TestGithubHook_triggerRunnerReconciliation(t *testing.T) {
 		client.Client
 	}
 
+	event := &workflowJobEvent{
+		WorkflowJobEvent: &github.WorkflowJobEvent{
+			Action: github.String("in_progress"),
+			WorkflowJob: &github.WorkflowJob{
+				ID:      github.Int64(1),
+				Name:    github.String("build"),
+				RunID:   github.Int64(2),
+				HTMLURL: github.String("https://github.com/octorun/octorun/runs/1"),
+			},
+			Repo: &github.Repository{FullName: github.String("octorun/octorun")},
+		},
+		WorkflowName: "CI",
+		HeadBranch:   "main",
+	}
+
 	tests := []struct {
 		name      string
 		fields    fields
 		runnerKey client.ObjectKey
+		wantJob   *octorunv1.RunnerJobStatus
 		wantErr   bool
 	}{
 		{
@@ -125,7 +144,16 @@ func TestGithubHook_triggerRunnerReconciliation(t *testing.T) {
 				Client: fakec,
 			},
 			runnerKey: types.NamespacedName{Namespace: "default", Name: "foo"},
-			wantErr:   false,
+			wantJob: &octorunv1.RunnerJobStatus{
+				ID:           1,
+				Name:         "build",
+				RunID:        2,
+				WorkflowName: "CI",
+				Repository:   "octorun/octorun",
+				HeadBranch:   "main",
+				URL:          "https://github.com/octorun/octorun/runs/1",
+			},
+			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
@@ -134,8 +162,100 @@ func TestGithubHook_triggerRunnerReconciliation(t *testing.T) {
 				Client: tt.fields.Client,
 			}
 			ctx := context.Background()
-			if err := gh.triggerRunnerReconciliation(ctx, tt.runnerKey); (err != nil) != tt.wantErr {
+			if err := gh.triggerRunnerReconciliation(ctx, tt.runnerKey, event); (err != nil) != tt.wantErr {
 				t.Errorf("GithubHook.triggerRunnerReconciliation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantJob == nil {
+				return
+			}
+
+			runner := &octorunv1.Runner{}
+			if err := tt.fields.Client.Get(ctx, tt.runnerKey, runner); err != nil {
+				t.Errorf("unexpected Get error: %v", err)
+				return
+			}
+
+			if got := annotations.Job(runner); !reflect.DeepEqual(got, tt.wantJob) {
+				t.Errorf("Runner job = %v, want %v", got, tt.wantJob)
+			}
+		})
+	}
+}
+
+func TestGithubHook_markRunnerJobCompleted(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := octorunv1.AddToScheme(scheme); err != nil {
+		t.Errorf("unexpected AddToScheme error: %v", err)
+	}
+
+	completedAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	event := &workflowJobEvent{
+		WorkflowJobEvent: &github.WorkflowJobEvent{
+			Action: github.String("completed"),
+			WorkflowJob: &github.WorkflowJob{
+				Conclusion:  github.String("success"),
+				CompletedAt: &github.Timestamp{Time: completedAt},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		runnerKey client.ObjectKey
+		wantFound bool
+		wantErr   bool
+	}{
+		{
+			name:      "runner_not_found_should_ignore_error",
+			runnerKey: types.NamespacedName{Namespace: "default", Name: "barr"},
+			wantFound: false,
+			wantErr:   false,
+		},
+		{
+			name:      "runner_found",
+			runnerKey: types.NamespacedName{Namespace: "default", Name: "foo"},
+			wantFound: true,
+			wantErr:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakec := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(&octorunv1.Runner{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "foo",
+						Namespace: "default",
+					},
+				}).Build()
+
+			gh := &GithubHook{
+				Client: fakec,
+			}
+			ctx := context.Background()
+			if err := gh.markRunnerJobCompleted(ctx, tt.runnerKey, event); (err != nil) != tt.wantErr {
+				t.Errorf("GithubHook.markRunnerJobCompleted() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantFound {
+				return
+			}
+
+			runner := &octorunv1.Runner{}
+			if err := fakec.Get(ctx, tt.runnerKey, runner); err != nil {
+				t.Errorf("unexpected Get error: %v", err)
+				return
+			}
+
+			if got, ok := annotations.JobCompletedAt(runner); !ok || !got.Equal(completedAt) {
+				t.Errorf("Runner job completed at = %v, want %v", got, completedAt)
+			}
+
+			if got := annotations.JobConclusion(runner); got != "success" {
+				t.Errorf("Runner job conclusion = %v, want %v", got, "success")
 			}
 		})
 	}
@@ -147,7 +267,7 @@ func TestGithubHook_processWorkflowJobEvent(t *testing.T) {
 	}
 	type args struct {
 		ctx   context.Context
-		event *github.WorkflowJobEvent
+		event *workflowJobEvent
 	}
 	tests := []struct {
 		name   string
@@ -165,3 +285,389 @@ func TestGithubHook_processWorkflowJobEvent(t *testing.T) {
 		})
 	}
 }
+
+func Test_eventRunnerURLs(t *testing.T) {
+	eventFn := func(ownerType string, enterprise *github.Enterprise) *workflowJobEvent {
+		return &workflowJobEvent{
+			WorkflowJobEvent: &github.WorkflowJobEvent{
+				Repo: &github.Repository{
+					HTMLURL: pointer.String("https://github.com/octorun/octorun"),
+					Owner: &github.User{
+						Type:    pointer.String(ownerType),
+						HTMLURL: pointer.String("https://github.com/octorun"),
+					},
+				},
+			},
+			Enterprise: enterprise,
+		}
+	}
+
+	tests := []struct {
+		name  string
+		event *workflowJobEvent
+		want  []string
+	}{
+		{
+			name:  "repo_owned_by_user",
+			event: eventFn("User", nil),
+			want:  []string{"https://github.com/octorun/octorun"},
+		},
+		{
+			name:  "repo_owned_by_organization",
+			event: eventFn("Organization", nil),
+			want:  []string{"https://github.com/octorun", "https://github.com/octorun/octorun"},
+		},
+		{
+			name: "repo_owned_by_enterprise_organization",
+			event: eventFn("Organization", &github.Enterprise{
+				HTMLURL: pointer.String("https://github.com/enterprises/octorun"),
+			}),
+			want: []string{"https://github.com/enterprises/octorun", "https://github.com/octorun", "https://github.com/octorun/octorun"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eventRunnerURLs(tt.event); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eventRunnerURLs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGithubHook_findRunnerSet(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := octorunv1.AddToScheme(scheme); err != nil {
+		t.Errorf("unexpected AddToScheme error: %v", err)
+	}
+
+	fakec := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&octorunv1.RunnerSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "gpu",
+					Namespace: "default",
+				},
+				Spec: octorunv1.RunnerSetSpec{
+					Template: octorunv1.RunnerTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"octorun.github.io/gpu": "true",
+							},
+						},
+						Spec: octorunv1.RunnerSpec{
+							URL: "https://github.com/octorun",
+						},
+					},
+				},
+			},
+			&octorunv1.RunnerSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "other-org",
+					Namespace: "default",
+				},
+				Spec: octorunv1.RunnerSetSpec{
+					Template: octorunv1.RunnerTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"octorun.github.io/gpu": "false",
+							},
+						},
+						Spec: octorunv1.RunnerSpec{
+							URL: "https://github.com/other",
+						},
+					},
+				},
+			},
+		).Build()
+
+	eventFn := func(labels ...string) *workflowJobEvent {
+		return &workflowJobEvent{WorkflowJobEvent: &github.WorkflowJobEvent{
+			WorkflowJob: &github.WorkflowJob{Labels: labels},
+			Repo: &github.Repository{
+				HTMLURL: pointer.String("https://github.com/octorun/octorun"),
+				Owner: &github.User{
+					Type:    pointer.String("Organization"),
+					HTMLURL: pointer.String("https://github.com/octorun"),
+				},
+			},
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		event   *workflowJobEvent
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "job_labels_match_runnerset",
+			event:   eventFn("self-hosted", "gpu=true"),
+			want:    "gpu",
+			wantErr: false,
+		},
+		{
+			name:    "job_labels_not_match_runnerset",
+			event:   eventFn("self-hosted", "gpu=false"),
+			want:    "",
+			wantErr: false,
+		},
+		{
+			name:    "job_labels_not_all_matched",
+			event:   eventFn("self-hosted", "gpu=true", "large"),
+			want:    "",
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gh := &GithubHook{
+				Client: fakec,
+			}
+			got, err := gh.findRunnerSet(context.Background(), tt.event, false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GithubHook.findRunnerSet() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var gotName string
+			if got != nil {
+				gotName = got.Name
+			}
+			if gotName != tt.want {
+				t.Errorf("GithubHook.findRunnerSet() = %v, want %v", gotName, tt.want)
+			}
+		})
+	}
+}
+
+func TestGithubHook_wakeUpRunnerSet(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := octorunv1.AddToScheme(scheme); err != nil {
+		t.Errorf("unexpected AddToScheme error: %v", err)
+	}
+
+	runnersetFn := func(name string, onDemand bool) *octorunv1.RunnerSet {
+		return &octorunv1.RunnerSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				UID:       types.UID(name),
+			},
+			Spec: octorunv1.RunnerSetSpec{
+				Runners:  pointer.Int32(0),
+				OnDemand: onDemand,
+				Template: octorunv1.RunnerTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							"octorun.github.io/runnerset": name,
+						},
+					},
+					Spec: octorunv1.RunnerSpec{
+						URL: "https://github.com/octorun/octorun",
+					},
+				},
+			},
+		}
+	}
+
+	runnerFn := func(name string, phase octorunv1.RunnerPhase, onDemandJobID int64) *octorunv1.Runner {
+		runner := &octorunv1.Runner{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Status: octorunv1.RunnerStatus{Phase: phase},
+		}
+
+		if onDemandJobID != 0 {
+			annotations.AnnotateOnDemand(runner)
+			annotations.AnnotateOnDemandJobID(runner, onDemandJobID)
+		}
+
+		if err := ctrl.SetControllerReference(runnersetFn("nightly", true), runner, scheme); err != nil {
+			t.Errorf("unexpected SetControllerReference error: %v", err)
+		}
+
+		return runner
+	}
+
+	jobEventFn := func(id int64, labels ...string) *workflowJobEvent {
+		return &workflowJobEvent{WorkflowJobEvent: &github.WorkflowJobEvent{
+			Action:      pointer.String("queued"),
+			WorkflowJob: &github.WorkflowJob{ID: pointer.Int64(id), Labels: labels},
+			Repo: &github.Repository{
+				HTMLURL: pointer.String("https://github.com/octorun/octorun"),
+				Owner: &github.User{
+					Type:    pointer.String("User"),
+					HTMLURL: pointer.String("https://github.com/octorun"),
+				},
+			},
+		}}
+	}
+
+	eventFn := func(labels ...string) *workflowJobEvent {
+		return jobEventFn(1, labels...)
+	}
+
+	tests := []struct {
+		name          string
+		event         *workflowJobEvent
+		deliveries    int
+		queuedBefore  []*workflowJobEvent
+		objects       []client.Object
+		wantRunnerSet string
+		wantCreated   int
+	}{
+		{
+			name:          "job_labels_match_on_demand_runnerset",
+			event:         eventFn("self-hosted", "runnerset=nightly"),
+			wantRunnerSet: "nightly",
+			wantCreated:   1,
+		},
+		{
+			name:          "job_labels_match_runnerset_without_on_demand",
+			event:         eventFn("self-hosted", "runnerset=regular"),
+			wantRunnerSet: "",
+		},
+		{
+			name:          "job_labels_not_match_any_runnerset",
+			event:         eventFn("self-hosted", "runnerset=foo"),
+			wantRunnerSet: "",
+		},
+		{
+			name:          "queued_event_delivered_several_times",
+			event:         eventFn("self-hosted", "runnerset=nightly"),
+			deliveries:    3,
+			wantRunnerSet: "nightly",
+			wantCreated:   1,
+		},
+		{
+			name:          "on_demand_runner_already_created_for_job",
+			event:         eventFn("self-hosted", "runnerset=nightly"),
+			objects:       []client.Object{runnerFn("nightly-job", octorunv1.RunnerPendingPhase, 1)},
+			wantRunnerSet: "nightly",
+			wantCreated:   0,
+		},
+		{
+			name:          "on_demand_runner_created_for_another_job",
+			event:         eventFn("self-hosted", "runnerset=nightly"),
+			objects:       []client.Object{runnerFn("nightly-other-job", octorunv1.RunnerIdlePhase, 2)},
+			wantRunnerSet: "nightly",
+			wantCreated:   1,
+		},
+		{
+			name:          "runnerset_has_idle_runner",
+			event:         eventFn("self-hosted", "runnerset=nightly"),
+			objects:       []client.Object{runnerFn("nightly-idle", octorunv1.RunnerIdlePhase, 0)},
+			wantRunnerSet: "nightly",
+			wantCreated:   0,
+		},
+		{
+			name:          "two_jobs_queued_with_one_idle_runner",
+			event:         eventFn("self-hosted", "runnerset=nightly"),
+			queuedBefore:  []*workflowJobEvent{jobEventFn(2, "self-hosted", "runnerset=nightly")},
+			objects:       []client.Object{runnerFn("nightly-idle", octorunv1.RunnerIdlePhase, 0)},
+			wantRunnerSet: "nightly",
+			wantCreated:   1,
+		},
+		{
+			name:  "three_jobs_queued_with_two_idle_runners",
+			event: eventFn("self-hosted", "runnerset=nightly"),
+			queuedBefore: []*workflowJobEvent{
+				jobEventFn(2, "self-hosted", "runnerset=nightly"),
+				jobEventFn(3, "self-hosted", "runnerset=nightly"),
+			},
+			objects: []client.Object{
+				runnerFn("nightly-idle-a", octorunv1.RunnerIdlePhase, 0),
+				runnerFn("nightly-idle-b", octorunv1.RunnerIdlePhase, 0),
+			},
+			wantRunnerSet: "nightly",
+			wantCreated:   1,
+		},
+		{
+			name:         "queued_job_has_on_demand_runner",
+			event:        eventFn("self-hosted", "runnerset=nightly"),
+			queuedBefore: []*workflowJobEvent{jobEventFn(2, "self-hosted", "runnerset=nightly")},
+			objects: []client.Object{
+				runnerFn("nightly-idle", octorunv1.RunnerIdlePhase, 0),
+				runnerFn("nightly-job", octorunv1.RunnerPendingPhase, 2),
+			},
+			wantRunnerSet: "nightly",
+			wantCreated:   0,
+		},
+		{
+			name:          "runnerset_has_active_runner",
+			event:         eventFn("self-hosted", "runnerset=nightly"),
+			objects:       []client.Object{runnerFn("nightly-active", octorunv1.RunnerActivePhase, 0)},
+			wantRunnerSet: "nightly",
+			wantCreated:   1,
+		},
+		{
+			name:  "runnerset_scaled_by_runnerautoscaler",
+			event: eventFn("self-hosted", "runnerset=nightly"),
+			objects: []client.Object{&octorunv1.RunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default"},
+				Spec: octorunv1.RunnerAutoscalerSpec{
+					ScaleTargetRef: octorunv1.RunnerAutoscalerTargetRef{Name: "nightly"},
+					MaxRunners:     5,
+				},
+			}},
+			wantRunnerSet: "nightly",
+			wantCreated:   0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakec := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(runnersetFn("nightly", true), runnersetFn("regular", false)).
+				WithObjects(tt.objects...).
+				Build()
+
+			gh := &GithubHook{
+				Client: fakec,
+			}
+
+			ctx := context.Background()
+			deliveries := tt.deliveries
+			if deliveries == 0 {
+				deliveries = 1
+			}
+
+			for _, event := range tt.queuedBefore {
+				gh.processWorkflowJobEvent(ctx, event)
+			}
+
+			for i := 0; i < deliveries; i++ {
+				gh.processWorkflowJobEvent(ctx, tt.event)
+			}
+
+			runnerList := &octorunv1.RunnerList{}
+			if err := fakec.List(ctx, runnerList); err != nil {
+				t.Errorf("unexpected List error: %v", err)
+				return
+			}
+
+			var created []octorunv1.Runner
+			for _, runner := range runnerList.Items {
+				if runner.GenerateName != "" {
+					created = append(created, runner)
+				}
+			}
+
+			if len(created) != tt.wantCreated {
+				t.Errorf("GithubHook.wakeUpRunnerSet() created %v runners, want %v", len(created), tt.wantCreated)
+				return
+			}
+
+			for _, runner := range created {
+				if owner := metav1.GetControllerOf(&runner); owner == nil || owner.Name != tt.wantRunnerSet {
+					t.Errorf("GithubHook.wakeUpRunnerSet() runner controller = %v, want %v", owner, tt.wantRunnerSet)
+				}
+				if !annotations.IsOnDemand(&runner) || annotations.OnDemandJobID(&runner) != tt.event.WorkflowJob.GetID() {
+					t.Errorf("GithubHook.wakeUpRunnerSet() runner annotations = %v, want on-demand annotations", runner.Annotations)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+	"octorun.github.io/octorun/metrics"
+)
+
+// workflowJobName returns the name of the WorkflowJob recording the workflow job from given event.
+func workflowJobName(event *workflowJobEvent) string {
+	return strconv.FormatInt(event.WorkflowJob.GetID(), 10)
+}
+
+// newWorkflowJob returns a new WorkflowJob in given namespace recording the workflow job from given event.
+// The WorkflowJob is labeled with the RunnerSet label of given runner labels if any.
+func newWorkflowJob(event *workflowJobEvent, namespace string, runnerLabels map[string]string) *octorunv1.WorkflowJob {
+	workflowJob := &octorunv1.WorkflowJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      workflowJobName(event),
+			Namespace: namespace,
+			Labels: map[string]string{
+				octorunv1.LabelWorkflowJobID: workflowJobName(event),
+			},
+		},
+		Spec: octorunv1.WorkflowJobSpec{
+			ID:           event.WorkflowJob.GetID(),
+			Name:         event.WorkflowJob.GetName(),
+			RunID:        event.WorkflowJob.GetRunID(),
+			WorkflowName: event.WorkflowName,
+			Repository:   event.Repo.GetFullName(),
+			HeadBranch:   event.HeadBranch,
+			URL:          event.WorkflowJob.GetHTMLURL(),
+			Labels:       event.WorkflowJob.Labels,
+		},
+	}
+
+	if runnerset, ok := runnerLabels[octorunv1.LabelRunnerSetName]; ok {
+		workflowJob.Labels[octorunv1.LabelRunnerSetName] = runnerset
+	}
+
+	return workflowJob
+}
+
+// eventTime returns the given Github timestamp of the event or the current time if it is not set.
+func eventTime(ts time.Time) *metav1.Time {
+	if ts.IsZero() {
+		now := metav1.Now()
+		return &now
+	}
+
+	t := metav1.NewTime(ts)
+	return &t
+}
+
+// updateWorkflowJobStatus updates the WorkflowJob status from given event. The phase never goes backward
+// since Github does not guarantee the order in which the events are delivered.
+func updateWorkflowJobStatus(workflowJob *octorunv1.WorkflowJob, event *workflowJobEvent, runnerPod *corev1.Pod) {
+	switch event.GetAction() {
+	case "queued":
+		if workflowJob.Status.Phase == "" {
+			workflowJob.Status.Phase = octorunv1.WorkflowJobQueuedPhase
+		}
+
+		// The started_at of a queued job is not when it starts running, use the job creation
+		// time or the time the queued event is received.
+		if workflowJob.Status.QueuedTime == nil {
+			workflowJob.Status.QueuedTime = eventTime(event.CreatedAt)
+		}
+	case "in_progress":
+		if workflowJob.Status.Phase != octorunv1.WorkflowJobCompletedPhase {
+			workflowJob.Status.Phase = octorunv1.WorkflowJobInProgressPhase
+		}
+
+		if workflowJob.Status.StartTime == nil {
+			workflowJob.Status.StartTime = eventTime(event.WorkflowJob.GetStartedAt().Time)
+		}
+	case "completed":
+		workflowJob.Status.Phase = octorunv1.WorkflowJobCompletedPhase
+		workflowJob.Status.Conclusion = event.WorkflowJob.GetConclusion()
+		if workflowJob.Status.CompletionTime == nil {
+			workflowJob.Status.CompletionTime = eventTime(event.WorkflowJob.GetCompletedAt().Time)
+		}
+	}
+
+	if runnerName := event.WorkflowJob.GetRunnerName(); runnerName != "" {
+		workflowJob.Status.RunnerName = runnerName
+	}
+
+	// The runner pod of an ephemeral runner may have gone by the time the job has completed,
+	// keep the pod and node recorded from the previous events then.
+	if runnerPod != nil {
+		workflowJob.Status.PodName = runnerPod.Name
+		if runnerPod.Spec.NodeName != "" {
+			workflowJob.Status.NodeName = runnerPod.Spec.NodeName
+		}
+	}
+}
+
+// listWorkflowJobs lists the WorkflowJobs recording the workflow job from given event in every namespace.
+func (gh *GithubHook) listWorkflowJobs(ctx context.Context, event *workflowJobEvent) ([]octorunv1.WorkflowJob, error) {
+	workflowJobList := &octorunv1.WorkflowJobList{}
+	if err := gh.List(ctx, workflowJobList, client.MatchingLabels{octorunv1.LabelWorkflowJobID: workflowJobName(event)}); err != nil {
+		return nil, err
+	}
+
+	return workflowJobList.Items, nil
+}
+
+// getRunnerPod returns the pod of given runner or nil if it has gone.
+func (gh *GithubHook) getRunnerPod(ctx context.Context, runner *octorunv1.Runner) (*corev1.Pod, error) {
+	runnerPod := &corev1.Pod{}
+	if err := gh.Get(ctx, client.ObjectKeyFromObject(runner), runnerPod); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	return runnerPod, nil
+}
+
+// recordWorkflowJob creates or updates the WorkflowJob recording the workflow job from given event in given namespace.
+// The runner labels and pod are recorded if the runner is known. WorkflowJobs of the same workflow job in other
+// namespaces are deleted, eg: the job was queued for a RunnerSet in another namespace than the runner picked it up.
+func (gh *GithubHook) recordWorkflowJob(ctx context.Context, event *workflowJobEvent, namespace string, runnerLabels map[string]string, runnerPod *corev1.Pod) error {
+	log := ctrl.LoggerFrom(ctx)
+	// The WorkflowJob created by the previous event may not be in the cache yet.
+	isRetriable := func(err error) bool { return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) }
+	return retry.OnError(retry.DefaultBackoff, isRetriable, func() error {
+		workflowJobs, err := gh.listWorkflowJobs(ctx, event)
+		if err != nil {
+			return err
+		}
+
+		var workflowJob *octorunv1.WorkflowJob
+		var queuedTime *metav1.Time
+		for i := range workflowJobs {
+			if workflowJobs[i].Namespace == namespace {
+				workflowJob = &workflowJobs[i]
+				continue
+			}
+
+			queuedTime = workflowJobs[i].Status.QueuedTime
+			if err := gh.Delete(ctx, &workflowJobs[i]); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+
+		if workflowJob == nil {
+			workflowJob = newWorkflowJob(event, namespace, runnerLabels)
+			log.V(1).Info("creating WorkflowJob", "workflowjob", client.ObjectKeyFromObject(workflowJob).String())
+			if err := gh.Create(ctx, workflowJob); err != nil {
+				return err
+			}
+
+			workflowJob.Status.QueuedTime = queuedTime
+		}
+
+		status := workflowJob.Status.DeepCopy()
+		updateWorkflowJobStatus(workflowJob, event, runnerPod)
+		if reflect.DeepEqual(status, &workflowJob.Status) {
+			return nil
+		}
+
+		if err := gh.Status().Update(ctx, workflowJob); err != nil {
+			return err
+		}
+
+		observeWorkflowJob(workflowJob, status)
+		return nil
+	})
+}
+
+// queueDuration returns the time the job of given completed WorkflowJob status waited until a runner picked it up.
+// It returns false if the job has not completed or one of the times is unknown, eg: the queued event was not delivered.
+func queueDuration(status *octorunv1.WorkflowJobStatus) (time.Duration, bool) {
+	if status.Phase != octorunv1.WorkflowJobCompletedPhase || status.QueuedTime == nil || status.StartTime == nil || status.StartTime.Before(status.QueuedTime) {
+		return 0, false
+	}
+
+	return status.StartTime.Sub(status.QueuedTime.Time), true
+}
+
+// runDuration returns the time the job of given completed WorkflowJob status ran on a runner.
+// It returns false if the job has not completed or one of the times is unknown, eg: the job was cancelled while queued.
+func runDuration(status *octorunv1.WorkflowJobStatus) (time.Duration, bool) {
+	if status.Phase != octorunv1.WorkflowJobCompletedPhase || status.StartTime == nil || status.CompletionTime == nil || status.CompletionTime.Before(status.StartTime) {
+		return 0, false
+	}
+
+	return status.CompletionTime.Sub(status.StartTime.Time), true
+}
+
+// observeWorkflowJob observes the queue and run durations of given WorkflowJob once they are known from its status
+// and were not known from its last status. They are observed once the job has completed so that they are labeled with
+// its conclusion, the run duration of a job whose in_progress event is delivered after completed event is observed then.
+func observeWorkflowJob(workflowJob *octorunv1.WorkflowJob, lastStatus *octorunv1.WorkflowJobStatus) {
+	labels := []string{
+		workflowJob.Namespace,
+		workflowJob.Labels[octorunv1.LabelRunnerSetName],
+		workflowJob.Spec.Repository,
+		workflowJob.Status.Conclusion,
+	}
+
+	if d, ok := queueDuration(&workflowJob.Status); ok {
+		if _, observed := queueDuration(lastStatus); !observed {
+			metrics.WorkflowJobQueueDuration.WithLabelValues(labels...).Observe(d.Seconds())
+		}
+	}
+
+	if d, ok := runDuration(&workflowJob.Status); ok {
+		if _, observed := runDuration(lastStatus); !observed {
+			metrics.WorkflowJobDuration.WithLabelValues(labels...).Observe(d.Seconds())
+		}
+	}
+}
+
+// forgetWorkflowJob deletes the WorkflowJobs recording the workflow job from given event,
+// eg: the job was queued for a RunnerSet but a runner not controlled by octorun picked it up.
+func (gh *GithubHook) forgetWorkflowJob(ctx context.Context, event *workflowJobEvent) error {
+	workflowJobs, err := gh.listWorkflowJobs(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	for i := range workflowJobs {
+		if err := gh.Delete(ctx, &workflowJobs[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// processWorkflowJob records the workflow job from given event as a WorkflowJob. A queued workflow job
+// is recorded in the namespace of the RunnerSet able to run it, then in the namespace of the Runner that
+// picked it up. The given runner is nil if the workflow job is not picked up by a Runner.
+func (gh *GithubHook) processWorkflowJob(ctx context.Context, event *workflowJobEvent, runner *octorunv1.Runner) {
+	log := ctrl.LoggerFrom(ctx)
+	switch {
+	case runner != nil:
+		runnerPod, err := gh.getRunnerPod(ctx, runner)
+		if err != nil {
+			log.Error(err, "unable to get runner pod")
+		}
+
+		if err := gh.recordWorkflowJob(ctx, event, runner.Namespace, runner.Labels, runnerPod); err != nil {
+			log.Error(err, "failed recording WorkflowJob")
+		}
+	case event.GetAction() == "queued":
+		runnerset, err := gh.findRunnerSet(ctx, event, false)
+		if err != nil {
+			log.Error(err, "unable to find RunnerSet")
+			return
+		}
+
+		if runnerset == nil {
+			return
+		}
+
+		if err := gh.recordWorkflowJob(ctx, event, runnerset.Namespace, runnerset.Spec.Template.Labels, nil); err != nil {
+			log.Error(err, "failed recording WorkflowJob")
+		}
+	case event.WorkflowJob.GetRunnerName() == "":
+		// The job has been cancelled before a runner picked it up. Complete the queued WorkflowJob if any.
+		workflowJobs, err := gh.listWorkflowJobs(ctx, event)
+		if err != nil {
+			log.Error(err, "unable to list WorkflowJobs")
+			return
+		}
+
+		if len(workflowJobs) == 0 {
+			return
+		}
+
+		if err := gh.recordWorkflowJob(ctx, event, workflowJobs[0].Namespace, nil, nil); err != nil {
+			log.Error(err, "failed recording WorkflowJob")
+		}
+	default:
+		if err := gh.forgetWorkflowJob(ctx, event); err != nil {
+			log.Error(err, "failed deleting WorkflowJob")
+		}
+	}
+}
@@ -0,0 +1,348 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v41/github"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+	"octorun.github.io/octorun/metrics"
+)
+
+func TestGithubHook_processWorkflowJob(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+
+	startedAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	createdAt := startedAt.Add(-30 * time.Second)
+	completedAt := startedAt.Add(time.Minute)
+	runnerset := &octorunv1.RunnerSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "runnersets"},
+		Spec: octorunv1.RunnerSetSpec{
+			Runners: pointer.Int32(1),
+			Template: octorunv1.RunnerTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{octorunv1.LabelRunnerSetName: "regular"},
+				},
+				Spec: octorunv1.RunnerSpec{URL: "https://github.com/octorun/octorun"},
+			},
+		},
+	}
+
+	runner := &octorunv1.Runner{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular-abc",
+			Namespace: "runners",
+			Labels:    map[string]string{octorunv1.LabelRunnerSetName: "regular"},
+		},
+		Spec: octorunv1.RunnerSpec{URL: "https://github.com/octorun/octorun"},
+	}
+
+	runnerPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular-abc", Namespace: "runners"},
+		Spec:       corev1.PodSpec{NodeName: "node-1"},
+	}
+
+	eventFn := func(action, runnerName, conclusion string, labels ...string) *workflowJobEvent {
+		workflowJob := &github.WorkflowJob{
+			ID:        github.Int64(1),
+			Name:      github.String("build"),
+			Labels:    labels,
+			StartedAt: &github.Timestamp{Time: startedAt},
+		}
+
+		if runnerName != "" {
+			workflowJob.RunnerName = github.String(runnerName)
+		}
+
+		if action == "completed" {
+			workflowJob.Conclusion = github.String(conclusion)
+			workflowJob.CompletedAt = &github.Timestamp{Time: completedAt}
+		}
+
+		return &workflowJobEvent{
+			WorkflowJobEvent: &github.WorkflowJobEvent{
+				Action:      github.String(action),
+				WorkflowJob: workflowJob,
+				Repo: &github.Repository{
+					FullName: github.String("octorun/octorun"),
+					HTMLURL:  github.String("https://github.com/octorun/octorun"),
+					Owner: &github.User{
+						Type:    github.String("User"),
+						HTMLURL: github.String("https://github.com/octorun"),
+					},
+				},
+			},
+			WorkflowName: "CI",
+			HeadBranch:   "main",
+			CreatedAt:    createdAt,
+		}
+	}
+
+	type step struct {
+		event  *workflowJobEvent
+		runner *octorunv1.Runner
+	}
+
+	tests := []struct {
+		name             string
+		steps            []step
+		want             *octorunv1.WorkflowJobStatus
+		wantNs           string
+		wantLabel        string
+		wantQueueSamples int
+		wantQueueSeconds float64
+		wantRunSamples   int
+	}{
+		{
+			name: "job_queued_for_runnerset",
+			steps: []step{
+				{event: eventFn("queued", "", "", "self-hosted", "runnerset=regular")},
+			},
+			want: &octorunv1.WorkflowJobStatus{
+				Phase:      octorunv1.WorkflowJobQueuedPhase,
+				QueuedTime: &metav1.Time{Time: createdAt},
+			},
+			wantNs:    "runnersets",
+			wantLabel: "regular",
+		},
+		{
+			name: "job_queued_not_matching_any_runnerset",
+			steps: []step{
+				{event: eventFn("queued", "", "", "self-hosted", "runnerset=foo")},
+			},
+			want: nil,
+		},
+		{
+			name: "job_picked_up_by_runner_in_another_namespace",
+			steps: []step{
+				{event: eventFn("queued", "", "", "self-hosted", "runnerset=regular")},
+				{event: eventFn("in_progress", "regular-abc", "", "self-hosted", "runnerset=regular"), runner: runner},
+			},
+			want: &octorunv1.WorkflowJobStatus{
+				Phase:      octorunv1.WorkflowJobInProgressPhase,
+				QueuedTime: &metav1.Time{Time: createdAt},
+				StartTime:  &metav1.Time{Time: startedAt},
+				RunnerName: "regular-abc",
+				PodName:    "regular-abc",
+				NodeName:   "node-1",
+			},
+			wantNs:    "runners",
+			wantLabel: "regular",
+		},
+		{
+			name: "job_completed",
+			steps: []step{
+				{event: eventFn("queued", "", "", "self-hosted", "runnerset=regular")},
+				{event: eventFn("in_progress", "regular-abc", "", "self-hosted", "runnerset=regular"), runner: runner},
+				{event: eventFn("completed", "regular-abc", "success", "self-hosted", "runnerset=regular"), runner: runner},
+				{event: eventFn("completed", "regular-abc", "success", "self-hosted", "runnerset=regular"), runner: runner},
+			},
+			want: &octorunv1.WorkflowJobStatus{
+				Phase:          octorunv1.WorkflowJobCompletedPhase,
+				Conclusion:     "success",
+				QueuedTime:     &metav1.Time{Time: createdAt},
+				StartTime:      &metav1.Time{Time: startedAt},
+				CompletionTime: &metav1.Time{Time: completedAt},
+				RunnerName:     "regular-abc",
+				PodName:        "regular-abc",
+				NodeName:       "node-1",
+			},
+			wantNs:           "runners",
+			wantLabel:        "regular",
+			wantQueueSamples: 1,
+			wantQueueSeconds: 30,
+			wantRunSamples:   1,
+		},
+		{
+			name: "job_completed_before_in_progress_event",
+			steps: []step{
+				{event: eventFn("completed", "regular-abc", "success", "self-hosted"), runner: runner},
+				{event: eventFn("in_progress", "regular-abc", "", "self-hosted"), runner: runner},
+			},
+			want: &octorunv1.WorkflowJobStatus{
+				Phase:          octorunv1.WorkflowJobCompletedPhase,
+				Conclusion:     "success",
+				StartTime:      &metav1.Time{Time: startedAt},
+				CompletionTime: &metav1.Time{Time: completedAt},
+				RunnerName:     "regular-abc",
+				PodName:        "regular-abc",
+				NodeName:       "node-1",
+			},
+			wantNs:           "runners",
+			wantLabel:        "regular",
+			wantQueueSamples: 0,
+			wantRunSamples:   1,
+		},
+		{
+			name: "job_cancelled_before_picked_up",
+			steps: []step{
+				{event: eventFn("queued", "", "", "self-hosted", "runnerset=regular")},
+				{event: eventFn("completed", "", "cancelled", "self-hosted", "runnerset=regular")},
+			},
+			want: &octorunv1.WorkflowJobStatus{
+				Phase:          octorunv1.WorkflowJobCompletedPhase,
+				Conclusion:     "cancelled",
+				QueuedTime:     &metav1.Time{Time: createdAt},
+				CompletionTime: &metav1.Time{Time: completedAt},
+			},
+			wantNs:    "runnersets",
+			wantLabel: "regular",
+		},
+		{
+			name: "job_picked_up_by_runner_not_controlled_by_octorun",
+			steps: []step{
+				{event: eventFn("queued", "", "", "self-hosted", "runnerset=regular")},
+				{event: eventFn("in_progress", "someone-else", "", "self-hosted", "runnerset=regular")},
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakec := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(runnerset.DeepCopy(), runner.DeepCopy(), runnerPod.DeepCopy()).
+				Build()
+
+			gh := &GithubHook{
+				Client: fakec,
+			}
+
+			metrics.WorkflowJobQueueDuration.Reset()
+			metrics.WorkflowJobDuration.Reset()
+			ctx := context.Background()
+			for _, s := range tt.steps {
+				gh.processWorkflowJob(ctx, s.event, s.runner)
+			}
+
+			if got := histogramSamples(metrics.WorkflowJobQueueDuration); got != tt.wantQueueSamples {
+				t.Errorf("GithubHook.processWorkflowJob() queue duration samples = %v, want %v", got, tt.wantQueueSamples)
+			}
+			if got := histogramSum(metrics.WorkflowJobQueueDuration); got != tt.wantQueueSeconds {
+				t.Errorf("GithubHook.processWorkflowJob() queue duration sum = %v, want %v", got, tt.wantQueueSeconds)
+			}
+			if got := histogramSamples(metrics.WorkflowJobDuration); got != tt.wantRunSamples {
+				t.Errorf("GithubHook.processWorkflowJob() run duration samples = %v, want %v", got, tt.wantRunSamples)
+			}
+
+			workflowJobList := &octorunv1.WorkflowJobList{}
+			if err := fakec.List(ctx, workflowJobList); err != nil {
+				t.Errorf("unexpected List error: %v", err)
+				return
+			}
+
+			if tt.want == nil {
+				if len(workflowJobList.Items) != 0 {
+					t.Errorf("GithubHook.processWorkflowJob() recorded %v WorkflowJobs, want 0", len(workflowJobList.Items))
+				}
+				return
+			}
+
+			if len(workflowJobList.Items) != 1 {
+				t.Errorf("GithubHook.processWorkflowJob() recorded %v WorkflowJobs, want 1", len(workflowJobList.Items))
+				return
+			}
+
+			got := workflowJobList.Items[0]
+			if got.Namespace != tt.wantNs {
+				t.Errorf("GithubHook.processWorkflowJob() namespace = %v, want %v", got.Namespace, tt.wantNs)
+			}
+			if got.Labels[octorunv1.LabelRunnerSetName] != tt.wantLabel {
+				t.Errorf("GithubHook.processWorkflowJob() runnerset label = %v, want %v", got.Labels[octorunv1.LabelRunnerSetName], tt.wantLabel)
+			}
+			if got.Spec.Repository != "octorun/octorun" || got.Spec.WorkflowName != "CI" || got.Spec.Name != "build" {
+				t.Errorf("GithubHook.processWorkflowJob() spec = %+v", got.Spec)
+			}
+			if !equalWorkflowJobStatus(&got.Status, tt.want) {
+				t.Errorf("GithubHook.processWorkflowJob() status = %+v, want %+v", got.Status, *tt.want)
+			}
+		})
+	}
+}
+
+// equalWorkflowJobStatus compares the WorkflowJob statuses, the times are compared
+// at the second precision they are serialized with.
+func equalWorkflowJobStatus(got, want *octorunv1.WorkflowJobStatus) bool {
+	equalTime := func(a, b *metav1.Time) bool {
+		if a == nil || b == nil {
+			return a == b
+		}
+
+		return a.Unix() == b.Unix()
+	}
+
+	return got.Phase == want.Phase &&
+		got.Conclusion == want.Conclusion &&
+		equalTime(got.QueuedTime, want.QueuedTime) &&
+		equalTime(got.StartTime, want.StartTime) &&
+		equalTime(got.CompletionTime, want.CompletionTime) &&
+		got.RunnerName == want.RunnerName &&
+		got.PodName == want.PodName &&
+		got.NodeName == want.NodeName
+}
+
+// histogramSamples returns the number of observations of given histogram over all its label values.
+func histogramSamples(h *prometheus.HistogramVec) int {
+	var samples int
+	ch := make(chan prometheus.Metric, 10)
+	go func() {
+		h.Collect(ch)
+		close(ch)
+	}()
+
+	for m := range ch {
+		pb := &dto.Metric{}
+		if err := m.Write(pb); err == nil {
+			samples += int(pb.GetHistogram().GetSampleCount())
+		}
+	}
+
+	return samples
+}
+
+func histogramSum(h *prometheus.HistogramVec) float64 {
+	var sum float64
+	ch := make(chan prometheus.Metric, 10)
+	go func() {
+		h.Collect(ch)
+		close(ch)
+	}()
+
+	for m := range ch {
+		pb := &dto.Metric{}
+		if err := m.Write(pb); err == nil {
+			sum += pb.GetHistogram().GetSampleSum()
+		}
+	}
+
+	return sum
+}
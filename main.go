@@ -19,16 +19,24 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/scale"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
@@ -62,6 +70,12 @@ type options struct {
 	probeAddr            string
 	metricsAddr          string
 	enableLeaderElection bool
+	runnerGCInterval     time.Duration
+	runnerGCDryRun       bool
+	runnerIDExecFallback bool
+	runnerPollInterval   time.Duration
+	workflowJobTTL       time.Duration
+	controllerID         string
 
 	Logger zap.Options
 	Github github.Options
@@ -73,6 +87,24 @@ func (o *options) bindFlags(fs *flag.FlagSet) {
 	fs.BoolVar(&o.enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	fs.DurationVar(&o.runnerGCInterval, "runner-gc-interval", 10*time.Minute,
+		"The interval to remove offline Github runners registered by octorun that are no longer tracked by any Runner. "+
+			"Set to 0 to disable the runner garbage collector.")
+	fs.BoolVar(&o.runnerGCDryRun, "runner-gc-dry-run", false,
+		"Only report the orphaned Github runners found by the runner garbage collector without removing them.")
+	fs.DurationVar(&o.runnerPollInterval, "runner-poll-interval", 30*time.Second,
+		"The interval to list the Github runners of every Runner URL that the Runner controller reads the runners status from. "+
+			"Set to 0 to get the status of every Runner from Github instead.")
+	fs.DurationVar(&o.workflowJobTTL, "workflow-job-ttl", 7*24*time.Hour,
+		"How long the WorkflowJobs recorded from the Github workflow_job events are retained after their job has completed. "+
+			"Set to 0 to retain the WorkflowJobs forever.")
+	fs.StringVar(&o.controllerID, "controller-id", "",
+		"The identity passed to every Github runner registered by the controller as the controller-id label. "+
+			"The runner garbage collector only removes the Github runners with this identity. "+
+			"Defaults to the UID of the kube-system namespace, set it when several controllers share a cluster.")
+	fs.BoolVar(&o.runnerIDExecFallback, "runner-id-exec-fallback", false,
+		"Read the runner id from the runner pod when the runner is not found on Github by its name. "+
+			"It requires pods/exec permission and jq in the runner image.")
 
 	o.Logger.Development = true
 	o.Logger.BindFlags(fs)
@@ -106,13 +138,43 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&controllers.RunnerReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Github:   gh.GetClient(),
-		Executor: pod.ExecutorManagedBy(mgr),
-		Recorder: mgr.GetEventRecorderFor(controllers.RunnerController),
-	}).SetupWithManager(ctx, mgr); err != nil {
+	controllerID := opts.controllerID
+	if controllerID == "" {
+		// The kube-system namespace UID identifies the cluster the controller is running in.
+		kubeSystem := &corev1.Namespace{}
+		if err := mgr.GetAPIReader().Get(ctx, client.ObjectKey{Name: metav1.NamespaceSystem}, kubeSystem); err != nil {
+			setupLog.Error(err, "unable to get the controller id from the kube-system namespace")
+			os.Exit(1)
+		}
+
+		controllerID = string(kubeSystem.UID)
+	}
+
+	credentials := github.NewCredentialClients(mgr.GetClient(), &opts.Github)
+	runnerReconciler := &controllers.RunnerReconciler{
+		Client:       mgr.GetClient(),
+		Scheme:       mgr.GetScheme(),
+		Github:       gh.GetClient(),
+		Credentials:  credentials,
+		Recorder:     mgr.GetEventRecorderFor(controllers.RunnerController),
+		ControllerID: controllerID,
+	}
+	if opts.runnerIDExecFallback {
+		runnerReconciler.Executor = pod.ExecutorManagedBy(mgr)
+	}
+	if opts.runnerPollInterval > 0 {
+		runnerReconciler.Poller = &controllers.RunnerPoller{
+			Client:      mgr.GetClient(),
+			Github:      gh.GetClient(),
+			Credentials: credentials,
+			Interval:    opts.runnerPollInterval,
+		}
+		if err = runnerReconciler.Poller.SetupWithManager(ctx, mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "RunnerPoller")
+			os.Exit(1)
+		}
+	}
+	if err = runnerReconciler.SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Runner")
 		os.Exit(1)
 	}
@@ -121,10 +183,63 @@ func main() {
 		Scheme:     mgr.GetScheme(),
 		Recorder:   mgr.GetEventRecorderFor(controllers.RunnerSetController),
 		Revisioner: new(controllers.RunnerSetRevisioner),
+		APIReader:  mgr.GetAPIReader(),
 	}).SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "RunnerSet")
 		os.Exit(1)
 	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to set up discovery client")
+		os.Exit(1)
+	}
+	scaleClient, err := scale.NewForConfig(mgr.GetConfig(), mgr.GetRESTMapper(), dynamic.LegacyAPIPathResolverFunc,
+		scale.NewDiscoveryScaleKindResolver(memory.NewMemCacheClient(discoveryClient)))
+	if err != nil {
+		setupLog.Error(err, "unable to set up scale client")
+		os.Exit(1)
+	}
+	if err = (&controllers.RunnerAutoscalerReconciler{
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		Recorder:    mgr.GetEventRecorderFor(controllers.RunnerAutoscalerController),
+		ScaleClient: scaleClient,
+	}).SetupWithManager(ctx, mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RunnerAutoscaler")
+		os.Exit(1)
+	}
+	if err = (&controllers.GitHubCredentialReconciler{
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		Recorder:    mgr.GetEventRecorderFor(controllers.GitHubCredentialController),
+		Credentials: credentials,
+	}).SetupWithManager(ctx, mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GitHubCredential")
+		os.Exit(1)
+	}
+	if opts.workflowJobTTL > 0 {
+		if err = (&controllers.WorkflowJobReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+			TTL:    opts.workflowJobTTL,
+		}).SetupWithManager(ctx, mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "WorkflowJob")
+			os.Exit(1)
+		}
+	}
+	if opts.runnerGCInterval > 0 {
+		if err = (&controllers.RunnerGCReconciler{
+			Client:       mgr.GetClient(),
+			Github:       gh.GetClient(),
+			Credentials:  credentials,
+			Interval:     opts.runnerGCInterval,
+			DryRun:       opts.runnerGCDryRun,
+			ControllerID: controllerID,
+		}).SetupWithManager(ctx, mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "RunnerGC")
+			os.Exit(1)
+		}
+	}
 
 	if err = (&webhooks.RunnerWebhook{
 		Client: mgr.GetAPIReader(),
@@ -138,6 +253,12 @@ func main() {
 		setupLog.Error(err, "unable to create webhook", "webhook", "RunnerSet")
 		os.Exit(1)
 	}
+	if err = (&webhooks.RunnerAutoscalerWebhook{
+		Client: mgr.GetAPIReader(),
+	}).SetupWithManager(ctx, mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "RunnerAutoscaler")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := (&hooks.GithubHook{
@@ -14,5 +14,6 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-// Package metrics contains statemetrics.Provider implementations.
+// Package metrics contains statemetrics.Provider implementations
+// and the metrics reported by the controllers.
 package metrics
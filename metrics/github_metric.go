@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const githubSubsystem = "github"
+
+var (
+	// GithubRateLimitLimit is the Github API rate limit quota of each client.
+	GithubRateLimitLimit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "octorun",
+		Subsystem: githubSubsystem,
+		Name:      "rate_limit_limit",
+		Help:      "The maximum number of Github API requests per hour of the client.",
+	}, []string{"client", "resource"})
+
+	// GithubRateLimitRemaining is the remaining Github API rate limit quota of each client.
+	GithubRateLimitRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "octorun",
+		Subsystem: githubSubsystem,
+		Name:      "rate_limit_remaining",
+		Help:      "The number of Github API requests remaining in the current rate limit window of the client.",
+	}, []string{"client", "resource"})
+
+	// GithubRateLimitReset is the time the Github API rate limit window of each client resets.
+	GithubRateLimitReset = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "octorun",
+		Subsystem: githubSubsystem,
+		Name:      "rate_limit_reset_timestamp_seconds",
+		Help:      "Unix timestamp at which the current Github API rate limit window of the client resets.",
+	}, []string{"client", "resource"})
+
+	// GithubRequests is the number of Github API requests sent by each client.
+	GithubRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "octorun",
+		Subsystem: githubSubsystem,
+		Name:      "requests_total",
+		Help:      "Number of Github API requests sent by the client, partitioned by method, endpoint and status code.",
+	}, []string{"client", "method", "endpoint", "code"})
+
+	// GithubRequestDuration is the latency of the Github API requests sent by each client.
+	GithubRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "octorun",
+		Subsystem: githubSubsystem,
+		Name:      "request_duration_seconds",
+		Help:      "Latency in seconds of the Github API requests sent by the client, partitioned by method and endpoint.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"client", "method", "endpoint"})
+
+	// GithubRequestErrors is the number of failed Github API requests sent by each client.
+	GithubRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "octorun",
+		Subsystem: githubSubsystem,
+		Name:      "request_errors_total",
+		Help:      "Number of failed Github API requests sent by the client, partitioned by method, endpoint and reason.",
+	}, []string{"client", "method", "endpoint", "reason"})
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(
+		GithubRateLimitLimit,
+		GithubRateLimitRemaining,
+		GithubRateLimitReset,
+		GithubRequests,
+		GithubRequestDuration,
+		GithubRequestErrors,
+	)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const runnerGCSubsystem = "runner_gc"
+
+var (
+	// RunnerGCOrphanedRunners counts the orphaned Github runners found by the runner garbage collector.
+	RunnerGCOrphanedRunners = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "octorun",
+		Subsystem: runnerGCSubsystem,
+		Name:      "orphaned_runners_total",
+		Help:      "Total number of orphaned Github runners found by the runner garbage collector.",
+	}, []string{"url", "dry_run"})
+
+	// RunnerGCRemovedRunners counts the orphaned Github runners removed by the runner garbage collector.
+	RunnerGCRemovedRunners = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "octorun",
+		Subsystem: runnerGCSubsystem,
+		Name:      "removed_runners_total",
+		Help:      "Total number of orphaned Github runners removed by the runner garbage collector.",
+	}, []string{"url"})
+
+	// RunnerGCErrors counts the errors of the runner garbage collector when listing or removing Github runners.
+	RunnerGCErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "octorun",
+		Subsystem: runnerGCSubsystem,
+		Name:      "errors_total",
+		Help:      "Total number of errors when listing or removing Github runners by the runner garbage collector.",
+	}, []string{"url"})
+
+	// RunnerGCLastRunTimestamp is the last time the runner garbage collector has run.
+	RunnerGCLastRunTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "octorun",
+		Subsystem: runnerGCSubsystem,
+		Name:      "last_run_timestamp_seconds",
+		Help:      "Unix timestamp of the last run of the runner garbage collector.",
+	})
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(
+		RunnerGCOrphanedRunners,
+		RunnerGCRemovedRunners,
+		RunnerGCErrors,
+		RunnerGCLastRunTimestamp,
+	)
+}
@@ -86,6 +86,7 @@ func (p *RunnerProvider) ProvideMetricFamily() []statemetrics.MetricFamily {
 					{phase == octorunv1.RunnerIdlePhase, string(octorunv1.RunnerIdlePhase)},
 					{phase == octorunv1.RunnerActivePhase, string(octorunv1.RunnerActivePhase)},
 					{phase == octorunv1.RunnerCompletePhase, string(octorunv1.RunnerCompletePhase)},
+					{phase == octorunv1.RunnerFailedPhase, string(octorunv1.RunnerFailedPhase)},
 				}
 
 				metrics := make([]*statemetrics.Metric, len(phases))
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	workflowJobSubsystem = "workflow_job"
+	runnerSubsystem      = "runner"
+)
+
+var (
+	// WorkflowJobQueueDuration is the time workflow jobs waited from queued to in_progress.
+	WorkflowJobQueueDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "octorun",
+		Subsystem: workflowJobSubsystem,
+		Name:      "queue_duration_seconds",
+		Help:      "Time in seconds the Github workflow jobs waited from queued until a Runner picked them up.",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600},
+	}, []string{"namespace", "runnerset", "repository", "conclusion"})
+
+	// WorkflowJobDuration is the time workflow jobs ran from in_progress to completed.
+	WorkflowJobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "octorun",
+		Subsystem: workflowJobSubsystem,
+		Name:      "duration_seconds",
+		Help:      "Time in seconds the Github workflow jobs ran on a Runner until completed.",
+		Buckets:   []float64{30, 60, 120, 300, 600, 1200, 1800, 3600, 7200, 21600},
+	}, []string{"namespace", "runnerset", "repository", "conclusion"})
+
+	// RunnerProvisioningDuration is the time Runners took from creation to Idle.
+	RunnerProvisioningDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "octorun",
+		Subsystem: runnerSubsystem,
+		Name:      "provisioning_duration_seconds",
+		Help:      "Time in seconds the Runners took from creation until online on Github and waiting for a job.",
+		Buckets:   []float64{5, 10, 20, 30, 60, 90, 120, 180, 300, 600},
+	}, []string{"namespace", "runnerset", "url"})
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(
+		WorkflowJobQueueDuration,
+		WorkflowJobDuration,
+		RunnerProvisioningDuration,
+	)
+}
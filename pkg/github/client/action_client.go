@@ -18,6 +18,7 @@ package client
 
 import (
 	"context"
+	"fmt"
 	"net/url"
 	"strings"
 
@@ -27,9 +28,14 @@ import (
 type ActionClient interface {
 	GetRunner(ctx context.Context, runnerURL string, runnerID int64) (Runner, error)
 	CreateRunnerToken(ctx context.Context, runnerURL string) (RunnerToken, error)
+	RemoveRunner(ctx context.Context, runnerURL string, runnerID int64) error
+	ListRunners(ctx context.Context, runnerURL string) ([]*github.Runner, error)
+	FindRunnerByName(ctx context.Context, runnerURL string, runnerName string) (Runner, error)
+	GenerateJITConfig(ctx context.Context, runnerURL string, jitRequest *JITConfigRequest) (*JITRunnerConfig, error)
 }
 
 type Runner interface {
+	GetID() int64
 	GetName() string
 	GetBusy() bool
 	GetOS() string
@@ -41,11 +47,33 @@ type RunnerToken interface {
 	GetExpiresAt() github.Timestamp
 }
 
+// JITConfigRequest is the request to register a just-in-time runner.
+type JITConfigRequest struct {
+	// Name of the runner.
+	Name string
+	// Name of the runner group. Defaults to Default.
+	Group string
+	// Labels of the runner in addition to self-hosted.
+	Labels []string
+	// WorkFolder is the runner work directory. Defaults to _work.
+	WorkFolder string
+}
+
+// JITRunnerConfig is the just-in-time runner configuration generated by Github.
+type JITRunnerConfig struct {
+	Runner           *github.Runner `json:"runner,omitempty"`
+	EncodedJITConfig string         `json:"encoded_jit_config"`
+}
+
 type runnerKey struct {
+	Enterprise string
 	Owner      string
 	Repository string
 }
 
+// enterprisesPath is the first path segment of an enterprise URL, eg: https://github.com/enterprises/octo-enterprise
+const enterprisesPath = "enterprises"
+
 func parseRunnerURL(u string) runnerKey {
 	parsedURL, err := url.Parse(u)
 	if err != nil {
@@ -60,6 +88,12 @@ func parseRunnerURL(u string) runnerKey {
 			Owner: paths[0],
 		}
 	default:
+		if paths[0] == enterprisesPath {
+			return runnerKey{
+				Enterprise: paths[1],
+			}
+		}
+
 		return runnerKey{
 			Owner:      paths[0],
 			Repository: paths[1],
@@ -69,6 +103,10 @@ func parseRunnerURL(u string) runnerKey {
 
 func (gh *Client) GetRunner(ctx context.Context, runnerURL string, runnerID int64) (Runner, error) {
 	runnerKey := parseRunnerURL(runnerURL)
+	if runnerKey.Enterprise != "" {
+		return gh.getEnterpriseRunner(ctx, runnerKey.Enterprise, runnerID)
+	}
+
 	if runnerKey.Repository != "" {
 		runner, _, err := gh.Actions.GetRunner(ctx, runnerKey.Owner, runnerKey.Repository, runnerID)
 		return runner, err
@@ -80,6 +118,11 @@ func (gh *Client) GetRunner(ctx context.Context, runnerURL string, runnerID int6
 
 func (gh *Client) CreateRunnerToken(ctx context.Context, runnerURL string) (RunnerToken, error) {
 	runnerKey := parseRunnerURL(runnerURL)
+	if runnerKey.Enterprise != "" {
+		runnerToken, _, err := gh.Enterprise.CreateRegistrationToken(ctx, runnerKey.Enterprise)
+		return runnerToken, err
+	}
+
 	if runnerKey.Repository != "" {
 		runnerToken, _, err := gh.Actions.CreateRegistrationToken(ctx, runnerKey.Owner, runnerKey.Repository)
 		return runnerToken, err
@@ -88,3 +131,201 @@ func (gh *Client) CreateRunnerToken(ctx context.Context, runnerURL string) (Runn
 	runnerToken, _, err := gh.Actions.CreateOrganizationRegistrationToken(ctx, runnerKey.Owner)
 	return runnerToken, err
 }
+
+func (gh *Client) RemoveRunner(ctx context.Context, runnerURL string, runnerID int64) error {
+	runnerKey := parseRunnerURL(runnerURL)
+	if runnerKey.Enterprise != "" {
+		_, err := gh.Enterprise.RemoveRunner(ctx, runnerKey.Enterprise, runnerID)
+		return err
+	}
+
+	if runnerKey.Repository != "" {
+		_, err := gh.Actions.RemoveRunner(ctx, runnerKey.Owner, runnerKey.Repository, runnerID)
+		return err
+	}
+
+	_, err := gh.Actions.RemoveOrganizationRunner(ctx, runnerKey.Owner, runnerID)
+	return err
+}
+
+// ListRunners returns all self-hosted runners registered to the given runner URL.
+func (gh *Client) ListRunners(ctx context.Context, runnerURL string) ([]*github.Runner, error) {
+	runnerKey := parseRunnerURL(runnerURL)
+	opts := &github.ListOptions{PerPage: 100}
+	var runners []*github.Runner
+	for {
+		var (
+			list *github.Runners
+			resp *github.Response
+			err  error
+		)
+
+		switch {
+		case runnerKey.Enterprise != "":
+			list, resp, err = gh.Enterprise.ListRunners(ctx, runnerKey.Enterprise, opts)
+		case runnerKey.Repository != "":
+			list, resp, err = gh.Actions.ListRunners(ctx, runnerKey.Owner, runnerKey.Repository, opts)
+		default:
+			list, resp, err = gh.Actions.ListOrganizationRunners(ctx, runnerKey.Owner, opts)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		runners = append(runners, list.Runners...)
+		if resp.NextPage == 0 {
+			return runners, nil
+		}
+
+		opts.Page = resp.NextPage
+	}
+}
+
+// FindRunnerByName returns the self-hosted runner registered to the given runner URL with the given name.
+// The runners are listed at most once per runnerCacheTTL for each runner URL, it returns
+// a not found error if the runner is not registered yet.
+func (gh *Client) FindRunnerByName(ctx context.Context, runnerURL string, runnerName string) (Runner, error) {
+	if runner, ok := gh.runnerCache.get(runnerURL, runnerName); ok {
+		return runner, nil
+	}
+
+	if !gh.runnerCache.expired(runnerURL) {
+		return nil, newRunnerNotFoundError(runnerURL, runnerName)
+	}
+
+	runners, err := gh.ListRunners(ctx, runnerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	gh.runnerCache.set(runnerURL, runners)
+	if runner, ok := gh.runnerCache.get(runnerURL, runnerName); ok {
+		return runner, nil
+	}
+
+	return nil, newRunnerNotFoundError(runnerURL, runnerName)
+}
+
+// GenerateJITConfig registers a just-in-time runner to the given runner URL and returns
+// its encoded configuration that the runner can be started with.
+func (gh *Client) GenerateJITConfig(ctx context.Context, runnerURL string, jitRequest *JITConfigRequest) (*JITRunnerConfig, error) {
+	runnerKey := parseRunnerURL(runnerURL)
+	groupID, err := gh.findRunnerGroupID(ctx, runnerKey, jitRequest.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	u := fmt.Sprintf("%v/actions/runners/generate-jitconfig", runnerKey.path())
+
+	body := struct {
+		Name          string   `json:"name"`
+		RunnerGroupID int64    `json:"runner_group_id"`
+		Labels        []string `json:"labels"`
+		WorkFolder    string   `json:"work_folder,omitempty"`
+	}{
+		Name:          jitRequest.Name,
+		RunnerGroupID: groupID,
+		Labels:        append([]string{"self-hosted"}, jitRequest.Labels...),
+		WorkFolder:    jitRequest.WorkFolder,
+	}
+
+	req, err := gh.NewRequest("POST", u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	jitConfig := new(JITRunnerConfig)
+	if _, err := gh.Do(ctx, req, jitConfig); err != nil {
+		return nil, err
+	}
+
+	return jitConfig, nil
+}
+
+// findRunnerGroupID returns the ID of the runner group with the given name.
+// Repository runners and the Default runner group always have ID 1.
+func (gh *Client) findRunnerGroupID(ctx context.Context, runnerKey runnerKey, group string) (int64, error) {
+	const defaultRunnerGroupID = 1
+	if runnerKey.Repository != "" || group == "" || group == "Default" {
+		return defaultRunnerGroupID, nil
+	}
+
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var (
+			groups *github.RunnerGroups
+			resp   *github.Response
+			err    error
+		)
+
+		if runnerKey.Enterprise != "" {
+			groups, resp, err = gh.listEnterpriseRunnerGroups(ctx, runnerKey.Enterprise, opts)
+		} else {
+			groups, resp, err = gh.Actions.ListOrganizationRunnerGroups(ctx, runnerKey.Owner, opts)
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		for _, g := range groups.RunnerGroups {
+			if g.GetName() == group {
+				return g.GetID(), nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return 0, fmt.Errorf("runner group %q not found", group)
+		}
+
+		opts.Page = resp.NextPage
+	}
+}
+
+// path returns the Github API path prefix of the runner key, eg: orgs/octorun.
+func (k runnerKey) path() string {
+	switch {
+	case k.Enterprise != "":
+		return fmt.Sprintf("enterprises/%v", k.Enterprise)
+	case k.Repository != "":
+		return fmt.Sprintf("repos/%v/%v", k.Owner, k.Repository)
+	default:
+		return fmt.Sprintf("orgs/%v", k.Owner)
+	}
+}
+
+// getEnterpriseRunner gets the enterprise self-hosted runner with the given ID.
+// The go-github EnterpriseService does not support it yet.
+func (gh *Client) getEnterpriseRunner(ctx context.Context, enterprise string, runnerID int64) (*github.Runner, error) {
+	u := fmt.Sprintf("enterprises/%v/actions/runners/%v", enterprise, runnerID)
+	req, err := gh.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	runner := new(github.Runner)
+	if _, err := gh.Do(ctx, req, runner); err != nil {
+		return nil, err
+	}
+
+	return runner, nil
+}
+
+// listEnterpriseRunnerGroups lists the runner groups of the enterprise.
+// The go-github EnterpriseService does not support it yet.
+func (gh *Client) listEnterpriseRunnerGroups(ctx context.Context, enterprise string, opts *github.ListOptions) (*github.RunnerGroups, *github.Response, error) {
+	u := fmt.Sprintf("enterprises/%v/actions/runner-groups?per_page=%v&page=%v", enterprise, opts.PerPage, opts.Page)
+	req, err := gh.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups := new(github.RunnerGroups)
+	resp, err := gh.Do(ctx, req, groups)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return groups, resp, nil
+}
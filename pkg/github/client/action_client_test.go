@@ -0,0 +1,299 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v41/github"
+)
+
+// fakeActionsAPI serves the Github Actions runners endpoints of any owner and records the requests it got.
+// The runners and runner groups are served in pages of one item.
+type fakeActionsAPI struct {
+	mu       sync.Mutex
+	requests []string
+	jitBody  map[string]interface{}
+}
+
+func (api *fakeActionsAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.requests = append(api.requests, r.Method+" "+r.URL.Path)
+	page := r.URL.Query().Get("page")
+	nextPage := func() {
+		if page != "2" {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=2>; rel="next"`, r.Host, r.URL.Path))
+		}
+	}
+
+	switch base := path.Base(r.URL.Path); {
+	case r.Method == http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && base == "generate-jitconfig":
+		_ = json.NewDecoder(r.Body).Decode(&api.jitBody)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"runner":{"id":42,"name":"runner-test"},"encoded_jit_config":"Y29uZmln"}`)
+	case base == "runner-groups":
+		nextPage()
+		if page == "2" {
+			_, _ = io.WriteString(w, `{"total_count":2,"runner_groups":[{"id":7,"name":"gpu"}]}`)
+			return
+		}
+
+		_, _ = io.WriteString(w, `{"total_count":2,"runner_groups":[{"id":1,"name":"Default"}]}`)
+	case base == "runners":
+		nextPage()
+		if page == "2" {
+			_, _ = io.WriteString(w, `{"total_count":2,"runners":[{"id":43,"name":"runner-b"}]}`)
+			return
+		}
+
+		_, _ = io.WriteString(w, `{"total_count":2,"runners":[{"id":42,"name":"runner-a"}]}`)
+	default:
+		_, _ = fmt.Fprintf(w, `{"id":%s,"name":"runner-test","status":"online"}`, base)
+	}
+}
+
+func newTestActionClient(t *testing.T, api *fakeActionsAPI) (*Client, *httptest.Server) {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	client := &Client{Client: github.NewClient(nil)}
+	client.BaseURL, _ = url.Parse(srv.URL + "/")
+	return client, srv
+}
+
+func TestParseRunnerURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		runnerURL string
+		want      runnerKey
+	}{
+		{
+			name:      "org_url",
+			runnerURL: "https://github.com/octorun",
+			want:      runnerKey{Owner: "octorun"},
+		},
+		{
+			name:      "repo_url",
+			runnerURL: "https://github.com/octorun/octorun",
+			want:      runnerKey{Owner: "octorun", Repository: "octorun"},
+		},
+		{
+			name:      "repo_url_with_trailing_slash",
+			runnerURL: "https://github.com/octorun/octorun/",
+			want:      runnerKey{Owner: "octorun", Repository: "octorun"},
+		},
+		{
+			name:      "enterprise_url",
+			runnerURL: "https://github.com/enterprises/octo-enterprise",
+			want:      runnerKey{Enterprise: "octo-enterprise"},
+		},
+		{
+			name:      "github_enterprise_server_url",
+			runnerURL: "https://github.example.com/enterprises/octo-enterprise",
+			want:      runnerKey{Enterprise: "octo-enterprise"},
+		},
+		{
+			name:      "invalid_url",
+			runnerURL: "://github.com/octorun",
+			want:      runnerKey{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRunnerURL(tt.runnerURL); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRunnerURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_runnerEndpoints(t *testing.T) {
+	tests := []struct {
+		name       string
+		runnerURL  string
+		wantGet    []string
+		wantList   []string
+		wantRemove []string
+	}{
+		{
+			name:       "enterprise_runner",
+			runnerURL:  "https://github.com/enterprises/octo-enterprise",
+			wantGet:    []string{"GET /enterprises/octo-enterprise/actions/runners/42"},
+			wantList:   []string{"GET /enterprises/octo-enterprise/actions/runners", "GET /enterprises/octo-enterprise/actions/runners"},
+			wantRemove: []string{"DELETE /enterprises/octo-enterprise/actions/runners/42"},
+		},
+		{
+			name:       "org_runner",
+			runnerURL:  "https://github.com/octorun",
+			wantGet:    []string{"GET /orgs/octorun/actions/runners/42"},
+			wantList:   []string{"GET /orgs/octorun/actions/runners", "GET /orgs/octorun/actions/runners"},
+			wantRemove: []string{"DELETE /orgs/octorun/actions/runners/42"},
+		},
+		{
+			name:       "repo_runner",
+			runnerURL:  "https://github.com/octorun/octorun",
+			wantGet:    []string{"GET /repos/octorun/octorun/actions/runners/42"},
+			wantList:   []string{"GET /repos/octorun/octorun/actions/runners", "GET /repos/octorun/octorun/actions/runners"},
+			wantRemove: []string{"DELETE /repos/octorun/octorun/actions/runners/42"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			api := &fakeActionsAPI{}
+			client, srv := newTestActionClient(t, api)
+			defer srv.Close()
+
+			runner, err := client.GetRunner(ctx, tt.runnerURL, 42)
+			if err != nil {
+				t.Fatalf("Client.GetRunner() error = %v", err)
+			}
+			if runner.GetID() != 42 || runner.GetStatus() != "online" {
+				t.Errorf("Client.GetRunner() = %v", runner)
+			}
+			if !reflect.DeepEqual(api.requests, tt.wantGet) {
+				t.Errorf("Client.GetRunner() requests = %v, want %v", api.requests, tt.wantGet)
+			}
+
+			api.requests = nil
+			runners, err := client.ListRunners(ctx, tt.runnerURL)
+			if err != nil {
+				t.Fatalf("Client.ListRunners() error = %v", err)
+			}
+			if len(runners) != 2 || runners[0].GetName() != "runner-a" || runners[1].GetName() != "runner-b" {
+				t.Errorf("Client.ListRunners() = %v, want runner-a and runner-b", runners)
+			}
+			if !reflect.DeepEqual(api.requests, tt.wantList) {
+				t.Errorf("Client.ListRunners() requests = %v, want %v", api.requests, tt.wantList)
+			}
+
+			api.requests = nil
+			if err := client.RemoveRunner(ctx, tt.runnerURL, 42); err != nil {
+				t.Fatalf("Client.RemoveRunner() error = %v", err)
+			}
+			if !reflect.DeepEqual(api.requests, tt.wantRemove) {
+				t.Errorf("Client.RemoveRunner() requests = %v, want %v", api.requests, tt.wantRemove)
+			}
+		})
+	}
+}
+
+func TestClient_GenerateJITConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		runnerURL    string
+		group        string
+		wantRequests []string
+		wantGroupID  float64
+		wantErr      bool
+	}{
+		{
+			name:      "enterprise_runner_group",
+			runnerURL: "https://github.com/enterprises/octo-enterprise",
+			group:     "gpu",
+			wantRequests: []string{
+				"GET /enterprises/octo-enterprise/actions/runner-groups",
+				"GET /enterprises/octo-enterprise/actions/runner-groups",
+				"POST /enterprises/octo-enterprise/actions/runners/generate-jitconfig",
+			},
+			wantGroupID: 7,
+		},
+		{
+			name:      "enterprise_runner_group_not_found",
+			runnerURL: "https://github.com/enterprises/octo-enterprise",
+			group:     "arm",
+			wantRequests: []string{
+				"GET /enterprises/octo-enterprise/actions/runner-groups",
+				"GET /enterprises/octo-enterprise/actions/runner-groups",
+			},
+			wantErr: true,
+		},
+		{
+			name:      "enterprise_default_runner_group",
+			runnerURL: "https://github.com/enterprises/octo-enterprise",
+			group:     "Default",
+			wantRequests: []string{
+				"POST /enterprises/octo-enterprise/actions/runners/generate-jitconfig",
+			},
+			wantGroupID: 1,
+		},
+		{
+			name:      "org_runner_group",
+			runnerURL: "https://github.com/octorun",
+			group:     "gpu",
+			wantRequests: []string{
+				"GET /orgs/octorun/actions/runner-groups",
+				"GET /orgs/octorun/actions/runner-groups",
+				"POST /orgs/octorun/actions/runners/generate-jitconfig",
+			},
+			wantGroupID: 7,
+		},
+		{
+			name:      "repo_runner",
+			runnerURL: "https://github.com/octorun/octorun",
+			group:     "gpu",
+			wantRequests: []string{
+				"POST /repos/octorun/octorun/actions/runners/generate-jitconfig",
+			},
+			wantGroupID: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeActionsAPI{}
+			client, srv := newTestActionClient(t, api)
+			defer srv.Close()
+
+			got, err := client.GenerateJITConfig(context.Background(), tt.runnerURL, &JITConfigRequest{
+				Name:   "runner-test",
+				Group:  tt.group,
+				Labels: []string{"linux"},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Client.GenerateJITConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(api.requests, tt.wantRequests) {
+				t.Errorf("Client.GenerateJITConfig() requests = %v, want %v", api.requests, tt.wantRequests)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.Runner.GetID() != 42 || got.EncodedJITConfig != "Y29uZmln" {
+				t.Errorf("Client.GenerateJITConfig() = %+v", got)
+			}
+			if api.jitBody["runner_group_id"] != tt.wantGroupID {
+				t.Errorf("Client.GenerateJITConfig() runner_group_id = %v, want %v", api.jitBody["runner_group_id"], tt.wantGroupID)
+			}
+			if want := []interface{}{"self-hosted", "linux"}; !reflect.DeepEqual(api.jitBody["labels"], want) {
+				t.Errorf("Client.GenerateJITConfig() labels = %v, want %v", api.jitBody["labels"], want)
+			}
+		})
+	}
+}
@@ -19,7 +19,11 @@ package client
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 
 	"github.com/google/go-github/v41/github"
 	"golang.org/x/oauth2"
@@ -27,14 +31,21 @@ import (
 
 type Client struct {
 	*github.Client
+
+	runnerCache runnerCache
+
+	// perOwner denotes the client authenticates as the Github App installation of each owner.
+	perOwner bool
 }
 
 type Opts struct {
+	name          string
 	endpoint      string
 	personalToken string
 
 	appID          int64
 	appKey         string
+	appKeyPEM      []byte
 	installationID string
 }
 
@@ -51,26 +62,61 @@ func New(opts ...ClientOption) (*Client, error) {
 		return nil, err
 	}
 
-	var ts oauth2.TokenSource
+	var hc *http.Client
+	var perOwner bool
 	if option.personalToken != "" {
-		ts = newPersonalTokenSource(option.personalToken)
-	} else if option.appID != 0 && option.appKey != "" && option.installationID != "" {
-		ts, err = newInstallationTokenSource(baseURL.String(), option.appID, option.appKey, option.installationID)
-		if err != nil {
-			return nil, err
+		hc = oauth2.NewClient(context.Background(), newPersonalTokenSource(option.personalToken))
+	} else if option.appID != 0 && (option.appKey != "" || len(option.appKeyPEM) > 0) {
+		appKeyPEM := option.appKeyPEM
+		if len(appKeyPEM) == 0 {
+			appKeyPEM, err = os.ReadFile(filepath.Clean(option.appKey))
+			if err != nil {
+				return nil, fmt.Errorf("invalid app private key file: %v", err)
+			}
+		}
+
+		if option.installationID != "" {
+			ts, err := newInstallationTokenSource(baseURL.String(), option.appID, appKeyPEM, option.installationID)
+			if err != nil {
+				return nil, err
+			}
+
+			hc = oauth2.NewClient(context.Background(), ts)
+		} else {
+			// Without installation ID the installation is looked up for the owner of each request.
+			transport, err := newInstallationTransport(baseURL, option.appID, appKeyPEM)
+			if err != nil {
+				return nil, err
+			}
+
+			hc = &http.Client{Transport: transport}
+			perOwner = true
 		}
 	} else {
 		return nil, errors.New("unable to authenticate Github Client.")
 	}
 
-	hc := oauth2.NewClient(context.Background(), ts)
+	name := option.name
+	if name == "" {
+		name = "default"
+	}
+
+	hc.Transport = newRateLimitTransport(newMetricsTransport(name, baseURL.Path, hc.Transport))
 	client := github.NewClient(hc)
 	client.BaseURL = baseURL
 	return &Client{
-		Client: client,
+		Client:   client,
+		perOwner: perOwner,
 	}, nil
 }
 
+// WithName sets the name of the client reported by the Github API metrics.
+func WithName(name string) ClientOption {
+	return func(o *Opts) {
+		o.name = name
+	}
+}
+
 func WithEndpoint(endpoint string) ClientOption {
 	return func(o *Opts) {
 		o.endpoint = endpoint
@@ -89,6 +135,14 @@ func WithAppPrivateKey(key string) ClientOption {
 	}
 }
 
+// WithAppPrivateKeyPEM sets the Github App private key in PEM format.
+// It takes precedence over the private key file set by WithAppPrivateKey.
+func WithAppPrivateKeyPEM(key []byte) ClientOption {
+	return func(o *Opts) {
+		o.appKeyPEM = key
+	}
+}
+
 func WithInstallationID(id string) ClientOption {
 	return func(o *Opts) {
 		o.installationID = id
@@ -100,3 +154,15 @@ func WithPersonalAccessToken(token string) ClientOption {
 		o.personalToken = token
 	}
 }
+
+// Authenticate checks that the client credential is accepted by Github.
+// The Github App itself is checked when the installation is looked up for each owner.
+func (gh *Client) Authenticate(ctx context.Context) error {
+	if gh.perOwner {
+		_, _, err := gh.Apps.Get(ctx, "")
+		return err
+	}
+
+	_, _, err := gh.RateLimits(ctx)
+	return err
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/go-github/v41/github"
+	"golang.org/x/oauth2"
+)
+
+// installationTransport authenticates every request as the Github App installation of the owner
+// the request is made for, so one Github App can serve every organization it is installed in.
+// The installation of an owner is looked up once using the Github App JWT and its token source is
+// kept for the subsequent requests. Requests not made for an owner are authenticated as the Github App.
+type installationTransport struct {
+	baseURL       *url.URL
+	appID         int64
+	appPrivateKey *rsa.PrivateKey
+	base          http.RoundTripper
+
+	mu      sync.Mutex
+	sources map[string]oauth2.TokenSource
+}
+
+func newInstallationTransport(baseURL *url.URL, appID int64, appKeyPEM []byte) (*installationTransport, error) {
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(appKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse app private key: %v", err)
+	}
+
+	return &installationTransport{
+		baseURL:       baseURL,
+		appID:         appID,
+		appPrivateKey: privateKey,
+		base:          http.DefaultTransport,
+		sources:       make(map[string]oauth2.TokenSource),
+	}, nil
+}
+
+func (t *installationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var token *oauth2.Token
+	key, ok := t.requestKey(req.URL)
+	if ok {
+		ts, err := t.tokenSource(req.Context(), key)
+		if err == nil {
+			token, err = ts.Token()
+		}
+
+		if err != nil {
+			// The Github App may have been uninstalled or reinstalled meanwhile,
+			// the installation is looked up again on the next request.
+			t.forget(key.Owner)
+			closeRequestBody(req)
+			return nil, err
+		}
+	} else {
+		tokenJWT, err := newAppJWT(t.appID, t.appPrivateKey)
+		if err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+
+		token = &oauth2.Token{AccessToken: tokenJWT, TokenType: "bearer"}
+	}
+
+	authReq := req.Clone(req.Context())
+	token.SetAuthHeader(authReq)
+	res, err := t.base.RoundTrip(authReq)
+	if err == nil && ok && res.StatusCode == http.StatusUnauthorized {
+		// The installation token has been revoked, eg: the Github App has been reinstalled.
+		t.forget(key.Owner)
+	}
+
+	return res, err
+}
+
+// requestKey returns the runner key of the owner the request is made for, eg: orgs/octorun/actions/runners
+// is made for the octorun organization. It returns false if the request is not made for an owner.
+func (t *installationTransport) requestKey(u *url.URL) (runnerKey, bool) {
+	path := strings.TrimPrefix(u.Path, t.baseURL.Path)
+	switch paths := strings.Split(strings.TrimPrefix(path, "/"), "/"); {
+	case len(paths) >= 2 && paths[0] == enterprisesPath:
+		return runnerKey{Enterprise: paths[1]}, true
+	case len(paths) >= 2 && paths[0] == "orgs":
+		return runnerKey{Owner: paths[1]}, true
+	case len(paths) >= 3 && paths[0] == "repos":
+		return runnerKey{Owner: paths[1], Repository: paths[2]}, true
+	default:
+		return runnerKey{}, false
+	}
+}
+
+// tokenSource returns the installation token source of the owner of the given runner key.
+func (t *installationTransport) tokenSource(ctx context.Context, key runnerKey) (oauth2.TokenSource, error) {
+	if key.Enterprise != "" {
+		return nil, fmt.Errorf("unable to look up the Github App installation of enterprise %s, "+
+			"the installation ID must be set to use an enterprise runner URL", key.Enterprise)
+	}
+
+	t.mu.Lock()
+	ts, ok := t.sources[key.Owner]
+	t.mu.Unlock()
+	if ok {
+		return ts, nil
+	}
+
+	installationID, err := t.lookupInstallation(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ts, ok := t.sources[key.Owner]; ok {
+		return ts, nil
+	}
+
+	ts = oauth2.ReuseTokenSource(nil, &installationTokenSource{
+		BaseURL:        t.baseURL.String(),
+		appID:          t.appID,
+		appPrivateKey:  t.appPrivateKey,
+		installationID: fmt.Sprint(installationID),
+	})
+	t.sources[key.Owner] = ts
+	return ts, nil
+}
+
+func (t *installationTransport) forget(owner string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.sources, owner)
+}
+
+// lookupInstallation returns the ID of the Github App installation for the owner of the given runner key
+// using either the organization or the repository installation endpoint.
+func (t *installationTransport) lookupInstallation(ctx context.Context, key runnerKey) (int64, error) {
+	tokenJWT, err := newAppJWT(t.appID, t.appPrivateKey)
+	if err != nil {
+		return 0, err
+	}
+
+	u := strings.TrimRight(t.baseURL.String(), "/") + "/" + key.path() + "/installation"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Authorization", "Bearer "+tokenJWT)
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return 0, fmt.Errorf("unable to get app installation of %s: %v", key.Owner, err)
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	// The Github error response is wrapped so that it can still be classified by the Github errors helpers.
+	if err := github.CheckResponse(res); err != nil {
+		return 0, fmt.Errorf("unable to get app installation of %s, "+
+			"the Github App may not be installed for %s: %w", key.Owner, key.Owner, err)
+	}
+
+	var installation github.Installation
+	if err := json.NewDecoder(res.Body).Decode(&installation); err != nil {
+		return 0, fmt.Errorf("unable to decode app installation response: %v", err)
+	}
+
+	return installation.GetID(), nil
+}
+
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
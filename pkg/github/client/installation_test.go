@@ -0,0 +1,329 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v41/github"
+
+	gherrors "octorun.github.io/octorun/pkg/github/errors"
+)
+
+// fakeGithubApp serves the Github App installation endpoints for the installations of the owners,
+// and answers any other request with its status code and the Authorization header it got.
+type fakeGithubApp struct {
+	installations map[string]int64
+
+	mu              sync.Mutex
+	lookups         map[string]int
+	tokens          map[string]int
+	tokenStatusCode int
+	otherStatusCode int
+}
+
+func (app *fakeGithubApp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	paths := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && paths[len(paths)-1] == "installation":
+		owner := paths[1]
+		app.lookups[owner]++
+		id, ok := app.installations[owner]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `{"id":%d}`, id)
+	case r.Method == http.MethodPost && len(paths) == 4 && paths[0] == "app" && paths[3] == "access_tokens":
+		app.tokens[paths[2]]++
+		if app.tokenStatusCode != 0 {
+			w.WriteHeader(app.tokenStatusCode)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      "token-" + paths[2],
+			"expires_at": time.Now().Add(time.Hour),
+		})
+	default:
+		if app.otherStatusCode != 0 {
+			w.WriteHeader(app.otherStatusCode)
+		}
+
+		_, _ = io.WriteString(w, r.Header.Get("Authorization"))
+	}
+}
+
+func newTestInstallationTransport(t *testing.T, app *fakeGithubApp) (*installationTransport, *httptest.Server) {
+	t.Helper()
+	srv := httptest.NewServer(app)
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate the app private key: %v", err)
+	}
+
+	baseURL, _ := url.Parse(srv.URL + "/")
+	appKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	transport, err := newInstallationTransport(baseURL, 1, appKeyPEM)
+	if err != nil {
+		t.Fatalf("unable to create the installation transport: %v", err)
+	}
+
+	return transport, srv
+}
+
+func TestInstallationTransport_requestKey(t *testing.T) {
+	baseURL, _ := url.Parse("https://github.example.com/api/v3/")
+	tests := []struct {
+		name   string
+		path   string
+		want   runnerKey
+		wantOk bool
+	}{
+		{
+			name:   "org_request",
+			path:   "/api/v3/orgs/octorun/actions/runners",
+			want:   runnerKey{Owner: "octorun"},
+			wantOk: true,
+		},
+		{
+			name:   "repo_request",
+			path:   "/api/v3/repos/octorun/octorun/actions/runners/42",
+			want:   runnerKey{Owner: "octorun", Repository: "octorun"},
+			wantOk: true,
+		},
+		{
+			name:   "enterprise_request",
+			path:   "/api/v3/enterprises/octo-enterprise/actions/runners",
+			want:   runnerKey{Enterprise: "octo-enterprise"},
+			wantOk: true,
+		},
+		{
+			name:   "app_request",
+			path:   "/api/v3/app/installations",
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &installationTransport{baseURL: baseURL}
+			got, gotOk := transport.requestKey(&url.URL{Path: tt.path})
+			if !reflect.DeepEqual(got, tt.want) || gotOk != tt.wantOk {
+				t.Errorf("installationTransport.requestKey() = %v, %v, want %v, %v", got, gotOk, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestInstallationTransport_RoundTrip(t *testing.T) {
+	app := &fakeGithubApp{
+		installations: map[string]int64{"octorun": 10, "octocat": 20},
+		lookups:       make(map[string]int),
+		tokens:        make(map[string]int),
+	}
+
+	transport, srv := newTestInstallationTransport(t, app)
+	defer srv.Close()
+
+	requests := []struct {
+		path     string
+		wantAuth string
+	}{
+		{path: "/orgs/octorun/actions/runners", wantAuth: "Bearer token-10"},
+		{path: "/repos/octocat/hello-world/actions/runners", wantAuth: "Bearer token-20"},
+		{path: "/repos/octorun/octorun/actions/runners/42", wantAuth: "Bearer token-10"},
+		{path: "/orgs/octocat/actions/runners", wantAuth: "Bearer token-20"},
+	}
+	for _, r := range requests {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+r.path, nil)
+		res, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("unexpected RoundTrip error: %v", err)
+		}
+
+		auth, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if string(auth) != r.wantAuth {
+			t.Errorf("installationTransport.RoundTrip() %s Authorization = %v, want %v", r.path, string(auth), r.wantAuth)
+		}
+	}
+
+	// The installation of every owner is looked up and its token created only once.
+	if want := map[string]int{"octorun": 1, "octocat": 1}; !reflect.DeepEqual(app.lookups, want) {
+		t.Errorf("installationTransport.RoundTrip() installation lookups = %v, want %v", app.lookups, want)
+	}
+	if want := map[string]int{"10": 1, "20": 1}; !reflect.DeepEqual(app.tokens, want) {
+		t.Errorf("installationTransport.RoundTrip() installation tokens = %v, want %v", app.tokens, want)
+	}
+}
+
+func TestInstallationTransport_forget(t *testing.T) {
+	tests := []struct {
+		name            string
+		installations   map[string]int64
+		tokenStatusCode int
+		otherStatusCode int
+		wantErr         bool
+		wantForgotten   bool
+		wantLookups     int
+	}{
+		{
+			name:          "installation_not_found",
+			installations: map[string]int64{},
+			wantErr:       true,
+			wantForgotten: true,
+			wantLookups:   2,
+		},
+		{
+			name:            "installation_token_not_found",
+			installations:   map[string]int64{"octorun": 10},
+			tokenStatusCode: http.StatusNotFound,
+			wantErr:         true,
+			wantForgotten:   true,
+			wantLookups:     2,
+		},
+		{
+			name:            "installation_token_unauthorized",
+			installations:   map[string]int64{"octorun": 10},
+			tokenStatusCode: http.StatusUnauthorized,
+			wantErr:         true,
+			wantForgotten:   true,
+			wantLookups:     2,
+		},
+		{
+			name:            "request_unauthorized",
+			installations:   map[string]int64{"octorun": 10},
+			otherStatusCode: http.StatusUnauthorized,
+			wantForgotten:   true,
+			wantLookups:     2,
+		},
+		{
+			name:            "request_not_found",
+			installations:   map[string]int64{"octorun": 10},
+			otherStatusCode: http.StatusNotFound,
+			wantForgotten:   false,
+			wantLookups:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &fakeGithubApp{
+				installations:   tt.installations,
+				lookups:         make(map[string]int),
+				tokens:          make(map[string]int),
+				tokenStatusCode: tt.tokenStatusCode,
+				otherStatusCode: tt.otherStatusCode,
+			}
+
+			transport, srv := newTestInstallationTransport(t, app)
+			defer srv.Close()
+
+			for i := 0; i < 2; i++ {
+				req, _ := http.NewRequest(http.MethodGet, srv.URL+"/orgs/octorun/actions/runners", nil)
+				res, err := transport.RoundTrip(req)
+				if (err != nil) != tt.wantErr {
+					t.Errorf("installationTransport.RoundTrip() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if err == nil {
+					_ = res.Body.Close()
+				}
+
+				_, ok := transport.sources["octorun"]
+				if forgotten := !ok; forgotten != tt.wantForgotten {
+					t.Errorf("installationTransport.RoundTrip() forgotten = %v, want %v", forgotten, tt.wantForgotten)
+				}
+			}
+
+			if got := app.lookups["octorun"]; got != tt.wantLookups {
+				t.Errorf("installationTransport.RoundTrip() installation lookups = %v, want %v", got, tt.wantLookups)
+			}
+		})
+	}
+}
+
+func TestInstallationTransport_errors(t *testing.T) {
+	tests := []struct {
+		name            string
+		installations   map[string]int64
+		tokenStatusCode int
+		wantNotFound    bool
+		wantForbidden   bool
+	}{
+		{
+			name:          "installation_not_found",
+			installations: map[string]int64{},
+			wantNotFound:  true,
+		},
+		{
+			name:            "installation_token_forbidden",
+			installations:   map[string]int64{"octorun": 10},
+			tokenStatusCode: http.StatusForbidden,
+			wantForbidden:   true,
+		},
+		{
+			name:            "installation_token_not_found",
+			installations:   map[string]int64{"octorun": 10},
+			tokenStatusCode: http.StatusNotFound,
+			wantNotFound:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &fakeGithubApp{
+				installations:   tt.installations,
+				lookups:         make(map[string]int),
+				tokens:          make(map[string]int),
+				tokenStatusCode: tt.tokenStatusCode,
+			}
+
+			transport, srv := newTestInstallationTransport(t, app)
+			defer srv.Close()
+
+			ghc := github.NewClient(&http.Client{Transport: transport})
+			ghc.BaseURL = transport.baseURL
+			_, _, err := ghc.Actions.ListOrganizationRunners(context.Background(), "octorun", nil)
+			if err == nil {
+				t.Fatal("expected an error listing the runners")
+			}
+			if got := gherrors.IsNotFound(err); got != tt.wantNotFound {
+				t.Errorf("IsNotFound(%v) = %v, want %v", err, got, tt.wantNotFound)
+			}
+			if got := gherrors.IsForbidden(err); got != tt.wantForbidden {
+				t.Errorf("IsForbidden(%v) = %v, want %v", err, got, tt.wantForbidden)
+			}
+		})
+	}
+}
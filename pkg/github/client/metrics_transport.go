@@ -0,0 +1,157 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v41/github"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"octorun.github.io/octorun/metrics"
+	gherrors "octorun.github.io/octorun/pkg/github/errors"
+)
+
+// endpointParams are the names of the path parameters following each Github API path segment.
+var endpointParams = map[string][]string{
+	"repos":       {"{owner}", "{repo}"},
+	"orgs":        {"{org}"},
+	"users":       {"{username}"},
+	"enterprises": {"{enterprise}"},
+}
+
+// metricsTransport records the Github API requests sent to Github, i.e. not the requests
+// answered by rateLimitTransport, and the rate limit of their responses.
+// Each request is also logged at verbosity 2 with the logger of its context.
+type metricsTransport struct {
+	name string
+	// basePath is the path of the Github API endpoint, eg: /api/v3 for Github Enterprise Server.
+	basePath string
+	base     http.RoundTripper
+}
+
+func newMetricsTransport(name, basePath string, base http.RoundTripper) *metricsTransport {
+	return &metricsTransport{
+		name:     name,
+		basePath: strings.TrimSuffix(basePath, "/"),
+		base:     base,
+	}
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := endpointTemplate(strings.TrimPrefix(req.URL.Path, t.basePath))
+	start := time.Now()
+	res, err := t.base.RoundTrip(req)
+	duration := time.Since(start)
+
+	code := "<error>"
+	reason := "transport"
+	if err == nil {
+		code = strconv.Itoa(res.StatusCode)
+		reason = requestErrorReason(res)
+		t.observeRateLimit(res)
+	}
+
+	metrics.GithubRequests.WithLabelValues(t.name, req.Method, endpoint, code).Inc()
+	metrics.GithubRequestDuration.WithLabelValues(t.name, req.Method, endpoint).Observe(duration.Seconds())
+	if reason != "" {
+		metrics.GithubRequestErrors.WithLabelValues(t.name, req.Method, endpoint, reason).Inc()
+	}
+
+	logf.FromContext(req.Context()).V(2).Info("sent Github API request",
+		"client", t.name, "method", req.Method, "endpoint", endpoint, "code", code, "duration", duration)
+	return res, err
+}
+
+// observeRateLimit records the rate limit of the given response.
+func (t *metricsTransport) observeRateLimit(res *http.Response) {
+	resource := res.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+
+	if v, err := strconv.ParseFloat(res.Header.Get("X-RateLimit-Limit"), 64); err == nil {
+		metrics.GithubRateLimitLimit.WithLabelValues(t.name, resource).Set(v)
+	}
+
+	if v, err := strconv.ParseFloat(res.Header.Get("X-RateLimit-Remaining"), 64); err == nil {
+		metrics.GithubRateLimitRemaining.WithLabelValues(t.name, resource).Set(v)
+	}
+
+	if v, err := strconv.ParseFloat(res.Header.Get("X-RateLimit-Reset"), 64); err == nil {
+		metrics.GithubRateLimitReset.WithLabelValues(t.name, resource).Set(v)
+	}
+}
+
+// requestErrorReason classifies the given response as go-github would report it,
+// it returns an empty string if the response is not an error.
+func requestErrorReason(res *http.Response) string {
+	if res.StatusCode < http.StatusBadRequest {
+		return ""
+	}
+
+	// The error body is read in full so that it is still available to go-github.
+	body, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	checked := *res
+	checked.Body = io.NopCloser(bytes.NewReader(body))
+	err := github.CheckResponse(&checked)
+	switch {
+	case gherrors.IsReteLimit(err):
+		return "rate_limit"
+	case gherrors.IsUnauthorized(err):
+		return "unauthorized"
+	case gherrors.IsForbidden(err):
+		return "forbidden"
+	case gherrors.IsNotFound(err):
+		return "not_found"
+	case res.StatusCode >= http.StatusInternalServerError:
+		return "server_error"
+	default:
+		return "client_error"
+	}
+}
+
+// endpointTemplate returns the given Github API path with its parameters replaced by their name,
+// eg: /repos/octorun/octorun/actions/runners/42 is /repos/{owner}/{repo}/actions/runners/{id},
+// so that the metrics are not partitioned by owner, repository or runner.
+func endpointTemplate(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i < len(segments); i++ {
+		if params, ok := endpointParams[segments[i]]; ok {
+			for j := 0; j < len(params) && i+1 < len(segments); j++ {
+				i++
+				segments[i] = params[j]
+			}
+
+			continue
+		}
+
+		if _, err := strconv.ParseInt(segments[i], 10, 64); err == nil {
+			segments[i] = "{id}"
+		}
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestEndpointTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "repo_runners",
+			path: "/repos/octorun/octorun/actions/runners",
+			want: "/repos/{owner}/{repo}/actions/runners",
+		},
+		{
+			name: "repo_runner",
+			path: "/repos/octorun/octorun/actions/runners/42",
+			want: "/repos/{owner}/{repo}/actions/runners/{id}",
+		},
+		{
+			name: "org_registration_token",
+			path: "/orgs/octorun/actions/runners/registration-token",
+			want: "/orgs/{org}/actions/runners/registration-token",
+		},
+		{
+			name: "org_runner_named_like_a_param",
+			path: "/orgs/repos/actions/runners/7",
+			want: "/orgs/{org}/actions/runners/{id}",
+		},
+		{
+			name: "enterprise_runner",
+			path: "/enterprises/octo-enterprise/actions/runners/42",
+			want: "/enterprises/{enterprise}/actions/runners/{id}",
+		},
+		{
+			name: "user_installation",
+			path: "/users/octocat/installation",
+			want: "/users/{username}/installation",
+		},
+		{
+			name: "app_installation_access_tokens",
+			path: "/app/installations/1234/access_tokens",
+			want: "/app/installations/{id}/access_tokens",
+		},
+		{
+			name: "truncated_repo_path",
+			path: "/repos/octorun",
+			want: "/repos/{owner}",
+		},
+		{
+			name: "trailing_slash",
+			path: "/orgs/octorun/installation/",
+			want: "/orgs/{org}/installation",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := endpointTemplate(tt.path); got != tt.want {
+				t.Errorf("endpointTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestErrorReason(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		header     http.Header
+		body       string
+		want       string
+	}{
+		{
+			name:       "ok",
+			statusCode: http.StatusOK,
+			body:       `{"total_count":0}`,
+			want:       "",
+		},
+		{
+			name:       "not_modified",
+			statusCode: http.StatusNotModified,
+			want:       "",
+		},
+		{
+			name:       "unauthorized",
+			statusCode: http.StatusUnauthorized,
+			body:       `{"message":"Bad credentials"}`,
+			want:       "unauthorized",
+		},
+		{
+			name:       "forbidden",
+			statusCode: http.StatusForbidden,
+			body:       `{"message":"Resource not accessible by integration"}`,
+			want:       "forbidden",
+		},
+		{
+			name:       "not_found",
+			statusCode: http.StatusNotFound,
+			body:       `{"message":"Not Found"}`,
+			want:       "not_found",
+		},
+		{
+			name:       "primary_rate_limit",
+			statusCode: http.StatusForbidden,
+			header: http.Header{
+				"X-Ratelimit-Limit":     []string{"5000"},
+				"X-Ratelimit-Remaining": []string{"0"},
+				"X-Ratelimit-Reset":     []string{"4102444800"},
+			},
+			body: `{"message":"API rate limit exceeded for installation ID 1234."}`,
+			want: "rate_limit",
+		},
+		{
+			name:       "secondary_rate_limit",
+			statusCode: http.StatusForbidden,
+			body:       `{"message":"You have exceeded a secondary rate limit.","documentation_url":"` + secondaryRateLimitDocumentationURL + `"}`,
+			want:       "rate_limit",
+		},
+		{
+			name:       "secondary_rate_limit_retry_after",
+			statusCode: http.StatusTooManyRequests,
+			header:     http.Header{"Retry-After": []string{"60"}},
+			body:       `{"message":"You have exceeded a secondary rate limit."}`,
+			want:       "rate_limit",
+		},
+		{
+			name:       "validation_failed",
+			statusCode: http.StatusUnprocessableEntity,
+			body:       `{"message":"Validation Failed"}`,
+			want:       "client_error",
+		},
+		{
+			name:       "bad_gateway",
+			statusCode: http.StatusBadGateway,
+			body:       `<html>Bad Gateway</html>`,
+			want:       "server_error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/octorun/actions/runners", nil)
+			header := tt.header
+			if header == nil {
+				header = http.Header{}
+			}
+
+			res := &http.Response{
+				StatusCode: tt.statusCode,
+				Header:     header,
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+				Request:    req,
+			}
+
+			if got := requestErrorReason(res); got != tt.want {
+				t.Errorf("requestErrorReason() = %v, want %v", got, tt.want)
+			}
+
+			// The response body is left for go-github to read.
+			if body, _ := io.ReadAll(res.Body); string(body) != tt.body {
+				t.Errorf("requestErrorReason() left body = %v, want %v", string(body), tt.body)
+			}
+		})
+	}
+}
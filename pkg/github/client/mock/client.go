@@ -24,6 +24,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	github "github.com/google/go-github/v41/github"
 	client "octorun.github.io/octorun/pkg/github/client"
 )
 
@@ -50,6 +51,20 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// Authenticate mocks base method.
+func (m *MockClient) Authenticate(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockClientMockRecorder) Authenticate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockClient)(nil).Authenticate), arg0)
+}
+
 // CreateRunnerToken mocks base method.
 func (m *MockClient) CreateRunnerToken(arg0 context.Context, arg1 string) (client.RunnerToken, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +80,36 @@ func (mr *MockClientMockRecorder) CreateRunnerToken(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRunnerToken", reflect.TypeOf((*MockClient)(nil).CreateRunnerToken), arg0, arg1)
 }
 
+// FindRunnerByName mocks base method.
+func (m *MockClient) FindRunnerByName(arg0 context.Context, arg1, arg2 string) (client.Runner, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRunnerByName", arg0, arg1, arg2)
+	ret0, _ := ret[0].(client.Runner)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRunnerByName indicates an expected call of FindRunnerByName.
+func (mr *MockClientMockRecorder) FindRunnerByName(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRunnerByName", reflect.TypeOf((*MockClient)(nil).FindRunnerByName), arg0, arg1, arg2)
+}
+
+// GenerateJITConfig mocks base method.
+func (m *MockClient) GenerateJITConfig(arg0 context.Context, arg1 string, arg2 *client.JITConfigRequest) (*client.JITRunnerConfig, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateJITConfig", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*client.JITRunnerConfig)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateJITConfig indicates an expected call of GenerateJITConfig.
+func (mr *MockClientMockRecorder) GenerateJITConfig(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateJITConfig", reflect.TypeOf((*MockClient)(nil).GenerateJITConfig), arg0, arg1, arg2)
+}
+
 // GetRunner mocks base method.
 func (m *MockClient) GetRunner(arg0 context.Context, arg1 string, arg2 int64) (client.Runner, error) {
 	m.ctrl.T.Helper()
@@ -79,3 +124,32 @@ func (mr *MockClientMockRecorder) GetRunner(arg0, arg1, arg2 interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRunner", reflect.TypeOf((*MockClient)(nil).GetRunner), arg0, arg1, arg2)
 }
+
+// ListRunners mocks base method.
+func (m *MockClient) ListRunners(arg0 context.Context, arg1 string) ([]*github.Runner, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRunners", arg0, arg1)
+	ret0, _ := ret[0].([]*github.Runner)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRunners indicates an expected call of ListRunners.
+func (mr *MockClientMockRecorder) ListRunners(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRunners", reflect.TypeOf((*MockClient)(nil).ListRunners), arg0, arg1)
+}
+
+// RemoveRunner mocks base method.
+func (m *MockClient) RemoveRunner(arg0 context.Context, arg1 string, arg2 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveRunner", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveRunner indicates an expected call of RemoveRunner.
+func (mr *MockClientMockRecorder) RemoveRunner(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRunner", reflect.TypeOf((*MockClient)(nil).RemoveRunner), arg0, arg1, arg2)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v41/github"
+)
+
+// runnerCacheTTL is how long the listed runners of a runner URL are used
+// to find runners by name before listing them again.
+const runnerCacheTTL = 10 * time.Second
+
+// runnerCache caches the listed runners by name for each runner URL so that
+// many runners registering at the same time only need a single list request.
+type runnerCache struct {
+	mu      sync.Mutex
+	entries map[string]runnerCacheEntry
+}
+
+type runnerCacheEntry struct {
+	runners  map[string]*github.Runner
+	listedAt time.Time
+}
+
+func (c *runnerCache) get(runnerURL, runnerName string) (*github.Runner, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	runner, ok := c.entries[runnerURL].runners[runnerName]
+	return runner, ok
+}
+
+func (c *runnerCache) expired(runnerURL string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[runnerURL]
+	return !ok || time.Since(entry.listedAt) > runnerCacheTTL
+}
+
+func (c *runnerCache) set(runnerURL string, runners []*github.Runner) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]runnerCacheEntry)
+	}
+
+	entry := runnerCacheEntry{
+		runners:  make(map[string]*github.Runner, len(runners)),
+		listedAt: time.Now(),
+	}
+
+	for _, runner := range runners {
+		entry.runners[runner.GetName()] = runner
+	}
+
+	c.entries[runnerURL] = entry
+}
+
+// newRunnerNotFoundError returns a Github not found error response
+// so it can be handled the same way as the other Github not found errors.
+func newRunnerNotFoundError(runnerURL, runnerName string) error {
+	req, _ := http.NewRequest(http.MethodGet, runnerURL, nil)
+	return &github.ErrorResponse{
+		Response: &http.Response{StatusCode: http.StatusNotFound, Request: req},
+		Message:  fmt.Sprintf("runner %q not found", runnerName),
+	}
+}
@@ -20,10 +20,7 @@ import (
 	"context"
 	"crypto/rsa"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -46,13 +43,8 @@ type installationTokenSource struct {
 	BaseURL string
 }
 
-func newInstallationTokenSource(baseURL string, appID int64, appKey, installationID string) (oauth2.TokenSource, error) {
-	f, err := os.ReadFile(filepath.Clean(appKey))
-	if err != nil {
-		return nil, fmt.Errorf("invalid app private key file: %v", err)
-	}
-
-	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(f)
+func newInstallationTokenSource(baseURL string, appID int64, appKeyPEM []byte, installationID string) (oauth2.TokenSource, error) {
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(appKeyPEM)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse app private key: %v", err)
 	}
@@ -80,17 +72,9 @@ type installationToken struct {
 }
 
 func (ts *installationTokenSource) Token() (*oauth2.Token, error) {
-	iss := time.Now().Add(-30 * time.Second).Truncate(time.Second)
-	exp := iss.Add(2 * time.Minute)
-	claims := &jwt.RegisteredClaims{
-		IssuedAt:  jwt.NewNumericDate(iss),
-		ExpiresAt: jwt.NewNumericDate(exp),
-		Issuer:    strconv.FormatInt(ts.appID, 10),
-	}
-
-	tokenJWT, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(ts.appPrivateKey)
+	tokenJWT, err := newAppJWT(ts.appID, ts.appPrivateKey)
 	if err != nil {
-		return nil, fmt.Errorf("could not sign jwt: %s", err)
+		return nil, err
 	}
 
 	u := strings.TrimRight(ts.BaseURL, "/") + "/app/installations/" + ts.installationID + "/access_tokens"
@@ -104,8 +88,8 @@ func (ts *installationTokenSource) Token() (*oauth2.Token, error) {
 		_ = res.Body.Close()
 	}()
 
-	if res.StatusCode != 201 {
-		return nil, errors.New("unable create app installations access_tokens. got non 2xx HTTP response code")
+	if err := github.CheckResponse(res); err != nil {
+		return nil, fmt.Errorf("unable create app installations access_tokens: %w", err)
 	}
 
 	var it installationToken
@@ -119,3 +103,21 @@ func (ts *installationTokenSource) Token() (*oauth2.Token, error) {
 		Expiry:      it.ExpiresAt,
 	}, nil
 }
+
+// newAppJWT returns a short-lived JWT to authenticate as the Github App itself.
+func newAppJWT(appID int64, appPrivateKey *rsa.PrivateKey) (string, error) {
+	iss := time.Now().Add(-30 * time.Second).Truncate(time.Second)
+	exp := iss.Add(2 * time.Minute)
+	claims := &jwt.RegisteredClaims{
+		IssuedAt:  jwt.NewNumericDate(iss),
+		ExpiresAt: jwt.NewNumericDate(exp),
+		Issuer:    strconv.FormatInt(appID, 10),
+	}
+
+	tokenJWT, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(appPrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("could not sign jwt: %s", err)
+	}
+
+	return tokenJWT, nil
+}
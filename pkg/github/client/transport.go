@@ -0,0 +1,231 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxCachedResponses bounds the number of GET responses kept for conditional requests.
+const maxCachedResponses = 1000
+
+// secondaryRateLimitDocumentationURL makes go-github report a pending secondary rate limit as github.AbuseRateLimitError.
+const secondaryRateLimitDocumentationURL = "https://docs.github.com/rest/overview/resources-in-the-rest-api#abuse-rate-limits"
+
+// rateLimitTransport reduces the Github API quota used by the client:
+//   - GET responses having an ETag are revalidated with conditional requests,
+//     Github does not count a 304 Not Modified response against the rate limit.
+//   - Identical GET requests in flight at the same time are sent only once.
+//   - No request is sent until the secondary rate limit Retry-After has elapsed.
+//
+// The primary rate limit is already honored by go-github which does not send
+// any request until X-RateLimit-Reset once the remaining quota is exhausted.
+type rateLimitTransport struct {
+	base http.RoundTripper
+
+	mu         sync.Mutex
+	retryAfter time.Time
+	responses  map[string]*recordedResponse
+	inflight   map[string]*inflightRequest
+}
+
+// recordedResponse is a response read in full so that it can be replayed to several requests.
+type recordedResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+type inflightRequest struct {
+	done chan struct{}
+	res  *recordedResponse
+	err  error
+}
+
+func newRateLimitTransport(base http.RoundTripper) *rateLimitTransport {
+	return &rateLimitTransport{
+		base:      base,
+		responses: make(map[string]*recordedResponse),
+		inflight:  make(map[string]*inflightRequest),
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if wait := t.secondaryRateLimitWait(); wait > 0 {
+		closeRequestBody(req)
+		return secondaryRateLimitResponse(req, wait), nil
+	}
+
+	if req.Method != http.MethodGet {
+		res, err := t.base.RoundTrip(req)
+		if err == nil {
+			t.observe(res)
+		}
+
+		return res, err
+	}
+
+	key := req.URL.String() + " " + req.Header.Get("Accept")
+	t.mu.Lock()
+	if call, ok := t.inflight[key]; ok {
+		t.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+
+		if call.err != nil {
+			if isContextError(call.err) && req.Context().Err() == nil {
+				// The request sent for every waiter was cancelled by its own context,
+				// send this request again since it is still wanted.
+				return t.RoundTrip(req)
+			}
+
+			return nil, call.err
+		}
+
+		return call.res.response(req), nil
+	}
+
+	call := &inflightRequest{done: make(chan struct{})}
+	t.inflight[key] = call
+	cached := t.responses[key]
+	t.mu.Unlock()
+
+	call.res, call.err = t.conditionalGet(req, key, cached)
+	t.mu.Lock()
+	delete(t.inflight, key)
+	t.mu.Unlock()
+	close(call.done)
+
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	return call.res.response(req), nil
+}
+
+// conditionalGet sends the given GET request revalidating the given cached response if any.
+func (t *rateLimitTransport) conditionalGet(req *http.Request, key string, cached *recordedResponse) (*recordedResponse, error) {
+	if cached != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.header.Get("ETag"))
+	}
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	t.observe(res)
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		// Replay the cached response along with the up to date rate limit headers.
+		header := cached.header.Clone()
+		for _, h := range []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-RateLimit-Used", "X-RateLimit-Resource"} {
+			if v := res.Header.Get(h); v != "" {
+				header.Set(h, v)
+			}
+		}
+
+		return &recordedResponse{statusCode: http.StatusOK, header: header, body: cached.body}, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := &recordedResponse{statusCode: res.StatusCode, header: res.Header, body: body}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if res.StatusCode == http.StatusOK && res.Header.Get("ETag") != "" {
+		if _, ok := t.responses[key]; !ok && len(t.responses) >= maxCachedResponses {
+			t.responses = make(map[string]*recordedResponse)
+		}
+
+		t.responses[key] = recorded
+	} else {
+		delete(t.responses, key)
+	}
+
+	return recorded, nil
+}
+
+// observe records the secondary rate limit Retry-After of the given response if any.
+func (t *rateLimitTransport) observe(res *http.Response) {
+	if res.StatusCode != http.StatusForbidden && res.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+
+	if seconds, err := strconv.ParseInt(res.Header.Get("Retry-After"), 10, 64); err == nil {
+		t.mu.Lock()
+		t.retryAfter = time.Now().Add(time.Duration(seconds) * time.Second)
+		t.mu.Unlock()
+	}
+}
+
+func (t *rateLimitTransport) secondaryRateLimitWait() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return time.Until(t.retryAfter)
+}
+
+// isContextError returns true if the given error is caused by a cancelled or expired request context.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func (r *recordedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.statusCode, http.StatusText(r.statusCode)),
+		StatusCode:    r.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}
+}
+
+// secondaryRateLimitResponse returns the response of a request not sent while the secondary rate limit is pending.
+func secondaryRateLimitResponse(req *http.Request, wait time.Duration) *http.Response {
+	body := fmt.Sprintf(`{"message":"secondary rate limit exceeded, retry after %s","documentation_url":%q}`,
+		wait.Round(time.Second), secondaryRateLimitDocumentationURL)
+	res := (&recordedResponse{
+		statusCode: http.StatusForbidden,
+		header:     http.Header{"Content-Type": []string{"application/json"}},
+		body:       []byte(body),
+	}).response(req)
+	res.Header.Set("Retry-After", strconv.FormatFloat(math.Ceil(wait.Seconds()), 'f', 0, 64))
+	return res
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v41/github"
+)
+
+func TestRateLimitTransport_revalidate(t *testing.T) {
+	var hits, revalidations int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&revalidations, 1)
+			w.Header().Set("X-RateLimit-Remaining", "4998")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, `{"total_count":1}`)
+	}))
+	defer srv.Close()
+
+	hc := &http.Client{Transport: newRateLimitTransport(http.DefaultTransport)}
+	var bodies []string
+	var remaining []string
+	for i := 0; i < 2; i++ {
+		res, err := hc.Get(srv.URL + "/repos/octorun/octorun/actions/runners")
+		if err != nil {
+			t.Fatalf("unexpected Get error: %v", err)
+		}
+
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("rateLimitTransport.RoundTrip() status = %v, want %v", res.StatusCode, http.StatusOK)
+		}
+
+		bodies = append(bodies, string(body))
+		remaining = append(remaining, res.Header.Get("X-RateLimit-Remaining"))
+	}
+
+	if hits, revalidations := atomic.LoadInt32(&hits), atomic.LoadInt32(&revalidations); hits != 2 || revalidations != 1 {
+		t.Errorf("rateLimitTransport.RoundTrip() hits = %v, revalidations = %v, want 2 and 1", hits, revalidations)
+	}
+	if bodies[1] != bodies[0] {
+		t.Errorf("rateLimitTransport.RoundTrip() revalidated body = %v, want %v", bodies[1], bodies[0])
+	}
+	if remaining[1] != "4998" {
+		t.Errorf("rateLimitTransport.RoundTrip() revalidated X-RateLimit-Remaining = %v, want 4998", remaining[1])
+	}
+}
+
+func TestRateLimitTransport_coalesce(t *testing.T) {
+	var hits int32
+	started := make(chan struct{}, 5)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		started <- struct{}{}
+		<-release
+		_, _ = io.WriteString(w, `{"id":42}`)
+	}))
+	defer srv.Close()
+
+	hc := &http.Client{Transport: newRateLimitTransport(http.DefaultTransport)}
+	get := func() (int, string, error) {
+		res, err := hc.Get(srv.URL + "/repos/octorun/octorun/actions/runners/42")
+		if err != nil {
+			return 0, "", err
+		}
+
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		return res.StatusCode, string(body), err
+	}
+
+	const requests = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	wg.Add(requests)
+	go func() {
+		defer wg.Done()
+		if code, body, err := get(); err != nil || code != http.StatusOK || body != `{"id":42}` {
+			errs <- errors.New("unexpected response of the leading request")
+		}
+	}()
+
+	<-started
+	for i := 1; i < requests; i++ {
+		go func() {
+			defer wg.Done()
+			if code, body, err := get(); err != nil || code != http.StatusOK || body != `{"id":42}` {
+				errs <- errors.New("unexpected response of a coalesced request")
+			}
+		}()
+	}
+
+	// Leave the waiters the time to find the request in flight.
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if hits := atomic.LoadInt32(&hits); hits != 1 {
+		t.Errorf("rateLimitTransport.RoundTrip() hits = %v, want 1", hits)
+	}
+}
+
+func TestRateLimitTransport_coalesceLeaderCancelled(t *testing.T) {
+	var hits int32
+	started := make(chan struct{}, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			started <- struct{}{}
+			<-r.Context().Done()
+			return
+		}
+
+		_, _ = io.WriteString(w, `{"id":42}`)
+	}))
+	defer srv.Close()
+
+	transport := newRateLimitTransport(http.DefaultTransport)
+	newRequest := func(ctx context.Context) *http.Request {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/repos/octorun/octorun/actions/runners/42", nil)
+		return req
+	}
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	leaderErr := make(chan error, 1)
+	go func() {
+		res, err := transport.RoundTrip(newRequest(leaderCtx))
+		if err == nil {
+			_ = res.Body.Close()
+		}
+		leaderErr <- err
+	}()
+
+	<-started
+	type result struct {
+		res *http.Response
+		err error
+	}
+
+	waiter := make(chan result, 1)
+	go func() {
+		res, err := transport.RoundTrip(newRequest(context.Background()))
+		waiter <- result{res: res, err: err}
+	}()
+
+	// Leave the waiter the time to find the request in flight.
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("rateLimitTransport.RoundTrip() leader error = %v, want %v", err, context.Canceled)
+	}
+
+	got := <-waiter
+	if got.err != nil {
+		t.Fatalf("rateLimitTransport.RoundTrip() waiter error = %v, want nil", got.err)
+	}
+
+	defer got.res.Body.Close()
+	if got.res.StatusCode != http.StatusOK {
+		t.Errorf("rateLimitTransport.RoundTrip() waiter status = %v, want %v", got.res.StatusCode, http.StatusOK)
+	}
+	if hits := atomic.LoadInt32(&hits); hits != 2 {
+		t.Errorf("rateLimitTransport.RoundTrip() hits = %v, want 2", hits)
+	}
+}
+
+func TestRateLimitTransport_retryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		wantHits int32
+	}{
+		{name: "get_request", method: http.MethodGet, wantHits: 1},
+		{name: "post_request", method: http.MethodPost, wantHits: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&hits, 1)
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Retry-After", "60")
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = io.WriteString(w, `{"message":"You have exceeded a secondary rate limit.","documentation_url":"`+secondaryRateLimitDocumentationURL+`"}`)
+			}))
+			defer srv.Close()
+
+			transport := newRateLimitTransport(http.DefaultTransport)
+			for i := 0; i < 2; i++ {
+				req, _ := http.NewRequest(tt.method, srv.URL+"/orgs/octorun/actions/runners/registration-token", nil)
+				res, err := transport.RoundTrip(req)
+				if err != nil {
+					t.Fatalf("unexpected RoundTrip error: %v", err)
+				}
+
+				var abuseErr *github.AbuseRateLimitError
+				if err := github.CheckResponse(res); !errors.As(err, &abuseErr) {
+					t.Errorf("rateLimitTransport.RoundTrip() error = %v, want a github.AbuseRateLimitError", err)
+				} else if abuseErr.GetRetryAfter() <= 0 {
+					t.Errorf("rateLimitTransport.RoundTrip() retry after = %v, want > 0", abuseErr.GetRetryAfter())
+				}
+				_ = res.Body.Close()
+			}
+
+			if hits := atomic.LoadInt32(&hits); hits != tt.wantHits {
+				t.Errorf("rateLimitTransport.RoundTrip() hits = %v, want %v", hits, tt.wantHits)
+			}
+		})
+	}
+}
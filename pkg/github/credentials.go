@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+	"octorun.github.io/octorun/pkg/github/client"
+)
+
+// ErrInvalidCredentialSecret is returned when the GitHubCredential Secret does not hold a usable credential.
+var ErrInvalidCredentialSecret = errors.New("invalid GitHubCredential secret")
+
+// ClientGetter returns the Github Client authenticated with the GitHubCredential of the given key.
+type ClientGetter interface {
+	ClientFor(ctx context.Context, key crclient.ObjectKey) (Client, error)
+	// Forget drops the Github Client of the GitHubCredential with the given key, eg: once it is deleted.
+	Forget(key crclient.ObjectKey)
+}
+
+// CredentialClients builds a Github Client for every GitHubCredential and caches it
+// until either the GitHubCredential or its Secret has changed or been deleted.
+type CredentialClients struct {
+	reader   crclient.Reader
+	endpoint string
+
+	mu      sync.Mutex
+	clients map[crclient.ObjectKey]credentialClient
+}
+
+type credentialClient struct {
+	generation    int64
+	secretVersion string
+	client        *client.Client
+}
+
+var _ ClientGetter = &CredentialClients{}
+
+// NewCredentialClients returns CredentialClients that reads the GitHubCredentials and their Secrets
+// using the given reader. The clients use the Github API endpoint from the given Options.
+func NewCredentialClients(reader crclient.Reader, opts *Options) *CredentialClients {
+	return &CredentialClients{
+		reader:   reader,
+		endpoint: opts.APIEndpoint,
+		clients:  make(map[crclient.ObjectKey]credentialClient),
+	}
+}
+
+// ClientFor returns the Github Client of the GitHubCredential with the given key.
+// It returns a not found error if either the GitHubCredential or its Secret does not exist.
+func (c *CredentialClients) ClientFor(ctx context.Context, key crclient.ObjectKey) (Client, error) {
+	credential := &octorunv1.GitHubCredential{}
+	if err := c.reader.Get(ctx, key, credential); err != nil {
+		if apierrors.IsNotFound(err) {
+			c.Forget(key)
+		}
+
+		return nil, err
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.reader.Get(ctx, crclient.ObjectKey{Namespace: key.Namespace, Name: credential.Spec.SecretName}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			c.Forget(key)
+		}
+
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.clients[key]; ok &&
+		cached.generation == credential.Generation && cached.secretVersion == secret.ResourceVersion {
+		return cached.client, nil
+	}
+
+	opts, err := c.clientOptions(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	ghc, err := client.New(append(opts, client.WithName(key.String()))...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.clients[key] = credentialClient{
+		generation:    credential.Generation,
+		secretVersion: secret.ResourceVersion,
+		client:        ghc,
+	}
+
+	return ghc, nil
+}
+
+// Forget drops the cached Github Client of the GitHubCredential with the given key.
+func (c *CredentialClients) Forget(key crclient.ObjectKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.clients, key)
+}
+
+func (c *CredentialClients) clientOptions(secret *corev1.Secret) ([]client.ClientOption, error) {
+	opts := []client.ClientOption{client.WithEndpoint(c.endpoint)}
+	if token := secret.Data[octorunv1.GitHubCredentialTokenKey]; len(token) > 0 {
+		return append(opts, client.WithPersonalAccessToken(string(token))), nil
+	}
+
+	appID, err := strconv.ParseInt(string(secret.Data[octorunv1.GitHubCredentialAppIDKey]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s has neither a valid %q nor %q key", ErrInvalidCredentialSecret, secret.Name,
+			octorunv1.GitHubCredentialTokenKey, octorunv1.GitHubCredentialAppIDKey)
+	}
+
+	if len(secret.Data[octorunv1.GitHubCredentialPrivateKeyKey]) == 0 {
+		return nil, fmt.Errorf("%w: %s is missing the %q key", ErrInvalidCredentialSecret, secret.Name,
+			octorunv1.GitHubCredentialPrivateKeyKey)
+	}
+
+	return append(opts,
+		client.WithAppID(appID),
+		client.WithAppPrivateKeyPEM(secret.Data[octorunv1.GitHubCredentialPrivateKeyKey]),
+		client.WithInstallationID(string(secret.Data[octorunv1.GitHubCredentialInstallationIDKey])),
+	), nil
+}
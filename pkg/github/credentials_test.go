@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+)
+
+func TestCredentialClients_ClientFor(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+
+	tests := []struct {
+		name       string
+		deleteFn   func(credential *octorunv1.GitHubCredential, secret *corev1.Secret) crclient.Object
+		wantCached bool
+	}{
+		{
+			name:       "credential_unchanged",
+			deleteFn:   func(credential *octorunv1.GitHubCredential, secret *corev1.Secret) crclient.Object { return nil },
+			wantCached: true,
+		},
+		{
+			name:       "credential_deleted",
+			deleteFn:   func(credential *octorunv1.GitHubCredential, secret *corev1.Secret) crclient.Object { return credential },
+			wantCached: false,
+		},
+		{
+			name:       "secret_deleted",
+			deleteFn:   func(credential *octorunv1.GitHubCredential, secret *corev1.Secret) crclient.Object { return secret },
+			wantCached: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			credential := &octorunv1.GitHubCredential{
+				ObjectMeta: metav1.ObjectMeta{Name: "credential-test", Namespace: "default"},
+				Spec:       octorunv1.GitHubCredentialSpec{SecretName: "octorun-github"},
+			}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "octorun-github", Namespace: "default"},
+				Data:       map[string][]byte{octorunv1.GitHubCredentialTokenKey: []byte("faketoken")},
+			}
+
+			fakec := fake.NewClientBuilder().WithScheme(scheme).WithObjects(credential, secret).Build()
+			credentials := NewCredentialClients(fakec, &Options{})
+			key := crclient.ObjectKeyFromObject(credential)
+			first, err := credentials.ClientFor(ctx, key)
+			if err != nil {
+				t.Fatalf("CredentialClients.ClientFor() error = %v", err)
+			}
+
+			if obj := tt.deleteFn(credential, secret); obj != nil {
+				if err := fakec.Delete(ctx, obj); err != nil {
+					t.Fatalf("unable to delete %s: %v", obj.GetName(), err)
+				}
+			}
+
+			second, err := credentials.ClientFor(ctx, key)
+			if tt.wantCached {
+				if err != nil || second != first {
+					t.Errorf("CredentialClients.ClientFor() = %v, %v, want the cached client", second, err)
+				}
+			} else if !apierrors.IsNotFound(err) {
+				t.Errorf("CredentialClients.ClientFor() error = %v, want not found", err)
+			}
+
+			if _, cached := credentials.clients[key]; cached != tt.wantCached {
+				t.Errorf("CredentialClients.ClientFor() cached = %v, want %v", cached, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestCredentialClients_Forget(t *testing.T) {
+	key := crclient.ObjectKey{Namespace: "default", Name: "credential-test"}
+	other := crclient.ObjectKey{Namespace: "default", Name: "credential-other"}
+	credentials := NewCredentialClients(nil, &Options{})
+	credentials.clients[key] = credentialClient{}
+	credentials.clients[other] = credentialClient{}
+
+	credentials.Forget(key)
+	if _, ok := credentials.clients[key]; ok {
+		t.Errorf("CredentialClients.Forget() kept %v", key)
+	}
+	if _, ok := credentials.clients[other]; !ok {
+		t.Errorf("CredentialClients.Forget() dropped %v", other)
+	}
+}
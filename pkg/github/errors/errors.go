@@ -17,18 +17,47 @@ limitations under the License.
 package errors
 
 import (
+	stderrors "errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/google/go-github/v41/github"
 )
 
-// IsReteLimit returns true if given error is github.RateLimitError
+// defaultSecondaryRateLimitRetryAfter is used when a secondary rate limit has no Retry-After.
+const defaultSecondaryRateLimitRetryAfter = time.Minute
+
+// IsReteLimit returns true if given error is either a Github primary
+// or secondary rate limit error.
 func IsReteLimit(err error) bool {
-	if _, ok := err.(*github.RateLimitError); ok {
-		return true
+	_, ok := RateLimitRetryAfter(err)
+	return ok
+}
+
+// RateLimitRetryAfter returns how long to wait before retrying if given error is either
+// github.RateLimitError, github.AbuseRateLimitError or github.ErrorResponse having a
+// Retry-After header (i.e. a secondary rate limit).
+func RateLimitRetryAfter(err error) (time.Duration, bool) {
+	var rateLimitErr *github.RateLimitError
+	if stderrors.As(err, &rateLimitErr) {
+		return atLeastOneSecond(time.Until(rateLimitErr.Rate.Reset.Time)), true
 	}
 
-	return false
+	var abuseRateLimitErr *github.AbuseRateLimitError
+	if stderrors.As(err, &abuseRateLimitErr) {
+		if abuseRateLimitErr.RetryAfter != nil {
+			return atLeastOneSecond(*abuseRateLimitErr.RetryAfter), true
+		}
+
+		return defaultSecondaryRateLimitRetryAfter, true
+	}
+
+	if rerr := parseErrorResponse(err); rerr != nil {
+		return secondaryRateLimitRetryAfter(rerr)
+	}
+
+	return 0, false
 }
 
 // IsBadRequest returns true if given error is github.ErrorResponse
@@ -53,8 +82,14 @@ func IsUnauthorized(err error) bool {
 
 // IsForbidden returns true if given error is github.ErrorResponse
 // and http response status code is http.StatusForbidden
+// unless the response is a secondary rate limit.
 func IsForbidden(err error) bool {
-	if rerr, ok := err.(*github.ErrorResponse); ok {
+	var rerr *github.ErrorResponse
+	if stderrors.As(err, &rerr) {
+		if _, ok := secondaryRateLimitRetryAfter(rerr.Response); ok {
+			return false
+		}
+
 		return rerr.Response.StatusCode == http.StatusForbidden
 	}
 
@@ -101,10 +136,34 @@ func IsGone(err error) bool {
 	return false
 }
 
+// parseErrorResponse returns the response of the github.ErrorResponse given error is or wraps.
+// eg: the Github App installation errors are wrapped by the url.Error of the http client.
 func parseErrorResponse(err error) *http.Response {
-	if rerr, ok := err.(*github.ErrorResponse); ok {
+	var rerr *github.ErrorResponse
+	if stderrors.As(err, &rerr) {
 		return rerr.Response
 	}
 
 	return nil
 }
+
+func secondaryRateLimitRetryAfter(res *http.Response) (time.Duration, bool) {
+	if res.StatusCode != http.StatusForbidden && res.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	seconds, err := strconv.ParseInt(res.Header.Get("Retry-After"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return atLeastOneSecond(time.Duration(seconds) * time.Second), true
+}
+
+func atLeastOneSecond(d time.Duration) time.Duration {
+	if d < time.Second {
+		return time.Second
+	}
+
+	return d
+}
@@ -17,12 +17,17 @@ limitations under the License.
 package github
 
 import (
+	"context"
+
 	"octorun.github.io/octorun/pkg/github/client"
 	"octorun.github.io/octorun/pkg/github/webhook"
 )
 
 type Client interface {
 	client.ActionClient
+
+	// Authenticate checks that the client credential is accepted by Github.
+	Authenticate(ctx context.Context) error
 }
 
 type Github struct {
@@ -39,7 +39,7 @@ func (o *Options) BindFlags(fs *flag.FlagSet) {
 	fs.StringVar(&o.AppPrivateKey, "github-app-private-key", "",
 		"Path to Github App Private Key file. Required if Github App ID is set.")
 	fs.StringVar(&o.AppInstallationID, "github-app-installation-id", "",
-		"The Github App installation ID. Required if Github App ID is set.")
+		"The Github App installation ID. If not set, the installation is looked up for the owner of each Runner URL.")
 	fs.StringVar(&o.WebhookAddress, "github-webook-address", ":9090", "The Address for Github webhook server.")
 	fs.StringVar(&o.WebhookPath, "github-webhook-path", "/", "The url path for Github webhook handler.")
 	fs.StringVar(&o.WebhookSecret, "github-webhook-secret", "", "The Github webhook secret.")
@@ -16,10 +16,29 @@ limitations under the License.
 
 package webhook
 
-import "context"
+import (
+	"context"
+
+	"github.com/google/go-github/v41/github"
+)
 
 type Request struct {
 	Event interface{}
+
+	// Enterprise is the enterprise the event belongs to, if any. It is decoded separately from
+	// the event since go-github only decodes it for a few event types.
+	Enterprise *github.Enterprise
+
+	// WorkflowJob is the workflow job of a workflow_job event, if any. It is decoded separately
+	// from the event for the workflow job fields go-github does not decode yet.
+	WorkflowJob *WorkflowJob
+}
+
+// WorkflowJob holds the workflow job fields of a workflow_job event go-github does not decode yet.
+type WorkflowJob struct {
+	WorkflowName string            `json:"workflow_name,omitempty"`
+	HeadBranch   string            `json:"head_branch,omitempty"`
+	CreatedAt    *github.Timestamp `json:"created_at,omitempty"`
 }
 
 // Handler can handle a Webhook.
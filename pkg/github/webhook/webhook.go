@@ -18,6 +18,7 @@ package webhook
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -65,6 +66,16 @@ func (wh *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	wh.Handle(r.Context(), Request{Event: event})
+	var extra struct {
+		Enterprise  *github.Enterprise `json:"enterprise,omitempty"`
+		WorkflowJob *WorkflowJob       `json:"workflow_job,omitempty"`
+	}
+
+	if err := json.Unmarshal(payload, &extra); err != nil {
+		http.Error(w, fmt.Sprintf("unable to parse webhook. err: %+v", err), http.StatusBadRequest)
+		return
+	}
+
+	wh.Handle(r.Context(), Request{Event: event, Enterprise: extra.Enterprise, WorkflowJob: extra.WorkflowJob})
 	w.WriteHeader(http.StatusOK)
 }
@@ -7,6 +7,15 @@ runner::prepare() {
     exit 1
   fi
 
+  if [ -n "$RUNNER_JITCONFIG_FILE" ]; then
+    if [ ! -f "$RUNNER_JITCONFIG_FILE" ]; then
+      echo 1>&2 "error: missing runner JIT config file $RUNNER_JITCONFIG_FILE"
+      exit 1
+    fi
+
+    return
+  fi
+
   RUNNER_TOKEN_FILE=${RUNNER_TOKEN_FILE:-.token}
   if [ ! -f "$RUNNER_TOKEN_FILE" ]; then
     if [ -z "$RUNNER_TOKEN" ]; then
@@ -19,6 +28,12 @@ runner::prepare() {
 }
 
 runner::cleanup() {
+  # JIT runners are removed by Github once they have run a job, otherwise
+  # by the controller once the runner pod has gone.
+  if [ -n "$RUNNER_JITCONFIG_FILE" ]; then
+    return
+  fi
+
   if [ -f .runner ]; then
     echo "Teardown. Github Action Runner ..."
     while true; do
@@ -66,23 +81,53 @@ dockerd::shutdown() {
   done
 }
 
+dockerd::wait() {
+  retry=12
+  while true; do
+    [ -S "${DOCKER_HOST#unix://}" ] && break
+    if [[ $retry -le 0 ]]; then
+      echo "Reached maximum attempts, not waiting any longer..."
+      exit 1
+    fi
+
+    echo "Waiting for docker sidecar to be ready, sleeping for 5 seconds."
+    retry=$((retry-1))
+    sleep 5
+  done
+}
+
 runner::prepare
-if type dockerd-entrypoint.sh &>/dev/null; then
+if [ -n "$DOCKERD_SIDECAR_EXIT_FILE" ]; then
+  echo "Waiting for Docker Daemon sidecar ..."
+  dockerd::wait
+elif type dockerd-entrypoint.sh &>/dev/null; then
   echo "Starting Docker Daemon ..."
   dockerd::start
 fi
 
-echo "Configuring Github Action Runner ..."
 unset RUNNER_TOKEN
 source /runner/env.sh
-/runner/config.sh --unattended \
-  --url "${URL}" \
-  --name "${RUNNER_NAME}" \
-  --token $(cat "$RUNNER_TOKEN_FILE") \
-  --labels "${RUNNER_LABELS}" \
-  --runnergroup "${RUNNER_GROUP:-Default}" \
-  --work "${RUNNER_WORKDIR:-_work}" \
-  --replace --ephemeral --disableupdate > /dev/null & wait $!
+if [ -z "$RUNNER_JITCONFIG_FILE" ]; then
+  # Persistent runners keep taking jobs, ephemeral runners exit after a single job.
+  ephemeral=--ephemeral
+  if [ "$RUNNER_LIFECYCLE" = "Persistent" ]; then
+    ephemeral=
+  fi
+
+  echo "Configuring Github Action Runner ..."
+  /runner/config.sh --unattended \
+    --url "${URL}" \
+    --name "${RUNNER_NAME}" \
+    --token $(cat "$RUNNER_TOKEN_FILE") \
+    --labels "${RUNNER_LABELS}" \
+    --runnergroup "${RUNNER_GROUP:-Default}" \
+    --work "${RUNNER_WORKDIR:-_work}" \
+    --replace $ephemeral --disableupdate > /dev/null & wait $!
+else
+  # The runner is already registered by the controller, the JIT config
+  # contains the runner name, labels, group and work directory.
+  set -- --jitconfig "$(cat "$RUNNER_JITCONFIG_FILE")" "$@"
+fi
 
 trap 'runner::cleanup; exit 130' INT
 trap 'runner::cleanup; exit 143' TERM
@@ -94,3 +139,10 @@ if [ -f $dockerd_pid ]; then
   echo "Shutdown Docker Daemon ..."
   dockerd::shutdown
 fi
+
+# Stop the Docker Daemon sidecar once the ephemeral runner has completed its job,
+# the persistent runner container is restarted and keeps using the sidecar.
+if [ -n "$DOCKERD_SIDECAR_EXIT_FILE" ] && [ "$RUNNER_LIFECYCLE" != "Persistent" ]; then
+  echo "Shutdown Docker Daemon sidecar ..."
+  touch "$DOCKERD_SIDECAR_EXIT_FILE"
+fi
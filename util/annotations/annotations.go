@@ -17,6 +17,7 @@ limitations under the License.
 package annotations
 
 import (
+	"strconv"
 	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -58,3 +59,178 @@ func IsTokenExpired(obj client.Object) bool {
 
 	return exp.Before(n.Add(5 * time.Minute))
 }
+
+// AnnotateJob give annotations to given runner about the details of the workflow job it has picked up.
+// The job completion annotations of a previous job are removed, so a persistent runner does not look
+// like it has completed the new job already.
+func AnnotateJob(obj client.Object, job *octorunv1.RunnerJobStatus) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	id := strconv.FormatInt(job.ID, 10)
+	if annotations[octorunv1.AnnotationRunnerJobID] != id {
+		delete(annotations, octorunv1.AnnotationRunnerJobCompletedAt)
+		delete(annotations, octorunv1.AnnotationRunnerJobConclusion)
+	}
+
+	annotations[octorunv1.AnnotationRunnerJobID] = id
+	annotations[octorunv1.AnnotationRunnerJobName] = job.Name
+	annotations[octorunv1.AnnotationRunnerJobRunID] = strconv.FormatInt(job.RunID, 10)
+	annotations[octorunv1.AnnotationRunnerJobWorkflow] = job.WorkflowName
+	annotations[octorunv1.AnnotationRunnerJobRepository] = job.Repository
+	annotations[octorunv1.AnnotationRunnerJobHeadBranch] = job.HeadBranch
+	annotations[octorunv1.AnnotationRunnerJobURL] = job.URL
+	obj.SetAnnotations(annotations)
+}
+
+// Job returns the details of the workflow job given runner has picked up.
+// It returns nil if there is no job-id annotation or format is invalid.
+func Job(obj client.Object) *octorunv1.RunnerJobStatus {
+	annotations := obj.GetAnnotations()
+	id, err := strconv.ParseInt(annotations[octorunv1.AnnotationRunnerJobID], 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	// The run id is informative only, a missing or invalid value is left empty.
+	runID, _ := strconv.ParseInt(annotations[octorunv1.AnnotationRunnerJobRunID], 10, 64)
+	return &octorunv1.RunnerJobStatus{
+		ID:           id,
+		Name:         annotations[octorunv1.AnnotationRunnerJobName],
+		RunID:        runID,
+		WorkflowName: annotations[octorunv1.AnnotationRunnerJobWorkflow],
+		Repository:   annotations[octorunv1.AnnotationRunnerJobRepository],
+		HeadBranch:   annotations[octorunv1.AnnotationRunnerJobHeadBranch],
+		URL:          annotations[octorunv1.AnnotationRunnerJobURL],
+	}
+}
+
+// AnnotateJobCompleted give an annotation to given runner
+// about its workflow job completion time and conclusion.
+func AnnotateJobCompleted(obj client.Object, completedAt time.Time, conclusion string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[octorunv1.AnnotationRunnerJobCompletedAt] = completedAt.UTC().Format(time.RFC3339)
+	annotations[octorunv1.AnnotationRunnerJobConclusion] = conclusion
+	obj.SetAnnotations(annotations)
+}
+
+// JobCompletedAt returns the time the workflow job of given runner has completed.
+// It returns false if there is no job-completed-at annotation or format is invalid.
+func JobCompletedAt(obj client.Object) (time.Time, bool) {
+	completedAt, ok := obj.GetAnnotations()[octorunv1.AnnotationRunnerJobCompletedAt]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339, completedAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// JobConclusion returns the conclusion of the completed workflow job of given runner.
+func JobConclusion(obj client.Object) string {
+	return obj.GetAnnotations()[octorunv1.AnnotationRunnerJobConclusion]
+}
+
+// AnnotateOnDemand give an annotation to given runner
+// to mark it as an on-demand runner.
+func AnnotateOnDemand(obj client.Object) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[octorunv1.AnnotationRunnerOnDemand] = "true"
+	obj.SetAnnotations(annotations)
+}
+
+// AnnotateOnDemandJobID give an annotation to given on-demand runner
+// about the ID of the queued workflow job it was created for.
+func AnnotateOnDemandJobID(obj client.Object, jobID int64) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[octorunv1.AnnotationRunnerOnDemandJobID] = strconv.FormatInt(jobID, 10)
+	obj.SetAnnotations(annotations)
+}
+
+// OnDemandJobID returns the ID of the queued workflow job given on-demand runner was created for.
+// It returns 0 if there is no on-demand-job-id annotation or format is invalid.
+func OnDemandJobID(obj client.Object) int64 {
+	jobID, err := strconv.ParseInt(obj.GetAnnotations()[octorunv1.AnnotationRunnerOnDemandJobID], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return jobID
+}
+
+// IsOnDemand determines if given runner was created on demand
+// for a single queued workflow job.
+func IsOnDemand(obj client.Object) bool {
+	return obj.GetAnnotations()[octorunv1.AnnotationRunnerOnDemand] == "true"
+}
+
+// AnnotateProvisioned give an annotation to given runner
+// to mark it as online at least once.
+func AnnotateProvisioned(obj client.Object) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[octorunv1.AnnotationRunnerProvisioned] = "true"
+	obj.SetAnnotations(annotations)
+}
+
+// IsProvisioned determines if given runner has been online at least once.
+func IsProvisioned(obj client.Object) bool {
+	return obj.GetAnnotations()[octorunv1.AnnotationRunnerProvisioned] == "true"
+}
+
+// AnnotateRegistrationCredentialVersion give an annotation to given runner
+// about the version of the credential it has failed to register with.
+func AnnotateRegistrationCredentialVersion(obj client.Object, version string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[octorunv1.AnnotationRunnerRegistrationCredentialVersion] = version
+	obj.SetAnnotations(annotations)
+}
+
+// RegistrationCredentialVersion returns the version of the credential given runner
+// has failed to register with or an empty string if it is not known.
+func RegistrationCredentialVersion(obj client.Object) string {
+	return obj.GetAnnotations()[octorunv1.AnnotationRunnerRegistrationCredentialVersion]
+}
+
+// AnnotateVolumeClaimedBy give an annotation to given volume pool
+// PersistentVolumeClaim about the runner it is handed out to.
+func AnnotateVolumeClaimedBy(obj client.Object, runnerName string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[octorunv1.AnnotationVolumeClaimedBy] = runnerName
+	obj.SetAnnotations(annotations)
+}
+
+// VolumeClaimedBy returns the runner name given volume pool PersistentVolumeClaim
+// is handed out to or an empty string if it is not handed out.
+func VolumeClaimedBy(obj client.Object) string {
+	return obj.GetAnnotations()[octorunv1.AnnotationVolumeClaimedBy]
+}
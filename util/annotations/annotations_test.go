@@ -147,3 +147,293 @@ func TestIsTokenExpired(t *testing.T) {
 		})
 	}
 }
+
+func TestIsOnDemand(t *testing.T) {
+	tests := []struct {
+		name   string
+		runner *octorunv1.Runner
+		want   bool
+	}{
+		{
+			name: "runner_without_on_demand_annotation",
+			runner: &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-runner",
+					Namespace: "test-namespace",
+				},
+			},
+			want: false,
+		},
+		{
+			name: "runner_annotated_on_demand",
+			runner: func() *octorunv1.Runner {
+				runner := &octorunv1.Runner{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-runner",
+						Namespace: "test-namespace",
+					},
+				}
+
+				AnnotateOnDemand(runner)
+				return runner
+			}(),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsOnDemand(tt.runner); got != tt.want {
+				t.Errorf("IsOnDemand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnDemandJobID(t *testing.T) {
+	tests := []struct {
+		name   string
+		runner *octorunv1.Runner
+		want   int64
+	}{
+		{
+			name: "runner_without_on_demand_job_id_annotation",
+			runner: &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-runner",
+					Namespace: "test-namespace",
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "runner_with_invalid_on_demand_job_id_annotation",
+			runner: &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-runner",
+					Namespace:   "test-namespace",
+					Annotations: map[string]string{octorunv1.AnnotationRunnerOnDemandJobID: "invalid"},
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "runner_annotated_on_demand_job_id",
+			runner: func() *octorunv1.Runner {
+				runner := &octorunv1.Runner{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-runner",
+						Namespace: "test-namespace",
+					},
+				}
+
+				AnnotateOnDemandJobID(runner, 4242)
+				return runner
+			}(),
+			want: 4242,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OnDemandJobID(tt.runner); got != tt.want {
+				t.Errorf("OnDemandJobID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsProvisioned(t *testing.T) {
+	tests := []struct {
+		name   string
+		runner *octorunv1.Runner
+		want   bool
+	}{
+		{
+			name: "runner_without_provisioned_annotation",
+			runner: &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-runner",
+					Namespace: "test-namespace",
+				},
+			},
+			want: false,
+		},
+		{
+			name: "runner_annotated_provisioned",
+			runner: func() *octorunv1.Runner {
+				runner := &octorunv1.Runner{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-runner",
+						Namespace: "test-namespace",
+					},
+				}
+
+				AnnotateProvisioned(runner)
+				return runner
+			}(),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsProvisioned(tt.runner); got != tt.want {
+				t.Errorf("IsProvisioned() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobCompletedAt(t *testing.T) {
+	completedAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		runner         *octorunv1.Runner
+		want           time.Time
+		wantOk         bool
+		wantConclusion string
+	}{
+		{
+			name: "runner_without_job_completed_at_annotation",
+			runner: &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-runner",
+					Namespace: "test-namespace",
+				},
+			},
+			want:   time.Time{},
+			wantOk: false,
+		},
+		{
+			name: "runner_with_invalid_job_completed_at_annotation",
+			runner: &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-runner",
+					Namespace: "test-namespace",
+					Annotations: map[string]string{
+						octorunv1.AnnotationRunnerJobCompletedAt: "invalid",
+					},
+				},
+			},
+			want:   time.Time{},
+			wantOk: false,
+		},
+		{
+			name: "runner_annotated_job_completed",
+			runner: func() *octorunv1.Runner {
+				runner := &octorunv1.Runner{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-runner",
+						Namespace: "test-namespace",
+					},
+				}
+
+				AnnotateJobCompleted(runner, completedAt, "success")
+				return runner
+			}(),
+			want:           completedAt,
+			wantOk:         true,
+			wantConclusion: "success",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := JobCompletedAt(tt.runner)
+			if !got.Equal(tt.want) || ok != tt.wantOk {
+				t.Errorf("JobCompletedAt() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+			if got := JobConclusion(tt.runner); got != tt.wantConclusion {
+				t.Errorf("JobConclusion() = %v, want %v", got, tt.wantConclusion)
+			}
+		})
+	}
+}
+
+func TestJob(t *testing.T) {
+	job := &octorunv1.RunnerJobStatus{
+		ID:           1,
+		Name:         "build",
+		RunID:        2,
+		WorkflowName: "CI",
+		Repository:   "octorun/octorun",
+		HeadBranch:   "main",
+		URL:          "https://github.com/octorun/octorun/runs/1",
+	}
+
+	tests := []struct {
+		name              string
+		runner            *octorunv1.Runner
+		want              *octorunv1.RunnerJobStatus
+		wantJobCompletion bool
+	}{
+		{
+			name: "runner_without_job_annotations",
+			runner: &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-runner",
+					Namespace: "test-namespace",
+				},
+			},
+			want: nil,
+		},
+		{
+			name: "runner_annotated_job",
+			runner: func() *octorunv1.Runner {
+				runner := &octorunv1.Runner{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-runner",
+						Namespace: "test-namespace",
+					},
+				}
+
+				AnnotateJob(runner, job)
+				return runner
+			}(),
+			want: job,
+		},
+		{
+			name: "runner_annotated_job_completed_then_same_job",
+			runner: func() *octorunv1.Runner {
+				runner := &octorunv1.Runner{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-runner",
+						Namespace: "test-namespace",
+					},
+				}
+
+				AnnotateJob(runner, job)
+				AnnotateJobCompleted(runner, time.Now(), "success")
+				AnnotateJob(runner, job)
+				return runner
+			}(),
+			want:              job,
+			wantJobCompletion: true,
+		},
+		{
+			name: "runner_annotated_job_completed_then_another_job",
+			runner: func() *octorunv1.Runner {
+				runner := &octorunv1.Runner{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-runner",
+						Namespace: "test-namespace",
+					},
+				}
+
+				AnnotateJob(runner, &octorunv1.RunnerJobStatus{ID: 3})
+				AnnotateJobCompleted(runner, time.Now(), "success")
+				AnnotateJob(runner, job)
+				return runner
+			}(),
+			want:              job,
+			wantJobCompletion: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Job(tt.runner); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Job() = %v, want %v", got, tt.want)
+			}
+			if _, ok := JobCompletedAt(tt.runner); ok != tt.wantJobCompletion {
+				t.Errorf("JobCompletedAt() ok = %v, want %v", ok, tt.wantJobCompletion)
+			}
+		})
+	}
+}
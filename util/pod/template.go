@@ -0,0 +1,91 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// MergePodTemplate strategically merges the given pod template on top of the given pod.
+// Containers, init containers, env and volumes are merged by their name, so a template
+// container named like an existing container overrides only the fields set on it.
+func MergePodTemplate(pod *corev1.Pod, template *corev1.PodTemplateSpec) error {
+	original, err := json.Marshal(pod)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal pod")
+	}
+
+	patch, err := templatePatch(template)
+	if err != nil {
+		return err
+	}
+
+	merged, err := strategicpatch.StrategicMergePatch(original, patch, corev1.Pod{})
+	if err != nil {
+		return errors.Wrap(err, "failed to merge pod template")
+	}
+
+	mergedPod := &corev1.Pod{}
+	if err := json.Unmarshal(merged, mergedPod); err != nil {
+		return errors.Wrap(err, "failed to unmarshal merged pod")
+	}
+
+	*pod = *mergedPod
+	return nil
+}
+
+// templatePatch returns the given pod template as a strategic merge patch.
+// Null fields are dropped from the patch since a null value in a strategic
+// merge patch deletes the field, eg: the required containers field would
+// remove every container of the pod when the template has no container.
+func templatePatch(template *corev1.PodTemplateSpec) ([]byte, error) {
+	raw, err := json.Marshal(template)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal pod template")
+	}
+
+	var patch map[string]interface{}
+	if err := json.Unmarshal(raw, &patch); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal pod template")
+	}
+
+	return json.Marshal(dropNulls(patch))
+}
+
+func dropNulls(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if value == nil {
+				delete(v, key)
+				continue
+			}
+
+			v[key] = dropNulls(value)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = dropNulls(v[i])
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestMergePodTemplate(t *testing.T) {
+	podFn := func() *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "runner-test",
+				Annotations: map[string]string{"foo": "bar"},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name:  "runner",
+						Image: "ghcr.io/octorun/runner",
+						Env: []corev1.EnvVar{
+							{Name: "URL", Value: "https://github.com/octorun"},
+						},
+					},
+				},
+				SecurityContext: &corev1.PodSecurityContext{
+					RunAsUser:  pointer.Int64(1000),
+					RunAsGroup: pointer.Int64(1000),
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		template *corev1.PodTemplateSpec
+		want     func() *corev1.Pod
+	}{
+		{
+			name:     "empty_template",
+			template: &corev1.PodTemplateSpec{},
+			want:     podFn,
+		},
+		{
+			name: "template_without_containers",
+			template: &corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{"baz": "qux"},
+				},
+				Spec: corev1.PodSpec{
+					PriorityClassName: "runner",
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsUser: pointer.Int64(2000),
+					},
+				},
+			},
+			want: func() *corev1.Pod {
+				pod := podFn()
+				pod.Annotations["baz"] = "qux"
+				pod.Spec.PriorityClassName = "runner"
+				pod.Spec.SecurityContext.RunAsUser = pointer.Int64(2000)
+				return pod
+			},
+		},
+		{
+			name: "template_with_runner_container_and_sidecar",
+			template: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "runner",
+							Env: []corev1.EnvVar{
+								{Name: "DOCKER_HOST", Value: "tcp://localhost:2375"},
+							},
+						},
+						{
+							Name:  "sidecar",
+							Image: "busybox",
+						},
+					},
+				},
+			},
+			want: func() *corev1.Pod {
+				pod := podFn()
+				pod.Spec.Containers[0].Env = append([]corev1.EnvVar{
+					{Name: "DOCKER_HOST", Value: "tcp://localhost:2375"},
+				}, pod.Spec.Containers[0].Env...)
+				pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{
+					Name:  "sidecar",
+					Image: "busybox",
+				})
+				return pod
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := podFn()
+			if err := MergePodTemplate(pod, tt.template); err != nil {
+				t.Errorf("MergePodTemplate() error = %v", err)
+				return
+			}
+			if want := tt.want(); !reflect.DeepEqual(pod, want) {
+				t.Errorf("MergePodTemplate() = %v, want %v", pod, want)
+			}
+		})
+	}
+}
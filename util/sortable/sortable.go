@@ -34,7 +34,7 @@ func (r RunnersToDelete) Len() int      { return len(r) }
 func (r RunnersToDelete) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
 func (r RunnersToDelete) Less(i, j int) bool {
 	priority := func(runner *octorunv1.Runner) float64 {
-		if !runner.GetDeletionTimestamp().IsZero() {
+		if !runner.GetDeletionTimestamp().IsZero() || runner.Status.Phase == octorunv1.RunnerFailedPhase {
 			return mustDelete
 		}
 
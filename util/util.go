@@ -21,15 +21,22 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
 	"octorun.github.io/octorun/util/remoteexec"
 )
 
+// systemRunnerLabels are labels that Github assigns to every self-hosted
+// runner on its own. A workflow job may target them without the runner
+// having them in the octorun labels.
+var systemRunnerLabels = []string{"self-hosted", "linux", "x64", "arm64"}
+
 // RandomString returns a random alphanumeric string.
 func RandomString(n int) string {
 	charset := "0123456789abcdefghijklmnopqrstuvwxyz"
@@ -59,3 +66,64 @@ func FindRunnerIDFromPod(pod *corev1.Pod, remoteexec remoteexec.RemoteExecutor)
 
 	return runnerID, nil
 }
+
+// RunnerLabels returns the Github runner labels from given object labels.
+// Only labels with octorun.github.io/ prefix are passed to the Github runner
+// and the prefix is trimmed. eg: "octorun.github.io/foo": "bar" will be "foo=bar".
+func RunnerLabels(labels map[string]string) []string {
+	runnerLabels := make([]string, 0)
+	for k, v := range labels {
+		if !strings.HasPrefix(k, octorunv1.LabelPrefix) {
+			continue
+		}
+
+		runnerLabel := strings.TrimPrefix(k, octorunv1.LabelPrefix) + "=" + v
+		runnerLabels = append(runnerLabels, runnerLabel)
+	}
+
+	sort.Strings(runnerLabels)
+	return runnerLabels
+}
+
+// MatchRunnerLabels returns true if every given workflow job labels (runs-on) are
+// satisfied by given runner labels. Github runner labels are case insensitive.
+func MatchRunnerLabels(jobLabels []string, runnerLabels []string) bool {
+	if len(jobLabels) == 0 {
+		return false
+	}
+
+	available := make(map[string]struct{}, len(runnerLabels)+len(systemRunnerLabels))
+	for _, l := range append(runnerLabels, systemRunnerLabels...) {
+		available[strings.ToLower(l)] = struct{}{}
+	}
+
+	for _, l := range jobLabels {
+		if _, ok := available[strings.ToLower(l)]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// VolumePoolVolume returns the runner volume of the given RunnerSet volume pool backed by the
+// PersistentVolumeClaim with given claimName. It returns an emptyDir volume if claimName is empty.
+func VolumePoolVolume(pool *octorunv1.RunnerSetVolumePool, claimName string) corev1.Volume {
+	if claimName == "" {
+		return corev1.Volume{
+			Name: pool.Name,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		}
+	}
+
+	return corev1.Volume{
+		Name: pool.Name,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: claimName,
+			},
+		},
+	}
+}
@@ -19,6 +19,7 @@ package util
 import (
 	"bytes"
 	"errors"
+	"reflect"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
@@ -111,3 +112,85 @@ func TestFindRunnerIDFromPod(t *testing.T) {
 		})
 	}
 }
+
+func TestRunnerLabels(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   []string
+	}{
+		{
+			name:   "nil_labels",
+			labels: nil,
+			want:   []string{},
+		},
+		{
+			name: "only_prefixed_labels",
+			labels: map[string]string{
+				"octorun.github.io/os":   "linux",
+				"octorun.github.io/arch": "x64",
+				"app":                    "foo",
+			},
+			want: []string{"arch=x64", "os=linux"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RunnerLabels(tt.labels); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RunnerLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchRunnerLabels(t *testing.T) {
+	type args struct {
+		jobLabels    []string
+		runnerLabels []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "job_has_no_labels",
+			args: args{
+				jobLabels:    nil,
+				runnerLabels: []string{"os=linux"},
+			},
+			want: false,
+		},
+		{
+			name: "job_has_system_labels_only",
+			args: args{
+				jobLabels:    []string{"self-hosted", "Linux"},
+				runnerLabels: nil,
+			},
+			want: true,
+		},
+		{
+			name: "runner_has_all_job_labels",
+			args: args{
+				jobLabels:    []string{"self-hosted", "os=linux", "GPU=true"},
+				runnerLabels: []string{"gpu=true", "os=linux"},
+			},
+			want: true,
+		},
+		{
+			name: "runner_missing_job_label",
+			args: args{
+				jobLabels:    []string{"self-hosted", "os=windows"},
+				runnerLabels: []string{"os=linux"},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchRunnerLabels(tt.args.jobLabels, tt.args.runnerLabels); got != tt.want {
+				t.Errorf("MatchRunnerLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -20,9 +20,15 @@ package webhooks
 import "regexp"
 
 const (
-	invalidURLMessage = "Must be Github Org or Repository URL. eg: https://github.com/org or https://github.com/org/repo"
+	invalidURLMessage = "Must be Github Enterprise, Org or Repository URL. eg: https://github.com/enterprises/enterprise, https://github.com/org or https://github.com/org/repo"
+
+	invalidLifecycleMessage = "Persistent lifecycle requires Token registration mode. JIT runners are always ephemeral"
+
+	invalidServiceAccountMessage = "Kubernetes container mode uses the container hooks ServiceAccount created by the controller"
+
+	invalidMinRunnersMessage = "Must not be greater than `maxRunners`"
 )
 
 var (
-	matchOrgOrRepoURLRegexp = regexp.MustCompile(`https?:\/\/github\.com\/[^\/]+(?:\/[^\/]+)?$`)
+	matchRunnerURLRegexp = regexp.MustCompile(`https?:\/\/github\.com\/(?:enterprises\/[^\/]+|[^\/]+(?:\/[^\/]+)?)$`)
 )
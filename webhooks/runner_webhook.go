@@ -23,6 +23,7 @@ import (
 
 	"github.com/pkg/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -86,10 +87,18 @@ func (w *RunnerWebhook) ValidateCreate(ctx context.Context, obj runtime.Object)
 		return apierrors.NewBadRequest(fmt.Sprintf("expected a Runner but got a %T", obj))
 	}
 
-	if !matchOrgOrRepoURLRegexp.MatchString(runner.Spec.URL) {
+	if !matchRunnerURLRegexp.MatchString(runner.Spec.URL) {
 		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "url"), runner.Spec.URL, invalidURLMessage))
 	}
 
+	if runner.Spec.Lifecycle == octorunv1.RunnerLifecyclePersistent && runner.Spec.RegistrationMode == octorunv1.RunnerRegistrationJIT {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "lifecycle"), runner.Spec.Lifecycle, invalidLifecycleMessage))
+	}
+
+	if runner.Spec.ContainerMode == octorunv1.RunnerContainerModeKubernetes && runner.Spec.ServiceAccountName != "" {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "serviceAccountName"), runner.Spec.ServiceAccountName, invalidServiceAccountMessage))
+	}
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -99,6 +108,13 @@ func (w *RunnerWebhook) ValidateCreate(ctx context.Context, obj runtime.Object)
 
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
 func (w *RunnerWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	if runner, ok := oldObj.(*octorunv1.Runner); ok && runner.Status.Phase == octorunv1.RunnerFailedPhase &&
+		meta.IsStatusConditionTrue(runner.Status.Conditions, octorunv1.RunnerConditionRegistrationFailed) {
+		// The runner that failed to register has neither runner pod nor Github runner yet.
+		// Allow to fix its spec so the controller can retry the registration.
+		return w.ValidateCreate(ctx, newObj)
+	}
+
 	var allErrs field.ErrorList
 	oldRunner, err := runtime.DefaultUnstructuredConverter.ToUnstructured(oldObj)
 	if err != nil {
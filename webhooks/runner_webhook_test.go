@@ -199,6 +199,46 @@ func TestRunnerWebhook_ValidateCreate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "runner_with_persistent_lifecycle_and_jit_registration",
+			obj: &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runner-test",
+				},
+				Spec: octorunv1.RunnerSpec{
+					URL:              "https://github.com/octorun",
+					RegistrationMode: octorunv1.RunnerRegistrationJIT,
+					Lifecycle:        octorunv1.RunnerLifecyclePersistent,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "runner_with_kubernetes_container_mode_and_service_account",
+			obj: &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runner-test",
+				},
+				Spec: octorunv1.RunnerSpec{
+					URL:                "https://github.com/octorun",
+					ContainerMode:      octorunv1.RunnerContainerModeKubernetes,
+					ServiceAccountName: "runner",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "runner_with_enterprise_spec_url",
+			obj: &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runner-test",
+				},
+				Spec: octorunv1.RunnerSpec{
+					URL: "https://github.com/enterprises/octorun",
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "runner_with_valid_spec",
 			obj: &octorunv1.Runner{
@@ -277,6 +317,66 @@ func TestRunnerWebhook_ValidateUpdate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "old_runner_has_registration_failed_and_new_runner_is_different",
+			oldObj: &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runner-test",
+				},
+				Spec: octorunv1.RunnerSpec{
+					URL: "https://github.com/octorun/notfound",
+				},
+				Status: octorunv1.RunnerStatus{
+					Phase: octorunv1.RunnerFailedPhase,
+					Conditions: []metav1.Condition{
+						{
+							Type:   octorunv1.RunnerConditionRegistrationFailed,
+							Status: metav1.ConditionTrue,
+							Reason: octorunv1.RunnerRegistrationNotFoundReason,
+						},
+					},
+				},
+			},
+			newObj: &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runner-test",
+				},
+				Spec: octorunv1.RunnerSpec{
+					URL: "https://github.com/octorun/repo",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "old_runner_has_registration_failed_and_new_runner_has_invalid_url",
+			oldObj: &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runner-test",
+				},
+				Spec: octorunv1.RunnerSpec{
+					URL: "https://github.com/octorun/notfound",
+				},
+				Status: octorunv1.RunnerStatus{
+					Phase: octorunv1.RunnerFailedPhase,
+					Conditions: []metav1.Condition{
+						{
+							Type:   octorunv1.RunnerConditionRegistrationFailed,
+							Status: metav1.ConditionTrue,
+							Reason: octorunv1.RunnerRegistrationNotFoundReason,
+						},
+					},
+				},
+			},
+			newObj: &octorunv1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runner-test",
+				},
+				Spec: octorunv1.RunnerSpec{
+					URL: "https://gitlab.com/octorun/repo",
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+	// +kubebuilder:scaffold:imports
+)
+
+type RunnerAutoscalerWebhook struct {
+	Client client.Reader
+}
+
+// +kubebuilder:webhook:path=/validate-octorun-github-io-v1alpha2-runnerautoscaler,mutating=false,failurePolicy=fail,sideEffects=None,groups=octorun.github.io,resources=runnerautoscalers,verbs=create;update,versions=v1alpha2,name=vrunnerautoscaler.octorun.github.io,admissionReviewVersions=v1
+
+var _ webhook.CustomValidator = &RunnerAutoscalerWebhook{}
+
+func (w *RunnerAutoscalerWebhook) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&octorunv1.RunnerAutoscaler{}).
+		WithValidator(w).
+		Complete()
+}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (w *RunnerAutoscalerWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	autoscaler, ok := obj.(*octorunv1.RunnerAutoscaler)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a RunnerAutoscaler but got a %T", obj))
+	}
+
+	return w.validate(autoscaler)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (w *RunnerAutoscalerWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	if _, ok := oldObj.(*octorunv1.RunnerAutoscaler); !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a RunnerAutoscaler but got a %T", oldObj))
+	}
+
+	newAutoscaler, ok := newObj.(*octorunv1.RunnerAutoscaler)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a RunnerAutoscaler but got a %T", newObj))
+	}
+
+	return w.validate(newAutoscaler)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (w *RunnerAutoscalerWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (w *RunnerAutoscalerWebhook) validate(autoscaler *octorunv1.RunnerAutoscaler) error {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	if minRunners := autoscaler.Spec.MinRunners; minRunners != nil && *minRunners > autoscaler.Spec.MaxRunners {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("minRunners"), *minRunners, invalidMinRunnersMessage))
+	}
+
+	for i, schedule := range autoscaler.Spec.Schedules {
+		if schedule.MinRunners > autoscaler.Spec.MaxRunners {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("schedules").Index(i).Child("minRunners"), schedule.MinRunners, invalidMinRunnersMessage))
+		}
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(autoscaler.GetObjectKind().GroupVersionKind().GroupKind(), autoscaler.GetName(), allErrs)
+}
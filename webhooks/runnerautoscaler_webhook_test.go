@@ -0,0 +1,212 @@
+/*
+Copyright 2022 The Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	octorunv1 "octorun.github.io/octorun/api/v1alpha2"
+)
+
+func TestRunnerAutoscalerWebhook_ValidateCreate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+
+	tests := []struct {
+		name    string
+		obj     runtime.Object
+		wantErr bool
+	}{
+		{
+			name:    "obj_is_not_runnerautoscaler",
+			obj:     &octorunv1.RunnerSet{},
+			wantErr: true,
+		},
+		{
+			name: "runnerautoscaler_with_min_runners_greater_than_max_runners",
+			obj: &octorunv1.RunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runnerautoscaler-test",
+				},
+				Spec: octorunv1.RunnerAutoscalerSpec{
+					MinRunners: pointer.Int32(5),
+					MaxRunners: 3,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "runnerautoscaler_with_min_runners_equal_to_max_runners",
+			obj: &octorunv1.RunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runnerautoscaler-test",
+				},
+				Spec: octorunv1.RunnerAutoscalerSpec{
+					MinRunners: pointer.Int32(3),
+					MaxRunners: 3,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "runnerautoscaler_with_schedule_min_runners_greater_than_max_runners",
+			obj: &octorunv1.RunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runnerautoscaler-test",
+				},
+				Spec: octorunv1.RunnerAutoscalerSpec{
+					MinRunners: pointer.Int32(1),
+					MaxRunners: 3,
+					Schedules: []octorunv1.RunnerAutoscalerSchedule{
+						{Name: "working-hours", Start: "08:00", End: "18:00", MinRunners: 3},
+						{Name: "release", Start: "18:00", End: "20:00", MinRunners: 10},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "runnerautoscaler_with_schedule_min_runners_within_max_runners",
+			obj: &octorunv1.RunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runnerautoscaler-test",
+				},
+				Spec: octorunv1.RunnerAutoscalerSpec{
+					MinRunners: pointer.Int32(1),
+					MaxRunners: 3,
+					Schedules: []octorunv1.RunnerAutoscalerSchedule{
+						{Name: "working-hours", Start: "08:00", End: "18:00", MinRunners: 3},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "runnerautoscaler_without_min_runners",
+			obj: &octorunv1.RunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runnerautoscaler-test",
+				},
+				Spec: octorunv1.RunnerAutoscalerSpec{
+					MaxRunners: 3,
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := &RunnerAutoscalerWebhook{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					Build(),
+			}
+
+			if err := raw.ValidateCreate(context.Background(), tt.obj); (err != nil) != tt.wantErr {
+				t.Errorf("RunnerAutoscalerWebhook.ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunnerAutoscalerWebhook_ValidateUpdate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(octorunv1.AddToScheme(scheme))
+
+	tests := []struct {
+		name    string
+		oldObj  runtime.Object
+		newObj  runtime.Object
+		wantErr bool
+	}{
+		{
+			name:    "oldObj_is_not_runnerautoscaler",
+			oldObj:  &octorunv1.RunnerSet{},
+			wantErr: true,
+		},
+		{
+			name:    "newObj_is_not_runnerautoscaler",
+			oldObj:  &octorunv1.RunnerAutoscaler{},
+			newObj:  &octorunv1.RunnerSet{},
+			wantErr: true,
+		},
+		{
+			name:   "newObj_with_max_runners_less_than_min_runners",
+			oldObj: &octorunv1.RunnerAutoscaler{},
+			newObj: &octorunv1.RunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runnerautoscaler-test",
+				},
+				Spec: octorunv1.RunnerAutoscalerSpec{
+					MinRunners: pointer.Int32(2),
+					MaxRunners: 1,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "newObj_with_schedule_min_runners_greater_than_max_runners",
+			oldObj: &octorunv1.RunnerAutoscaler{},
+			newObj: &octorunv1.RunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runnerautoscaler-test",
+				},
+				Spec: octorunv1.RunnerAutoscalerSpec{
+					MaxRunners: 5,
+					Schedules: []octorunv1.RunnerAutoscalerSchedule{
+						{Name: "working-hours", Start: "08:00", End: "18:00", MinRunners: 6},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "newObj_with_valid_spec",
+			oldObj: &octorunv1.RunnerAutoscaler{},
+			newObj: &octorunv1.RunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runnerautoscaler-test",
+				},
+				Spec: octorunv1.RunnerAutoscalerSpec{
+					MinRunners: pointer.Int32(1),
+					MaxRunners: 5,
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := &RunnerAutoscalerWebhook{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					Build(),
+			}
+
+			if err := raw.ValidateUpdate(context.Background(), tt.oldObj, tt.newObj); (err != nil) != tt.wantErr {
+				t.Errorf("RunnerAutoscalerWebhook.ValidateUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
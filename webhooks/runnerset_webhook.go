@@ -89,10 +89,18 @@ func (w *RunnerSetWebhook) ValidateCreate(ctx context.Context, obj runtime.Objec
 
 	template := runnerset.Spec.Template
 	templatePath := field.NewPath("spec", "template")
-	if !matchOrgOrRepoURLRegexp.MatchString(template.Spec.URL) {
+	if !matchRunnerURLRegexp.MatchString(template.Spec.URL) {
 		allErrs = append(allErrs, field.Invalid(templatePath.Child("spec", "url"), template.Spec.URL, invalidURLMessage))
 	}
 
+	if template.Spec.Lifecycle == octorunv1.RunnerLifecyclePersistent && template.Spec.RegistrationMode == octorunv1.RunnerRegistrationJIT {
+		allErrs = append(allErrs, field.Invalid(templatePath.Child("spec", "lifecycle"), template.Spec.Lifecycle, invalidLifecycleMessage))
+	}
+
+	if template.Spec.ContainerMode == octorunv1.RunnerContainerModeKubernetes && template.Spec.ServiceAccountName != "" {
+		allErrs = append(allErrs, field.Invalid(templatePath.Child("spec", "serviceAccountName"), template.Spec.ServiceAccountName, invalidServiceAccountMessage))
+	}
+
 	if !selector.Matches(labels.Set(template.Labels)) {
 		allErrs = append(allErrs, field.Invalid(templatePath.Child("metadata", "labels"), template.Labels, "`selector` does not match template `labels`"))
 	}
@@ -119,10 +127,18 @@ func (w *RunnerSetWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj ru
 
 	newTemplate := newRunnerSet.Spec.Template
 	newTemplatePath := field.NewPath("spec", "template")
-	if !matchOrgOrRepoURLRegexp.MatchString(newTemplate.Spec.URL) {
+	if !matchRunnerURLRegexp.MatchString(newTemplate.Spec.URL) {
 		allErrs = append(allErrs, field.Invalid(newTemplatePath.Child("spec", "url"), newTemplate.Spec.URL, invalidURLMessage))
 	}
 
+	if newTemplate.Spec.Lifecycle == octorunv1.RunnerLifecyclePersistent && newTemplate.Spec.RegistrationMode == octorunv1.RunnerRegistrationJIT {
+		allErrs = append(allErrs, field.Invalid(newTemplatePath.Child("spec", "lifecycle"), newTemplate.Spec.Lifecycle, invalidLifecycleMessage))
+	}
+
+	if newTemplate.Spec.ContainerMode == octorunv1.RunnerContainerModeKubernetes && newTemplate.Spec.ServiceAccountName != "" {
+		allErrs = append(allErrs, field.Invalid(newTemplatePath.Child("spec", "serviceAccountName"), newTemplate.Spec.ServiceAccountName, invalidServiceAccountMessage))
+	}
+
 	if !reflect.DeepEqual(oldRunnerSet.Spec.Selector, newRunnerSet.Spec.Selector) {
 		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "selector"), "`selector` is immutable"))
 	}
@@ -118,6 +118,11 @@ var _ = BeforeSuite(func() {
 	}).SetupWithManager(ctx, mgr)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = (&RunnerAutoscalerWebhook{
+		Client: crclient,
+	}).SetupWithManager(ctx, mgr)
+	Expect(err).NotTo(HaveOccurred())
+
 	// +kubebuilder:scaffold:webhook
 
 	go func() {